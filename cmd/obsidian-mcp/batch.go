@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+func handleBatch(ctx context.Context, req *mcp.CallToolRequest, input BatchInput) (*mcp.CallToolResult, BatchOutput, error) {
+	if len(input.Operations) == 0 {
+		return &mcp.CallToolResult{IsError: true}, BatchOutput{}, fmt.Errorf("operations cannot be empty")
+	}
+
+	tx := fileSystem.Begin()
+	tx.SetCause("batch", input)
+	output := BatchOutput{Results: []BatchResult{}, Changes: []types.NoteChange{}}
+	failed := 0
+
+	for i, op := range input.Operations {
+		result := BatchResult{
+			Index:   i + 1,
+			Op:      strings.ToLower(strings.TrimSpace(op.Op)),
+			Path:    strings.TrimSpace(op.Path),
+			NewPath: strings.TrimSpace(op.NewPath),
+		}
+		if failed > 0 {
+			result.Error = "skipped: an earlier operation failed"
+		} else if n, err := stageBatchOperation(tx, result, op); err != nil {
+			result.Error = err.Error()
+			failed = result.Index
+		} else {
+			result.Success = true
+			result.Replacements = n
+		}
+		output.Results = append(output.Results, result)
+	}
+
+	if failed > 0 {
+		output.Message = fmt.Sprintf("Batch not applied: operation %d failed; no notes were changed", failed)
+		return &mcp.CallToolResult{IsError: true}, output, nil
+	}
+
+	err := snapshotBefore("batch")
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		for i := range output.Results {
+			output.Results[i].Success = false
+		}
+		output.Message = "Batch not applied: " + err.Error()
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+
+	for i, result := range output.Results {
+		if result.Op == "delete" {
+			continue
+		}
+		path := result.Path
+		if result.Op == "rename" {
+			path = result.NewPath
+		}
+		output.Results[i].Version = tx.Version(path)
+	}
+
+	output.Success = true
+	output.Changes = tx.Changes(false)
+	output.Message = fmt.Sprintf("Applied %d operations, changing %d notes", len(output.Results), len(output.Changes))
+	return nil, output, nil
+}
+
+// stageBatchOperation validates an operation against the notes staged in
+// tx so far and stages its changes. It returns the number of replacements
+// for edits.
+func stageBatchOperation(tx *filesystem.Tx, result BatchResult, op BatchOperation) (int, error) {
+	if result.Path == "" {
+		return 0, fmt.Errorf("path cannot be empty")
+	}
+
+	switch result.Op {
+	case "write":
+		return 0, tx.WriteNote(types.NoteWriteParams{
+			Path:            result.Path,
+			Content:         op.Content,
+			Frontmatter:     op.Frontmatter,
+			Mode:            strings.ToLower(strings.TrimSpace(op.Mode)),
+			Separator:       op.Separator,
+			CreateIfMissing: op.CreateIfMissing,
+			Heading:         op.Heading,
+			IfMatch:         op.IfMatch,
+		})
+	case "edit":
+		replacements := 0
+		err := tx.UpdateNote(result.Path, op.IfMatch, func(content string) (string, error) {
+			updated, n, err := editNote(content, EditInput{
+				OldText:     op.OldText,
+				NewText:     op.NewText,
+				ReplaceAll:  op.ReplaceAll,
+				Frontmatter: op.Frontmatter,
+			})
+			replacements = n
+			return updated, err
+		})
+		return replacements, err
+	case "rename":
+		if result.NewPath == "" {
+			return 0, fmt.Errorf("newPath cannot be empty")
+		}
+		return 0, tx.MoveNote(types.MoveNoteParams{
+			OldPath:   result.Path,
+			NewPath:   result.NewPath,
+			Overwrite: op.Overwrite,
+			IfMatch:   op.IfMatch,
+		})
+	case "delete":
+		if op.Confirm != "yes" {
+			return 0, fmt.Errorf("deletion not confirmed: set confirm='yes' to proceed")
+		}
+		return 0, tx.DeleteNote(types.DeleteNoteParams{
+			Path:        result.Path,
+			ConfirmPath: result.Path,
+			IfMatch:     op.IfMatch,
+		})
+	default:
+		return 0, fmt.Errorf("unknown operation: %q (use write, edit, rename or delete)", result.Op)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+func TestHandleBatch(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "inbox/alpha.md", "---\nstatus: draft\n---\n# Alpha\n")
+	writeTestNote(t, vaultPath, "inbox/beta.md", "# Beta\n")
+	writeTestNote(t, vaultPath, "old.md", "obsolete\n")
+
+	_, got, err := handleBatch(context.Background(), nil, BatchInput{Operations: []BatchOperation{
+		{Op: "write", Path: "projects/index.md", Content: "# Index\n- [[alpha]]\n- [[beta]]\n"},
+		{Op: "rename", Path: "inbox/alpha.md", NewPath: "projects/alpha.md"},
+		{Op: "rename", Path: "inbox/beta.md", NewPath: "projects/beta.md"},
+		{Op: "edit", Path: "projects/alpha.md", Frontmatter: map[string]any{"status": "active"}},
+		{Op: "edit", Path: "projects/beta.md", OldText: "Beta", NewText: "Beta project"},
+		{Op: "delete", Path: "old.md", Confirm: "yes"},
+	}})
+	if err != nil {
+		t.Fatalf("handleBatch() error = %v", err)
+	}
+	if !got.Success || len(got.Results) != 6 {
+		t.Fatalf("handleBatch() = %+v", got)
+	}
+	if got.Results[4].Replacements != 1 || got.Results[1].Version == "" {
+		t.Errorf("handleBatch() results = %+v", got.Results)
+	}
+	wantChanges := []types.NoteChange{
+		{Path: "projects/index.md", Action: "create"},
+		{Path: "projects/alpha.md", Action: "rename", From: "inbox/alpha.md"},
+		{Path: "projects/beta.md", Action: "rename", From: "inbox/beta.md"},
+		{Path: "old.md", Action: "delete", Trash: "system:old.md"},
+	}
+	if !reflect.DeepEqual(got.Changes, wantChanges) {
+		t.Errorf("handleBatch().Changes = %+v, want %+v", got.Changes, wantChanges)
+	}
+
+	for path, want := range map[string]string{
+		"projects/index.md": "# Index\n- [[alpha]]\n- [[beta]]\n",
+		"projects/alpha.md": "---\nstatus: active\n---\n# Alpha\n",
+		"projects/beta.md":  "# Beta project\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(vaultPath, path))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", path, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", path, data, want)
+		}
+	}
+	for _, path := range []string{"inbox/alpha.md", "inbox/beta.md", "old.md"} {
+		if fileSystem.Exists(path) {
+			t.Errorf("%s still exists", path)
+		}
+	}
+}
+
+func TestHandleBatchRejectsInvalidOperation(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "alpha.md", "# Alpha\n")
+
+	result, got, err := handleBatch(context.Background(), nil, BatchInput{Operations: []BatchOperation{
+		{Op: "write", Path: "index.md", Content: "# Index\n"},
+		{Op: "rename", Path: "alpha.md", NewPath: "projects/alpha.md"},
+		{Op: "edit", Path: "alpha.md", OldText: "Alpha", NewText: "A"},
+		{Op: "delete", Path: "index.md", Confirm: "yes"},
+	}})
+	if err != nil {
+		t.Fatalf("handleBatch() error = %v", err)
+	}
+	if result == nil || !result.IsError || got.Success {
+		t.Fatalf("handleBatch() = %+v, want an error result", got)
+	}
+
+	res := got.Results
+	if !res[0].Success || !res[1].Success || res[2].Success || res[3].Success {
+		t.Errorf("handleBatch() results = %+v", res)
+	}
+	if !strings.Contains(res[2].Error, "file not found") || !strings.Contains(res[3].Error, "skipped") {
+		t.Errorf("handleBatch() errors = %q, %q", res[2].Error, res[3].Error)
+	}
+
+	// Nothing is written when any operation fails.
+	if fileSystem.Exists("index.md") || fileSystem.Exists("projects/alpha.md") || !fileSystem.Exists("alpha.md") {
+		t.Error("handleBatch() changed the vault despite the failed operation")
+	}
+}
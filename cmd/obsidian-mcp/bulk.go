@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/pathfilter"
+	"github.com/taigrr/obsidian-mcp/internal/schema"
+	"github.com/taigrr/obsidian-mcp/internal/tagtree"
+	"github.com/taigrr/obsidian-mcp/internal/values"
+)
+
+// defaultBulkMaxFiles is how many notes bulk_frontmatter changes at most
+// unless maxFiles says otherwise.
+const defaultBulkMaxFiles = 100
+
+func handleBulkFrontmatter(ctx context.Context, req *mcp.CallToolRequest, input BulkFrontmatterInput) (*mcp.CallToolResult, BulkFrontmatterOutput, error) {
+	if len(input.Operations) == 0 {
+		return &mcp.CallToolResult{IsError: true}, BulkFrontmatterOutput{}, fmt.Errorf("operations cannot be empty")
+	}
+	folder := strings.Trim(strings.TrimSpace(input.Folder), "/")
+	glob := strings.TrimSpace(input.Glob)
+	tag := strings.TrimSpace(input.Tag)
+	if folder == "" && glob == "" && tag == "" && len(input.Where) == 0 {
+		return &mcp.CallToolResult{IsError: true}, BulkFrontmatterOutput{},
+			fmt.Errorf("a selector is required: folder, glob, tag or where (use glob '**' for every note)")
+	}
+	for _, filter := range input.Where {
+		if err := checkFilter(filter); err != nil {
+			return &mcp.CallToolResult{IsError: true}, BulkFrontmatterOutput{}, err
+		}
+	}
+
+	notes, err := loadVaultNotes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, BulkFrontmatterOutput{}, err
+	}
+	var paths []string
+	for _, note := range notes {
+		switch {
+		case folder != "" && !strings.HasPrefix(note.Path, folder+"/"):
+		case glob != "" && !pathfilter.MatchGlob(glob, note.Path):
+		case tag != "" && !tagtree.MatchesAny(note.Tags, tag, input.NestedTags):
+		case !matchesFilters(note.Note.Frontmatter, input.Where):
+		default:
+			paths = append(paths, note.Path)
+		}
+	}
+
+	maxFiles := input.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultBulkMaxFiles
+	}
+	if len(paths) > maxFiles {
+		return &mcp.CallToolResult{IsError: true}, BulkFrontmatterOutput{Matched: len(paths)},
+			fmt.Errorf("%d notes match, more than maxFiles (%d); narrow the selector or raise maxFiles", len(paths), maxFiles)
+	}
+
+	output := BulkFrontmatterOutput{Matched: len(paths), DryRun: input.DryRun, Files: []BulkFrontmatterFile{}}
+	if len(paths) == 0 {
+		output.Success = true
+		output.Message = "No notes match the selector"
+		return nil, output, nil
+	}
+
+	if !input.DryRun {
+		if err := snapshotBefore("bulk_frontmatter"); err != nil {
+			return &mcp.CallToolResult{IsError: true}, output, err
+		}
+	}
+
+	ops := frontmatterOperations(input.Operations)
+	handler := fileSystem.Frontmatter()
+	index := make(map[string]int, len(paths))
+	failed := 0
+	changes, err := fileSystem.Apply(paths, input.DryRun, func(tx *filesystem.Tx) error {
+		tx.SetCause("bulk_frontmatter", input)
+		for _, path := range paths {
+			file := BulkFrontmatterFile{Path: path}
+			err := tx.UpdateNote(path, "", func(content string) (string, error) {
+				return handler.ApplyOperations(content, ops)
+			})
+			if err != nil {
+				file.Error = err.Error()
+				failed++
+			}
+			index[path] = len(output.Files)
+			output.Files = append(output.Files, file)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d notes failed; no notes were changed", failed, len(paths))
+		}
+		return nil
+	})
+	if err != nil {
+		// Schema violations are found when the changes are committed, for
+		// all notes at once.
+		errs := []error{err}
+		var joined interface{ Unwrap() []error }
+		if errors.As(err, &joined) {
+			errs = joined.Unwrap()
+		}
+		for _, e := range errs {
+			var schemaErr *schema.Error
+			if !errors.As(e, &schemaErr) {
+				continue
+			}
+			if i, ok := index[schemaErr.Path]; ok {
+				output.Files[i].Error = schemaErr.Error()
+			}
+		}
+		output.Message = "Not applied: " + err.Error()
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+
+	for _, change := range changes {
+		if i, ok := index[change.Path]; ok {
+			output.Files[i].Changed = true
+		}
+	}
+	output.Success = true
+	output.Changed = len(changes)
+	output.Changes = changes
+	if input.DryRun {
+		output.Message = fmt.Sprintf("Would change %d of %d matching notes", len(changes), len(paths))
+	} else {
+		output.Message = fmt.Sprintf("Changed %d of %d matching notes", len(changes), len(paths))
+	}
+	return nil, output, nil
+}
+
+// checkFilter reports an invalid frontmatter filter.
+func checkFilter(filter FrontmatterFilter) error {
+	if strings.TrimSpace(filter.Key) == "" {
+		return fmt.Errorf("where: key cannot be empty")
+	}
+	switch filter.Op {
+	case "", "eq", "ne", "exists", "missing", "contains", "gt", "gte", "lt", "lte":
+		return nil
+	}
+	return fmt.Errorf("where: unknown op %q (use eq, ne, exists, missing, contains, gt, gte, lt or lte)", filter.Op)
+}
+
+// matchesFilters reports whether frontmatter matches every filter.
+func matchesFilters(fm map[string]any, filters []FrontmatterFilter) bool {
+	for _, filter := range filters {
+		if !matchesFilter(fm[strings.TrimSpace(filter.Key)], filter) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesFilter(value any, filter FrontmatterFilter) bool {
+	switch filter.Op {
+	case "exists":
+		return value != nil
+	case "missing":
+		return value == nil
+	case "", "eq":
+		return valueEquals(value, filter.Value)
+	case "ne":
+		return !valueEquals(value, filter.Value)
+	case "contains":
+		if items, ok := value.([]any); ok {
+			for _, item := range items {
+				if valueEquals(item, filter.Value) {
+					return true
+				}
+			}
+			return false
+		}
+		s, ok := value.(string)
+		return ok && strings.Contains(strings.ToLower(s), strings.ToLower(fmt.Sprint(filter.Value)))
+	}
+
+	cmp, ok := compareValues(value, filter.Value)
+	if !ok {
+		return false
+	}
+	switch filter.Op {
+	case "gt":
+		return cmp > 0
+	case "gte":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+// valueEquals compares a frontmatter value with a filter value, which
+// comes from JSON: numbers and dates compare by value and everything else
+// by its text.
+func valueEquals(value, want any) bool {
+	if value == nil || want == nil {
+		return value == want
+	}
+	if cmp, ok := compareValues(value, want); ok {
+		return cmp == 0
+	}
+	return fmt.Sprint(value) == fmt.Sprint(want)
+}
+
+// compareValues orders two numbers, two dates or two strings.
+func compareValues(a, b any) (int, bool) {
+	if x, ok := values.Number(a); ok {
+		y, ok := values.Number(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if x, ok := values.Time(a); ok {
+		if y, ok := values.Time(b); ok {
+			return x.Compare(y), true
+		}
+	}
+	x, ok := a.(string)
+	y, ok2 := b.(string)
+	if !ok || !ok2 {
+		return 0, false
+	}
+	return strings.Compare(x, y), true
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandleBulkFrontmatter(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "weekly/2024-04-29.md", "---\ndate: 2024-04-29\ntags: [weekly]\n---\n# Week\n")
+	writeTestNote(t, vaultPath, "weekly/2024-05-06.md", "---\ndate: 2024-05-06\ntags: [weekly]\n---\n# Week\n")
+	writeTestNote(t, vaultPath, "weekly/2024-05-13.md", "---\ndate: 2024-05-13\ntags: [weekly, draft]\n---\n# Week\n")
+	writeTestNote(t, vaultPath, "daily/2024-05-07.md", "---\ndate: 2024-05-07\n---\n# Day\n")
+
+	input := BulkFrontmatterInput{
+		Tag: "weekly",
+		Where: []FrontmatterFilter{
+			{Key: "date", Op: "gte", Value: "2024-05-01"},
+			{Key: "date", Op: "lt", Value: "2024-06-01"},
+		},
+		Operations: []FrontmatterOperation{
+			{Op: "set", Key: "reviewed", Value: true},
+			{Op: "remove", Key: "tags", Value: "draft"},
+		},
+		DryRun: true,
+	}
+
+	_, got, err := handleBulkFrontmatter(context.Background(), nil, input)
+	if err != nil {
+		t.Fatalf("handleBulkFrontmatter() dry run error = %v", err)
+	}
+	if !got.Success || got.Matched != 2 || got.Changed != 2 || len(got.Changes) != 2 || got.Changes[0].Diff == "" {
+		t.Fatalf("handleBulkFrontmatter() dry run = %+v", got)
+	}
+	data, err := os.ReadFile(filepath.Join(vaultPath, "weekly/2024-05-06.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "reviewed") {
+		t.Errorf("dry run wrote the note: %q", data)
+	}
+
+	input.DryRun = false
+	_, got, err = handleBulkFrontmatter(context.Background(), nil, input)
+	if err != nil {
+		t.Fatalf("handleBulkFrontmatter() error = %v", err)
+	}
+	if !got.Success || got.Changed != 2 || len(got.Files) != 2 {
+		t.Fatalf("handleBulkFrontmatter() = %+v", got)
+	}
+
+	for path, want := range map[string]string{
+		"weekly/2024-04-29.md": "---\ndate: 2024-04-29\ntags: [weekly]\n---\n# Week\n",
+		"weekly/2024-05-06.md": "---\ndate: 2024-05-06\ntags: [weekly]\nreviewed: true\n---\n# Week\n",
+		"weekly/2024-05-13.md": "---\ndate: 2024-05-13\ntags: [weekly]\nreviewed: true\n---\n# Week\n",
+		"daily/2024-05-07.md":  "---\ndate: 2024-05-07\n---\n# Day\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(vaultPath, path))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", path, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", path, data, want)
+		}
+	}
+}
+
+func TestHandleBulkFrontmatterMaxFiles(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "a.md", "# A\n")
+	writeTestNote(t, vaultPath, "b.md", "# B\n")
+
+	result, got, err := handleBulkFrontmatter(context.Background(), nil, BulkFrontmatterInput{
+		Glob:       "**",
+		Operations: []FrontmatterOperation{{Op: "set", Key: "status", Value: "done"}},
+		MaxFiles:   1,
+	})
+	if err == nil || result == nil || !result.IsError || got.Matched != 2 {
+		t.Fatalf("handleBulkFrontmatter() = %+v, %v, want maxFiles error", got, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "a.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "# A\n" {
+		t.Errorf("a.md = %q, want it unchanged", data)
+	}
+}
+
+func TestHandleBulkFrontmatterFailedNote(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "projects/a.md", "---\nowner: me\n---\n# A\n")
+	writeTestNote(t, vaultPath, "projects/b.md", "---\nowner: me\nassignee: you\n---\n# B\n")
+
+	result, got, err := handleBulkFrontmatter(context.Background(), nil, BulkFrontmatterInput{
+		Folder:     "projects",
+		Operations: []FrontmatterOperation{{Op: "rename", Key: "owner", NewKey: "assignee"}},
+	})
+	if err == nil || result == nil || !result.IsError {
+		t.Fatalf("handleBulkFrontmatter() error = %v, want a failed note", err)
+	}
+	if len(got.Files) != 2 || got.Files[0].Error != "" || !strings.Contains(got.Files[1].Error, "already exists") {
+		t.Errorf("handleBulkFrontmatter() files = %+v", got.Files)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "projects/a.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "---\nowner: me\n---\n# A\n" {
+		t.Errorf("a.md = %q, want it unchanged", data)
+	}
+}
+
+func TestMatchesFilters(t *testing.T) {
+	fm := map[string]any{"status": "active", "rating": 4, "tags": []any{"a", "b"}}
+
+	tests := []struct {
+		name    string
+		filters []FrontmatterFilter
+		want    bool
+	}{
+		{"eq", []FrontmatterFilter{{Key: "status", Value: "active"}}, true},
+		{"ne", []FrontmatterFilter{{Key: "status", Op: "ne", Value: "active"}}, false},
+		{"number", []FrontmatterFilter{{Key: "rating", Op: "gte", Value: 4.0}}, true},
+		{"contains", []FrontmatterFilter{{Key: "tags", Op: "contains", Value: "b"}}, true},
+		{"missing", []FrontmatterFilter{{Key: "due", Op: "missing"}}, true},
+		{"exists", []FrontmatterFilter{{Key: "due", Op: "exists"}}, false},
+		{"all", []FrontmatterFilter{{Key: "status", Value: "active"}, {Key: "rating", Op: "lt", Value: 3.0}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesFilters(fm, tt.filters); got != tt.want {
+				t.Errorf("matchesFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
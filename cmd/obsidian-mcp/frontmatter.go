@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/frontmatter"
+)
+
+func handleFrontmatter(ctx context.Context, req *mcp.CallToolRequest, input FrontmatterInput) (*mcp.CallToolResult, FrontmatterOutput, error) {
+	path := strings.TrimSpace(input.Path)
+	if len(input.Operations) == 0 {
+		return &mcp.CallToolResult{IsError: true}, FrontmatterOutput{Path: path}, fmt.Errorf("operations cannot be empty")
+	}
+
+	ops := frontmatterOperations(input.Operations)
+	handler := fileSystem.Frontmatter()
+	var staged *filesystem.Tx
+	changes, err := fileSystem.Apply([]string{path}, input.DryRun, func(tx *filesystem.Tx) error {
+		staged = tx
+		tx.SetCause("frontmatter", input)
+		return tx.UpdateNote(path, input.IfMatch, func(content string) (string, error) {
+			return handler.ApplyOperations(content, ops)
+		})
+	})
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, FrontmatterOutput{Path: path}, err
+	}
+
+	fm := handler.ExtractFrontmatter(staged.Content(path))
+	if input.DryRun {
+		return nil, FrontmatterOutput{Success: true, Path: path, Frontmatter: fm, DryRun: true, Changes: changes}, nil
+	}
+	return nil, FrontmatterOutput{Success: true, Path: path, Frontmatter: fm, Version: staged.Version(path)}, nil
+}
+
+// frontmatterOperations converts tool operations to frontmatter package
+// operations.
+func frontmatterOperations(input []FrontmatterOperation) []frontmatter.Operation {
+	ops := make([]frontmatter.Operation, len(input))
+	for i, op := range input {
+		ops[i] = frontmatter.Operation{Op: op.Op, Key: op.Key, NewKey: op.NewKey, Value: op.Value}
+	}
+	return ops
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandleFrontmatter(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "note.md", "---\ntitle: Note\ntags: [draft, idea]\nstatus: null\nauthor: me\n---\n# Note\n")
+
+	_, got, err := handleFrontmatter(context.Background(), nil, FrontmatterInput{
+		Path: "note.md",
+		Operations: []FrontmatterOperation{
+			{Op: "unset", Key: "status"},
+			{Op: "rename", Key: "author", NewKey: "creator"},
+			{Op: "remove", Key: "tags", Value: "draft"},
+			{Op: "append", Key: "aliases", Value: []any{"First note"}},
+			{Op: "merge_patch", Value: map[string]any{"title": nil, "meta": map[string]any{"rating": 4.0}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("handleFrontmatter() error = %v", err)
+	}
+
+	want := map[string]any{
+		"tags":    []any{"idea"},
+		"creator": "me",
+		"aliases": []any{"First note"},
+		"meta":    map[string]any{"rating": 4},
+	}
+	if !got.Success || got.Version == "" || !reflect.DeepEqual(got.Frontmatter, want) {
+		t.Errorf("handleFrontmatter() = %+v, want frontmatter %v", got, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "note.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "status") || !strings.HasSuffix(string(data), "---\n# Note\n") {
+		t.Errorf("note = %q", data)
+	}
+}
+
+func TestHandleFrontmatterFailedOperation(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	content := "---\ntitle: Note\n---\n# Note\n"
+	writeTestNote(t, vaultPath, "note.md", content)
+
+	result, _, err := handleFrontmatter(context.Background(), nil, FrontmatterInput{
+		Path: "note.md",
+		Operations: []FrontmatterOperation{
+			{Op: "set", Key: "status", Value: "done"},
+			{Op: "json_patch", Value: []any{map[string]any{"op": "test", "path": "/title", "value": "Other"}}},
+		},
+	})
+	if err == nil || result == nil || !result.IsError {
+		t.Fatalf("handleFrontmatter() error = %v, want a failed test", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "note.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("note = %q, want it unchanged", data)
+	}
+}
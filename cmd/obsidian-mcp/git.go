@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/gitvault"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// maxSubjectPaths is the number of paths named in a commit subject.
+const maxSubjectPaths = 3
+
+// gitCommitHook commits the notes changed by each tool call to repo.
+// Failures are reported on stderr, as the changes are already written.
+func gitCommitHook(repo *gitvault.Repo) filesystem.CommitHook {
+	return func(tool string, changes []types.NoteChange) {
+		var paths []string
+		for _, change := range changes {
+			paths = append(paths, change.Path)
+			if change.From != "" {
+				paths = append(paths, change.From)
+			}
+		}
+		if _, err := repo.CommitPaths(commitMessage(tool, changes), paths); err != nil {
+			fmt.Fprintf(os.Stderr, "obsidian-mcp: failed to commit %s changes: %v\n", tool, err)
+		}
+	}
+}
+
+// commitMessage names the tool and the changed notes in the subject and
+// lists every change in the body.
+func commitMessage(tool string, changes []types.NoteChange) string {
+	var names, body []string
+	for _, change := range changes {
+		if len(names) < maxSubjectPaths {
+			names = append(names, change.Path)
+		}
+		if change.From != "" {
+			body = append(body, fmt.Sprintf("%s %s -> %s", change.Action, change.From, change.Path))
+		} else {
+			body = append(body, fmt.Sprintf("%s %s", change.Action, change.Path))
+		}
+	}
+
+	subject := tool + ": " + strings.Join(names, ", ")
+	if extra := len(changes) - len(names); extra > 0 {
+		subject += fmt.Sprintf(" and %d more", extra)
+	}
+	return subject + "\n\n" + strings.Join(body, "\n") + "\n"
+}
+
+func handleGitLog(ctx context.Context, req *mcp.CallToolRequest, input GitLogInput) (*mcp.CallToolResult, GitLogOutput, error) {
+	path := strings.TrimSpace(input.Path)
+	if gitRepo == nil {
+		return &mcp.CallToolResult{IsError: true}, GitLogOutput{Path: path}, gitvault.ErrNotRepository
+	}
+	if err := fileSystem.CheckPath(path); err != nil {
+		return &mcp.CallToolResult{IsError: true}, GitLogOutput{Path: path}, err
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	commits, err := gitRepo.Log(path, limit)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, GitLogOutput{Path: path}, err
+	}
+	return nil, GitLogOutput{Path: path, Commits: commits}, nil
+}
+
+func handleGitDiff(ctx context.Context, req *mcp.CallToolRequest, input GitDiffInput) (*mcp.CallToolResult, GitDiffOutput, error) {
+	path := strings.TrimSpace(input.Path)
+	from := strings.TrimSpace(input.From)
+	if from == "" {
+		from = "HEAD"
+	}
+	to := strings.TrimSpace(input.To)
+	output := GitDiffOutput{Path: path, From: from, To: to}
+
+	if gitRepo == nil {
+		return &mcp.CallToolResult{IsError: true}, output, gitvault.ErrNotRepository
+	}
+	if err := fileSystem.CheckPath(path); err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+
+	diff, err := gitRepo.Diff(path, from, to)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+	output.Diff = diff
+	return nil, output, nil
+}
+
+func handleGitRestore(ctx context.Context, req *mcp.CallToolRequest, input GitRestoreInput) (*mcp.CallToolResult, GitRestoreOutput, error) {
+	path := strings.TrimSpace(input.Path)
+	revision := strings.TrimSpace(input.Revision)
+	output := GitRestoreOutput{Path: path, Revision: revision}
+
+	if gitRepo == nil {
+		return &mcp.CallToolResult{IsError: true}, output, gitvault.ErrNotRepository
+	}
+	source := strings.TrimSpace(input.SourcePath)
+	if source == "" {
+		source = path
+	}
+	for _, p := range []string{path, source} {
+		if err := fileSystem.CheckPath(p); err != nil {
+			return &mcp.CallToolResult{IsError: true}, output, err
+		}
+	}
+
+	content, err := gitRepo.Show(revision, source)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, fmt.Errorf("failed to read %s at %s: %w", source, revision, err)
+	}
+
+	var staged *filesystem.Tx
+	changes, err := fileSystem.Apply([]string{path}, input.DryRun, func(tx *filesystem.Tx) error {
+		staged = tx
+		tx.SetCause("git_restore", input)
+		return tx.WriteNote(types.NoteWriteParams{Path: path, Content: content, IfMatch: input.IfMatch})
+	})
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+
+	output.Success = true
+	if input.DryRun {
+		output.DryRun = true
+		output.Changes = changes
+		return nil, output, nil
+	}
+	output.Version = staged.Version(path)
+	return nil, output, nil
+}
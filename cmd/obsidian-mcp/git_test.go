@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/taigrr/obsidian-mcp/internal/gitvault"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+func setupGitVault(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	vaultPath := setupTestVault(t)
+	if out, err := exec.Command("git", "init", "--quiet", vaultPath).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	repo, err := gitvault.Open(vaultPath)
+	if err != nil {
+		t.Fatalf("gitvault.Open() error = %v", err)
+	}
+	gitRepo = repo
+	t.Cleanup(func() { gitRepo = nil })
+	fileSystem.OnCommit(gitCommitHook(repo))
+	return vaultPath
+}
+
+func TestCommitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    string
+		changes []types.NoteChange
+		want    string
+	}{
+		{
+			name:    "single note",
+			tool:    "edit",
+			changes: []types.NoteChange{{Path: "a.md", Action: "modify"}},
+			want:    "edit: a.md\n\nmodify a.md\n",
+		},
+		{
+			name:    "rename",
+			tool:    "rename",
+			changes: []types.NoteChange{{Path: "b.md", Action: "rename", From: "a.md"}},
+			want:    "rename: b.md\n\nrename a.md -> b.md\n",
+		},
+		{
+			name: "many notes",
+			tool: "rename_tag",
+			changes: []types.NoteChange{
+				{Path: "1.md", Action: "modify"},
+				{Path: "2.md", Action: "modify"},
+				{Path: "3.md", Action: "modify"},
+				{Path: "4.md", Action: "modify"},
+				{Path: "5.md", Action: "delete"},
+			},
+			want: "rename_tag: 1.md, 2.md, 3.md and 2 more\n\nmodify 1.md\nmodify 2.md\nmodify 3.md\nmodify 4.md\ndelete 5.md\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitMessage(tt.tool, tt.changes); got != tt.want {
+				t.Errorf("commitMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitTools(t *testing.T) {
+	vaultPath := setupGitVault(t)
+	ctx := context.Background()
+
+	if _, _, err := handleWrite(ctx, nil, WriteInput{Path: "note.md", Content: "# Note\ndraft\n"}); err != nil {
+		t.Fatalf("handleWrite() error = %v", err)
+	}
+	if _, _, err := handleEdit(ctx, nil, EditInput{Path: "note.md", OldText: "draft", NewText: "final"}); err != nil {
+		t.Fatalf("handleEdit() error = %v", err)
+	}
+	writeTestNote(t, vaultPath, "note.md", "# Note\nfinal\nlocal change\n")
+
+	_, log, err := handleGitLog(ctx, nil, GitLogInput{Path: "note.md"})
+	if err != nil {
+		t.Fatalf("handleGitLog() error = %v", err)
+	}
+	if len(log.Commits) != 2 || log.Commits[0].Subject != "edit: note.md" || log.Commits[1].Subject != "write: note.md" {
+		t.Fatalf("handleGitLog() = %+v", log.Commits)
+	}
+
+	_, diff, err := handleGitDiff(ctx, nil, GitDiffInput{Path: "note.md"})
+	if err != nil {
+		t.Fatalf("handleGitDiff() error = %v", err)
+	}
+	if !strings.Contains(diff.Diff, "+local change") || diff.From != "HEAD" {
+		t.Errorf("handleGitDiff() = %+v", diff)
+	}
+	_, diff, _ = handleGitDiff(ctx, nil, GitDiffInput{Path: "note.md", From: log.Commits[1].Hash, To: log.Commits[0].Hash})
+	if !strings.Contains(diff.Diff, "-draft") || strings.Contains(diff.Diff, "local change") {
+		t.Errorf("handleGitDiff(from, to) = %q", diff.Diff)
+	}
+
+	_, restored, err := handleGitRestore(ctx, nil, GitRestoreInput{Path: "note.md", Revision: log.Commits[1].Hash})
+	if err != nil {
+		t.Fatalf("handleGitRestore() error = %v", err)
+	}
+	if !restored.Success || restored.Version == "" {
+		t.Errorf("handleGitRestore() = %+v", restored)
+	}
+	if data, _ := os.ReadFile(vaultPath + "/note.md"); string(data) != "# Note\ndraft\n" {
+		t.Errorf("note.md after git_restore = %q", data)
+	}
+	if _, log, _ = handleGitLog(ctx, nil, GitLogInput{Path: "note.md", Limit: 1}); len(log.Commits) != 1 || log.Commits[0].Subject != "git_restore: note.md" {
+		t.Errorf("handleGitLog() after git_restore = %+v", log.Commits)
+	}
+
+	if _, _, err := handleGitDiff(ctx, nil, GitDiffInput{Path: ".obsidian/app.json"}); err == nil {
+		t.Error("handleGitDiff(.obsidian/app.json) succeeded, want access denied")
+	}
+	if _, _, err := handleGitRestore(ctx, nil, GitRestoreInput{Path: "note.md", SourcePath: ".obsidian-mcp/journal.jsonl", Revision: "HEAD"}); err == nil {
+		t.Error("handleGitRestore(sourcePath: .obsidian-mcp/journal.jsonl) succeeded, want access denied")
+	}
+
+	if _, _, err := handleDelete(ctx, nil, DeleteInput{Path: "note.md", Confirm: "yes"}); err != nil {
+		t.Fatalf("handleDelete() error = %v", err)
+	}
+	out, _ := exec.Command("git", "-C", vaultPath, "status", "--porcelain").Output()
+	if strings.TrimSpace(string(out)) != "" {
+		t.Errorf("git status after delete = %q, want a clean tree", out)
+	}
+}
+
+func TestGitToolsWithoutRepository(t *testing.T) {
+	setupTestVault(t)
+	if _, _, err := handleGitLog(context.Background(), nil, GitLogInput{Path: "note.md"}); err != gitvault.ErrNotRepository {
+		t.Errorf("handleGitLog() error = %v, want ErrNotRepository", err)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/graph"
+	"github.com/taigrr/obsidian-mcp/internal/tagtree"
+)
+
+// graphFilter restricts which notes are added to the vault graph and
+// selects the frontmatter fields attached to them as attributes.
+type graphFilter struct {
+	Folder string
+	Tag    string
+	Fields []string
+}
+
+func (f graphFilter) includes(n vaultNote) bool {
+	if folder := strings.Trim(f.Folder, "/"); folder != "" && folder != "." {
+		if !strings.HasPrefix(n.Path, folder+"/") {
+			return false
+		}
+	}
+	if tag := tagtree.Normalize(f.Tag); tag != "" && !tagtree.MatchesAny(n.Tags, tag, true) {
+		return false
+	}
+	return true
+}
+
+func (f graphFilter) attrs(n vaultNote) map[string]any {
+	if len(f.Fields) == 0 {
+		return nil
+	}
+	attrs := make(map[string]any, len(f.Fields))
+	for _, field := range f.Fields {
+		if v, ok := n.Note.Frontmatter[field]; ok {
+			attrs[field] = v
+		}
+	}
+	return attrs
+}
+
+// buildVaultGraph builds the note/link/tag graph for the notes accepted by
+// filter. Links are resolved against the whole vault, but only links
+// between included notes become edges.
+func buildVaultGraph(notes []vaultNote, filter graphFilter) *graph.Graph {
+	resolver := newLinkResolver(notePaths(notes))
+
+	g := graph.New()
+	var included []vaultNote
+	for _, n := range notes {
+		if filter.includes(n) {
+			g.AddNote(n.Path, filter.attrs(n))
+			included = append(included, n)
+		}
+	}
+	for _, n := range included {
+		for _, link := range n.Links {
+			if target, ok := resolver.resolve(link); ok && g.HasNote(target) {
+				g.AddLink(n.Path, target)
+			}
+		}
+		for _, tag := range n.Tags {
+			g.AddTag(n.Path, tag)
+		}
+	}
+	return g
+}
+
+// notePaths returns the vault paths of notes.
+func notePaths(notes []vaultNote) []string {
+	paths := make([]string, 0, len(notes))
+	for _, n := range notes {
+		paths = append(paths, n.Path)
+	}
+	return paths
+}
+
+// exportGraph writes the vault graph in the given format and returns the
+// number of nodes and edges written.
+func exportGraph(w io.Writer, format graph.Format, filter graphFilter) (int, int, error) {
+	notes, err := loadVaultNotes()
+	if err != nil {
+		return 0, 0, err
+	}
+	g := buildVaultGraph(notes, filter)
+	if err := g.Write(w, format); err != nil {
+		return 0, 0, err
+	}
+	return len(g.Nodes()), len(g.Edges()), nil
+}
+
+func parseDirection(direction string) (graph.Direction, error) {
+	switch strings.ToLower(strings.TrimSpace(direction)) {
+	case "", "both":
+		return graph.Both, nil
+	case "out", "outgoing":
+		return graph.Outgoing, nil
+	case "in", "incoming", "backlinks":
+		return graph.Incoming, nil
+	default:
+		return "", fmt.Errorf("invalid direction %q: use out, in or both", direction)
+	}
+}
+
+func handleGraph(ctx context.Context, req *mcp.CallToolRequest, input GraphInput) (*mcp.CallToolResult, GraphOutput, error) {
+	mode := strings.ToLower(strings.TrimSpace(input.Mode))
+	path := strings.TrimSpace(input.Path)
+	target := strings.TrimSpace(input.Target)
+
+	direction, err := parseDirection(input.Direction)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, GraphOutput{}, err
+	}
+	opts := graph.TraversalOptions{Direction: direction, IncludeTags: input.Tags}
+
+	switch mode {
+	case "neighborhood", "path":
+		if path == "" {
+			return &mcp.CallToolResult{IsError: true}, GraphOutput{}, fmt.Errorf("path is required for mode=%s", mode)
+		}
+		if mode == "path" && target == "" {
+			return &mcp.CallToolResult{IsError: true}, GraphOutput{}, fmt.Errorf("target is required for mode=path")
+		}
+	case "centrality":
+	default:
+		return &mcp.CallToolResult{IsError: true}, GraphOutput{},
+			fmt.Errorf("invalid mode %q: use neighborhood, path or centrality", input.Mode)
+	}
+
+	notes, err := loadVaultNotes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, GraphOutput{}, err
+	}
+	g := buildVaultGraph(notes, graphFilter{})
+	output := GraphOutput{Mode: mode, TotalNotes: len(notes)}
+
+	// Notes may be given by name, like in a wiki link, as well as by path.
+	resolver := newLinkResolver(notePaths(notes))
+	if p, ok := resolver.resolve(path); ok {
+		path = p
+	}
+	if p, ok := resolver.resolve(target); ok {
+		target = p
+	}
+
+	switch mode {
+	case "neighborhood":
+		depth := input.Depth
+		if depth <= 0 {
+			depth = 2
+		}
+		hops, err := g.Neighborhood(path, depth, opts)
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true}, GraphOutput{}, err
+		}
+		output.Nodes = make([]GraphNode, 0, len(hops))
+		for _, hop := range hops {
+			output.Nodes = append(output.Nodes, GraphNode{
+				Path:     hop.ID,
+				Depth:    hop.Depth,
+				Via:      hop.Via,
+				Relation: hop.Relation,
+				Tag:      hop.Tag,
+			})
+		}
+
+	case "path":
+		steps, err := g.ShortestPath(path, target, opts)
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true}, GraphOutput{}, err
+		}
+		output.Connected = steps != nil
+		for _, step := range steps {
+			output.Path = append(output.Path, GraphStep{
+				Path:     step.ID,
+				Relation: step.Relation,
+				Tag:      step.Tag,
+			})
+		}
+
+	case "centrality":
+		limit := input.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		degrees := g.Degrees()
+		ranks := g.PageRank(0.85, 100)
+
+		scores := make([]GraphCentrality, 0, len(degrees))
+		for id, degree := range degrees {
+			scores = append(scores, GraphCentrality{
+				Path:      id,
+				InDegree:  degree.In,
+				OutDegree: degree.Out,
+				Tags:      degree.Tags,
+				PageRank:  ranks[id],
+			})
+		}
+		sort.Slice(scores, func(i, j int) bool {
+			if scores[i].PageRank != scores[j].PageRank {
+				return scores[i].PageRank > scores[j].PageRank
+			}
+			return scores[i].Path < scores[j].Path
+		})
+		if len(scores) > limit {
+			scores = scores[:limit]
+		}
+		output.Centrality = scores
+	}
+
+	return nil, output, nil
+}
+
+func handleExportGraph(ctx context.Context, req *mcp.CallToolRequest, input ExportGraphInput) (*mcp.CallToolResult, ExportGraphOutput, error) {
+	format, err := graph.ParseFormat(input.Format)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, ExportGraphOutput{}, err
+	}
+
+	var b strings.Builder
+	nodes, edges, err := exportGraph(&b, format, graphFilter{
+		Folder: strings.TrimSpace(input.Folder),
+		Tag:    input.Tag,
+		Fields: input.Fields,
+	})
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, ExportGraphOutput{}, err
+	}
+
+	return nil, ExportGraphOutput{
+		Format: string(format),
+		Nodes:  nodes,
+		Edges:  edges,
+		Graph:  b.String(),
+	}, nil
+}
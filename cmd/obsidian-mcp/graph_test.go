@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLinkResolver(t *testing.T) {
+	resolver := newLinkResolver([]string{
+		"Inbox.md",
+		"projects/Alpha.md",
+		"archive/old/alpha.md",
+		"areas/Inbox.md",
+	})
+
+	tests := []struct {
+		link   string
+		want   string
+		wantOK bool
+	}{
+		{link: "inbox", want: "Inbox.md", wantOK: true},
+		{link: "alpha", want: "projects/Alpha.md", wantOK: true},
+		{link: "archive/old/alpha", want: "archive/old/alpha.md", wantOK: true},
+		{link: "areas/inbox.md", want: "areas/Inbox.md", wantOK: true},
+		{link: "missing", wantOK: false},
+		{link: "other/alpha", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			got, ok := resolver.resolve(tt.link)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("resolve(%q) = %q, %v, want %q, %v", tt.link, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func setupGraphVault(t *testing.T) {
+	t.Helper()
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "hub.md", "Links to [[Alpha]] and [[Beta]].\n")
+	writeTestNote(t, vaultPath, "alpha.md", "---\ntags: [project]\n---\nSee [[Gamma]].\n")
+	writeTestNote(t, vaultPath, "beta.md", "Back to [[hub]].\n")
+	writeTestNote(t, vaultPath, "gamma.md", "Leaf note. [[Missing]]\n")
+	writeTestNote(t, vaultPath, "island.md", "#project\n")
+}
+
+func TestHandleGraphNeighborhood(t *testing.T) {
+	setupGraphVault(t)
+
+	_, got, err := handleGraph(context.Background(), nil, GraphInput{
+		Mode:      "neighborhood",
+		Path:      "hub.md",
+		Direction: "out",
+	})
+	if err != nil {
+		t.Fatalf("handleGraph() error = %v", err)
+	}
+
+	want := []GraphNode{
+		{Path: "alpha.md", Depth: 1, Via: "hub.md", Relation: "outgoing"},
+		{Path: "beta.md", Depth: 1, Via: "hub.md", Relation: "outgoing"},
+		{Path: "gamma.md", Depth: 2, Via: "alpha.md", Relation: "outgoing"},
+	}
+	if !reflect.DeepEqual(got.Nodes, want) {
+		t.Fatalf("handleGraph().Nodes = %#v, want %#v", got.Nodes, want)
+	}
+	if got.TotalNotes != 5 {
+		t.Errorf("handleGraph().TotalNotes = %d, want 5", got.TotalNotes)
+	}
+}
+
+func TestHandleGraphPath(t *testing.T) {
+	setupGraphVault(t)
+
+	_, got, err := handleGraph(context.Background(), nil, GraphInput{
+		Mode:   "path",
+		Path:   "island",
+		Target: "Gamma",
+		Tags:   true,
+	})
+	if err != nil {
+		t.Fatalf("handleGraph() error = %v", err)
+	}
+
+	want := []GraphStep{
+		{Path: "island.md"},
+		{Path: "alpha.md", Relation: "shared-tag", Tag: "project"},
+		{Path: "gamma.md", Relation: "outgoing"},
+	}
+	if !got.Connected || !reflect.DeepEqual(got.Path, want) {
+		t.Fatalf("handleGraph().Path = %#v (connected=%v), want %#v", got.Path, got.Connected, want)
+	}
+
+	_, got, err = handleGraph(context.Background(), nil, GraphInput{
+		Mode:   "path",
+		Path:   "island.md",
+		Target: "gamma.md",
+	})
+	if err != nil {
+		t.Fatalf("handleGraph() error = %v", err)
+	}
+	if got.Connected || len(got.Path) != 0 {
+		t.Errorf("handleGraph() without tags = %#v, want no path", got.Path)
+	}
+}
+
+func TestHandleGraphCentrality(t *testing.T) {
+	setupGraphVault(t)
+
+	_, got, err := handleGraph(context.Background(), nil, GraphInput{Mode: "centrality", Limit: 2})
+	if err != nil {
+		t.Fatalf("handleGraph() error = %v", err)
+	}
+
+	if len(got.Centrality) != 2 {
+		t.Fatalf("handleGraph().Centrality has %d entries, want 2", len(got.Centrality))
+	}
+	if got.Centrality[0].PageRank < got.Centrality[1].PageRank {
+		t.Errorf("handleGraph().Centrality is not sorted by PageRank: %#v", got.Centrality)
+	}
+}
+
+func TestHandleGraphRejectsInvalidInput(t *testing.T) {
+	setupGraphVault(t)
+
+	tests := []GraphInput{
+		{Mode: "unknown"},
+		{Mode: "neighborhood"},
+		{Mode: "path", Path: "hub.md"},
+		{Mode: "neighborhood", Path: "hub.md", Direction: "sideways"},
+		{Mode: "neighborhood", Path: "missing.md"},
+	}
+	for _, input := range tests {
+		if _, _, err := handleGraph(context.Background(), nil, input); err == nil {
+			t.Errorf("handleGraph(%+v) error = nil, want error", input)
+		}
+	}
+}
+
+func TestHandleExportGraph(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	writeTestNote(t, vaultPath, "projects/alpha.md", "---\nstatus: active\ntags: [project]\n---\nSee [[beta]] and [[outside]].\n")
+	writeTestNote(t, vaultPath, "projects/beta.md", "---\nstatus: done\n---\nBack to [[alpha]].\n")
+	writeTestNote(t, vaultPath, "outside.md", "Not exported.\n")
+
+	_, got, err := handleExportGraph(context.Background(), nil, ExportGraphInput{
+		Format: "dot",
+		Folder: "projects/",
+		Fields: []string{"status"},
+	})
+	if err != nil {
+		t.Fatalf("handleExportGraph() error = %v", err)
+	}
+
+	if got.Format != "dot" || got.Nodes != 3 || got.Edges != 3 {
+		t.Fatalf("handleExportGraph() = format %q, %d nodes, %d edges, want dot, 3, 3", got.Format, got.Nodes, got.Edges)
+	}
+	for _, want := range []string{
+		`"projects/alpha.md" [label="alpha", kind="note", "status"="active"];`,
+		`"projects/alpha.md" -> "projects/beta.md" [kind="link"];`,
+		`"projects/beta.md" -> "projects/alpha.md" [kind="link"];`,
+	} {
+		if !strings.Contains(got.Graph, want) {
+			t.Errorf("handleExportGraph() graph missing %q:\n%s", want, got.Graph)
+		}
+	}
+	if strings.Contains(got.Graph, "outside.md") {
+		t.Errorf("handleExportGraph() graph should not include notes outside the folder:\n%s", got.Graph)
+	}
+}
+
+func TestHandleExportGraphFiltersByTag(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	writeTestNote(t, vaultPath, "a.md", "#keep [[b]]\n")
+	writeTestNote(t, vaultPath, "b.md", "#keep\n")
+	writeTestNote(t, vaultPath, "c.md", "#drop [[a]]\n")
+	writeTestNote(t, vaultPath, "d.md", "#keep/nested\n")
+	writeTestNote(t, vaultPath, "e.md", "#keeper [[a]]\n")
+
+	_, got, err := handleExportGraph(context.Background(), nil, ExportGraphInput{Tag: "#keep"})
+	if err != nil {
+		t.Fatalf("handleExportGraph() error = %v", err)
+	}
+	if got.Format != "json" || got.Nodes != 5 || got.Edges != 4 {
+		t.Errorf("handleExportGraph() = format %q, %d nodes, %d edges, want json, 5, 4\n%s", got.Format, got.Nodes, got.Edges, got.Graph)
+	}
+	if !strings.Contains(got.Graph, "d.md") || strings.Contains(got.Graph, "e.md") {
+		t.Errorf("handleExportGraph() graph should include nested tags only:\n%s", got.Graph)
+	}
+}
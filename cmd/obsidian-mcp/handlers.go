@@ -3,8 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"maps"
-	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -13,27 +11,44 @@ import (
 	"sync"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/markdown"
+	"github.com/taigrr/obsidian-mcp/internal/tagtree"
+	"github.com/taigrr/obsidian-mcp/internal/tokenizer"
 	"github.com/taigrr/obsidian-mcp/internal/types"
 )
 
 func handleRead(ctx context.Context, req *mcp.CallToolRequest, input ReadInput) (*mcp.CallToolResult, ReadOutput, error) {
 	path := strings.TrimSpace(input.Path)
-	note, err := fileSystem.ReadNote(path)
+	note, version, err := fileSystem.ReadNoteVersion(path)
 	if err != nil {
 		return &mcp.CallToolResult{IsError: true}, ReadOutput{}, err
 	}
 
 	content := note.Content
 	lines := strings.Split(content, "\n")
+	startLine := 0
+
+	if anchor := strings.TrimSpace(input.Anchor); anchor != "" {
+		r, err := anchorRange(content, anchor)
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true}, ReadOutput{}, fmt.Errorf("%w in %s", err, path)
+		}
+		lines = lines[r.Start:r.End]
+		startLine = r.Start + 1
+	}
 	totalLines := len(lines)
 
 	offset := max(input.Offset, 0)
 	if offset >= totalLines {
 		return nil, ReadOutput{
-			Frontmatter: note.Frontmatter,
-			Content:     "",
-			TotalLines:  totalLines,
-			Truncated:   true,
+			Frontmatter:  note.Frontmatter,
+			InlineFields: note.InlineFields,
+			Content:      "",
+			TotalLines:   totalLines,
+			StartLine:    startLine,
+			Truncated:    true,
+			Version:      version,
 		}, nil
 	}
 
@@ -53,27 +68,55 @@ func handleRead(ctx context.Context, req *mcp.CallToolRequest, input ReadInput)
 	resultLines := lines[offset:endIdx]
 	resultContent := strings.Join(resultLines, "\n")
 
+	var lineRange *LineRange
+	if input.LineNumbers {
+		first := max(startLine, 1) + offset
+		resultContent = markdown.NumberLines(resultLines, first)
+		lineRange = &LineRange{
+			Start: first,
+			End:   first + len(resultLines) - 1,
+			Hash:  markdown.HashLines(resultLines),
+		}
+	}
+
 	return nil, ReadOutput{
-		Frontmatter: note.Frontmatter,
-		Content:     resultContent,
-		TotalLines:  totalLines,
-		Truncated:   truncated,
+		Frontmatter:  note.Frontmatter,
+		InlineFields: note.InlineFields,
+		Content:      resultContent,
+		TotalLines:   totalLines,
+		StartLine:    startLine,
+		Truncated:    truncated,
+		Range:        lineRange,
+		Version:      version,
 	}, nil
 }
 
 func handleWrite(ctx context.Context, req *mcp.CallToolRequest, input WriteInput) (*mcp.CallToolResult, WriteOutput, error) {
 	path := strings.TrimSpace(input.Path)
-	err := fileSystem.WriteNote(types.NoteWriteParams{
-		Path:        path,
-		Content:     input.Content,
-		Frontmatter: input.Frontmatter,
-		Mode:        "overwrite",
+	params := types.NoteWriteParams{
+		Path:            path,
+		Content:         input.Content,
+		Frontmatter:     input.Frontmatter,
+		Mode:            strings.ToLower(strings.TrimSpace(input.Mode)),
+		Separator:       input.Separator,
+		CreateIfMissing: input.CreateIfMissing,
+		Heading:         input.Heading,
+		IfMatch:         input.IfMatch,
+	}
+	var staged *filesystem.Tx
+	changes, err := fileSystem.Apply([]string{path}, input.DryRun, func(tx *filesystem.Tx) error {
+		staged = tx
+		tx.SetCause("write", input)
+		return tx.WriteNote(params)
 	})
 	if err != nil {
 		return &mcp.CallToolResult{IsError: true}, WriteOutput{Success: false, Path: path}, err
 	}
 
-	return nil, WriteOutput{Success: true, Path: path}, nil
+	if input.DryRun {
+		return nil, WriteOutput{Success: true, Path: path, DryRun: true, Changes: changes}, nil
+	}
+	return nil, WriteOutput{Success: true, Path: path, Version: staged.Version(path)}, nil
 }
 
 func handleDelete(ctx context.Context, req *mcp.CallToolRequest, input DeleteInput) (*mcp.CallToolResult, DeleteOutput, error) {
@@ -87,6 +130,8 @@ func handleDelete(ctx context.Context, req *mcp.CallToolRequest, input DeleteInp
 	result := fileSystem.DeleteNote(types.DeleteNoteParams{
 		Path:        path,
 		ConfirmPath: path,
+		IfMatch:     input.IfMatch,
+		DryRun:      input.DryRun,
 	})
 
 	if !result.Success {
@@ -94,7 +139,10 @@ func handleDelete(ctx context.Context, req *mcp.CallToolRequest, input DeleteInp
 			fmt.Errorf("%s", result.Message)
 	}
 
-	return nil, DeleteOutput{Success: true, Path: path}, nil
+	if input.DryRun {
+		return nil, DeleteOutput{Success: true, Path: path, DryRun: true, Changes: result.Changes}, nil
+	}
+	return nil, DeleteOutput{Success: true, Path: path, Trash: result.Trash}, nil
 }
 
 func handleRename(ctx context.Context, req *mcp.CallToolRequest, input RenameInput) (*mcp.CallToolResult, RenameOutput, error) {
@@ -105,6 +153,8 @@ func handleRename(ctx context.Context, req *mcp.CallToolRequest, input RenameInp
 		OldPath:   oldPath,
 		NewPath:   newPath,
 		Overwrite: input.Overwrite,
+		IfMatch:   input.IfMatch,
+		DryRun:    input.DryRun,
 	})
 
 	if !result.Success {
@@ -113,82 +163,68 @@ func handleRename(ctx context.Context, req *mcp.CallToolRequest, input RenameInp
 			fmt.Errorf("%s", result.Message)
 	}
 
-	return nil, RenameOutput{Success: true, OldPath: oldPath, NewPath: newPath}, nil
+	if input.DryRun {
+		return nil, RenameOutput{Success: true, OldPath: oldPath, NewPath: newPath, DryRun: true, Changes: result.Changes}, nil
+	}
+	return nil, RenameOutput{Success: true, OldPath: oldPath, NewPath: newPath, Version: result.Version}, nil
 }
 
 func handleEdit(ctx context.Context, req *mcp.CallToolRequest, input EditInput) (*mcp.CallToolResult, EditOutput, error) {
 	path := strings.TrimSpace(input.Path)
 
-	note, err := fileSystem.ReadNote(path)
+	replacements := 0
+	var staged *filesystem.Tx
+	changes, err := fileSystem.Apply([]string{path}, input.DryRun, func(tx *filesystem.Tx) error {
+		staged = tx
+		tx.SetCause("edit", input)
+		return tx.UpdateNote(path, input.IfMatch, func(content string) (string, error) {
+			updated, n, err := editNote(content, input)
+			replacements = n
+			return updated, err
+		})
+	})
 	if err != nil {
 		return &mcp.CallToolResult{IsError: true}, EditOutput{Success: false, Path: path}, err
 	}
 
+	if input.DryRun {
+		return nil, EditOutput{Success: true, Path: path, Replacements: replacements, DryRun: true, Changes: changes}, nil
+	}
+	return nil, EditOutput{Success: true, Path: path, Replacements: replacements, Version: staged.Version(path)}, nil
+}
+
+// editNote applies an edit to the raw content of a note and returns the
+// new content and the number of text replacements made.
+func editNote(content string, input EditInput) (string, int, error) {
 	replacements := 0
-	newContent := note.Content
 
 	// Handle text replacement if oldText is provided
 	if input.OldText != "" {
-		occurrences := strings.Count(note.OriginalContent, input.OldText)
+		occurrences := strings.Count(content, input.OldText)
 		if occurrences == 0 {
-			return &mcp.CallToolResult{IsError: true}, EditOutput{Success: false, Path: path},
-				fmt.Errorf("oldText not found in note")
+			return "", 0, fmt.Errorf("oldText not found in note")
 		}
 
 		if !input.ReplaceAll && occurrences > 1 {
-			return &mcp.CallToolResult{IsError: true}, EditOutput{Success: false, Path: path},
-				fmt.Errorf("found %d occurrences of oldText; use replaceAll=true or provide more specific text", occurrences)
+			return "", 0, fmt.Errorf("found %d occurrences of oldText; use replaceAll=true or provide more specific text", occurrences)
 		}
 
 		// Replace in full content (including frontmatter area)
-		var updatedFull string
 		if input.ReplaceAll {
-			updatedFull = strings.ReplaceAll(note.OriginalContent, input.OldText, input.NewText)
+			content = strings.ReplaceAll(content, input.OldText, input.NewText)
 			replacements = occurrences
 		} else {
-			updatedFull = strings.Replace(note.OriginalContent, input.OldText, input.NewText, 1)
+			content = strings.Replace(content, input.OldText, input.NewText, 1)
 			replacements = 1
 		}
-
-		// Write the raw updated content
-		fullPath, err := fileSystem.ResolvePath(path)
-		if err != nil {
-			return &mcp.CallToolResult{IsError: true}, EditOutput{Success: false, Path: path}, err
-		}
-		if err := os.WriteFile(fullPath, []byte(updatedFull), 0o644); err != nil {
-			return &mcp.CallToolResult{IsError: true}, EditOutput{Success: false, Path: path}, err
-		}
-
-		// Re-read the note if we also need to update frontmatter
-		if input.Frontmatter != nil {
-			note, err = fileSystem.ReadNote(path)
-			if err != nil {
-				return &mcp.CallToolResult{IsError: true}, EditOutput{Success: false, Path: path}, err
-			}
-			newContent = note.Content
-		}
 	}
 
-	// Handle frontmatter update if provided
+	// Handle frontmatter update if provided, merged with the existing fields
 	if input.Frontmatter != nil {
-		updatedFm := make(map[string]any)
-		if note.Frontmatter != nil {
-			maps.Copy(updatedFm, note.Frontmatter)
-		}
-		maps.Copy(updatedFm, input.Frontmatter)
-
-		err := fileSystem.WriteNote(types.NoteWriteParams{
-			Path:        path,
-			Content:     newContent,
-			Frontmatter: updatedFm,
-			Mode:        "overwrite",
-		})
-		if err != nil {
-			return &mcp.CallToolResult{IsError: true}, EditOutput{Success: false, Path: path}, err
-		}
+		updated, err := fileSystem.Frontmatter().UpdateFrontmatter(content, input.Frontmatter)
+		return updated, replacements, err
 	}
-
-	return nil, EditOutput{Success: true, Path: path, Replacements: replacements}, nil
+	return content, replacements, nil
 }
 
 func handleSearch(ctx context.Context, req *mcp.CallToolRequest, input SearchInput) (*mcp.CallToolResult, SearchOutput, error) {
@@ -209,6 +245,21 @@ func handleSearch(ctx context.Context, req *mcp.CallToolRequest, input SearchInp
 
 	offset := max(input.Offset, 0)
 
+	// Restrict the search to notes carrying the requested tag
+	var paths []string
+	if tag := strings.TrimSpace(input.Tag); tag != "" {
+		notes, err := loadVaultNotes()
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true}, SearchOutput{}, err
+		}
+		paths = []string{}
+		for _, n := range notes {
+			if tagtree.MatchesAny(n.Tags, tag, input.NestedTags) {
+				paths = append(paths, n.Path)
+			}
+		}
+	}
+
 	results, totalFiles, err := searchService.SearchAdvanced(types.SearchParamsAdvanced{
 		Query:         query,
 		UseRegex:      input.UseRegex,
@@ -216,6 +267,7 @@ func handleSearch(ctx context.Context, req *mcp.CallToolRequest, input SearchInp
 		ContextLines:  contextLines,
 		Limit:         limit,
 		Offset:        offset,
+		Paths:         paths,
 	})
 	if err != nil {
 		return &mcp.CallToolResult{IsError: true}, SearchOutput{}, err
@@ -269,11 +321,39 @@ func handleSearch(ctx context.Context, req *mcp.CallToolRequest, input SearchInp
 	}, nil
 }
 
-// Obsidian link pattern: [[note]] or [[note|alias]] or [[note#heading]]
-var linkPattern = regexp.MustCompile(`\[\[([^\]|#]+)(?:#[^\]|]*)?(?:\|[^\]]+)?\]\]`)
+// Obsidian link pattern: [[note]], [[note|alias]], [[note#heading]],
+// [[note#heading#subheading]], [[note#^block]] or [[#heading]]
+var linkPattern = regexp.MustCompile(`\[\[([^\]|#]*)(?:#([^\]|]*))?(?:\|[^\]]*)?\]\]`)
+
+// noteLink is a wiki-link split into its target note and anchor. Target is
+// empty for links to a heading or block in the same note.
+type noteLink struct {
+	Raw     string
+	Target  string
+	Heading string
+	Block   string
+}
 
-// Inline tag pattern: #tag (not inside code blocks)
-var inlineTagPattern = regexp.MustCompile(`(?:^|\s)#([a-zA-Z0-9_/-]+)`)
+func parseLinks(content string) []noteLink {
+	var links []noteLink
+	for _, match := range linkPattern.FindAllStringSubmatch(content, -1) {
+		link := noteLink{
+			Raw:    match[0],
+			Target: strings.TrimSpace(match[1]),
+		}
+		anchor := strings.TrimSpace(match[2])
+		if block, ok := strings.CutPrefix(anchor, "^"); ok {
+			link.Block = block
+		} else {
+			link.Heading = anchor
+		}
+		if link.Target == "" && link.Heading == "" && link.Block == "" {
+			continue
+		}
+		links = append(links, link)
+	}
+	return links
+}
 
 func handleRelated(ctx context.Context, req *mcp.CallToolRequest, input RelatedInput) (*mcp.CallToolResult, RelatedOutput, error) {
 	path := strings.TrimSpace(input.Path)
@@ -307,36 +387,18 @@ func handleRelated(ctx context.Context, req *mcp.CallToolRequest, input RelatedI
 		outgoingLinks = extractLinks(note.Content)
 	}
 
-	// Collect all markdown files first
-	vaultPath := fileSystem.GetVaultPath()
-	var allFiles []string
-	err = filepath.Walk(vaultPath, func(fullPath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".") {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if !strings.HasSuffix(info.Name(), ".md") {
-			return nil
-		}
-		relPath, _ := filepath.Rel(vaultPath, fullPath)
-		relPath = strings.ReplaceAll(relPath, "\\", "/")
+	// Collect all other markdown files first
+	vaultFiles, err := listVaultNotes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, RelatedOutput{}, err
+	}
+	allFiles := make([]string, 0, len(vaultFiles))
+	for _, relPath := range vaultFiles {
 		if relPath != path {
 			allFiles = append(allFiles, relPath)
 		}
-		return nil
-	})
-	if err != nil {
-		return &mcp.CallToolResult{IsError: true}, RelatedOutput{}, err
 	}
 
-	// Sort for stable ordering
-	sort.Strings(allFiles)
-
 	// Process files in parallel
 	numWorkers := max(min(runtime.NumCPU(), len(allFiles)), 1)
 
@@ -368,7 +430,7 @@ func handleRelated(ctx context.Context, req *mcp.CallToolRequest, input RelatedI
 				// Check for tag matches
 				if searchTags && len(sourceTags) > 0 {
 					otherTags := extractTags(otherNote.Frontmatter, otherNote.Content)
-					sharedTags := findSharedTags(sourceTags, otherTags)
+					sharedTags := findSharedTags(sourceTags, otherTags, input.NestedTags)
 					if len(sharedTags) > 0 {
 						resultsCh <- fileResult{
 							idx:      file.idx,
@@ -474,12 +536,9 @@ func extractTags(frontmatter map[string]any, content string) []string {
 		}
 	}
 
-	// Extract inline tags
-	matches := inlineTagPattern.FindAllStringSubmatch(content, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			tagSet[strings.ToLower(match[1])] = true
-		}
+	// Extract inline tags, skipping code, math, comments and URLs
+	for _, tag := range markdown.InlineTags(content) {
+		tagSet[strings.ToLower(tag.Name)] = true
 	}
 
 	var tags []string
@@ -492,11 +551,10 @@ func extractTags(frontmatter map[string]any, content string) []string {
 
 func extractLinks(content string) []string {
 	linkSet := make(map[string]bool)
-	matches := linkPattern.FindAllStringSubmatch(content, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
+	for _, link := range parseLinks(tokenizer.Mask(content)) {
+		if link.Target != "" {
 			// Normalize: lowercase for comparison
-			linkSet[strings.ToLower(strings.TrimSpace(match[1]))] = true
+			linkSet[strings.ToLower(link.Target)] = true
 		}
 	}
 
@@ -508,15 +566,13 @@ func extractLinks(content string) []string {
 	return links
 }
 
-func findSharedTags(tags1, tags2 []string) []string {
-	set1 := make(map[string]bool)
-	for _, t := range tags1 {
-		set1[t] = true
-	}
-
+// findSharedTags returns the tags of tags1 that also appear in tags2. With
+// nested set, a tag in tags1 is also shared when tags2 contains one of its
+// children, so "project" is shared with a note tagged "project/alpha".
+func findSharedTags(tags1, tags2 []string, nested bool) []string {
 	var shared []string
-	for _, t := range tags2 {
-		if set1[t] {
+	for _, t := range tags1 {
+		if tagtree.MatchesAny(tags2, t, nested) {
 			shared = append(shared, t)
 		}
 	}
@@ -554,32 +610,14 @@ func addRelation(existing, newRel string) string {
 }
 
 func handleTags(ctx context.Context, req *mcp.CallToolRequest, input TagsInput) (*mcp.CallToolResult, TagsOutput, error) {
-	vaultPath := fileSystem.GetVaultPath()
-
 	// Collect all markdown files
-	var allFiles []string
-	err := filepath.Walk(vaultPath, func(fullPath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".") {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if !strings.HasSuffix(info.Name(), ".md") {
-			return nil
-		}
-		relPath, _ := filepath.Rel(vaultPath, fullPath)
-		relPath = strings.ReplaceAll(relPath, "\\", "/")
-		allFiles = append(allFiles, relPath)
-		return nil
-	})
+	allFiles, err := listVaultNotes()
 	if err != nil {
 		return &mcp.CallToolResult{IsError: true}, TagsOutput{}, err
 	}
 
+	prefix := strings.TrimSpace(input.Prefix)
+
 	// Process files in parallel, sending tags via channel
 	numWorkers := max(min(runtime.NumCPU(), len(allFiles)), 1)
 
@@ -594,7 +632,12 @@ func handleTags(ctx context.Context, req *mcp.CallToolRequest, input TagsInput)
 				if err != nil {
 					continue
 				}
-				tags := extractTags(note.Frontmatter, note.Content)
+				var tags []string
+				for _, tag := range extractTags(note.Frontmatter, note.Content) {
+					if tagtree.HasPrefix(tag, prefix) {
+						tags = append(tags, tag)
+					}
+				}
 				if len(tags) > 0 {
 					tagsCh <- tags
 				}
@@ -615,11 +658,15 @@ func handleTags(ctx context.Context, req *mcp.CallToolRequest, input TagsInput)
 	// Collect tags with counts using map[string]int
 	tagCounts := make(map[string]int)
 	notesWithTags := 0
+	var noteTags [][]string
 	for tags := range tagsCh {
 		notesWithTags++
 		for _, tag := range tags {
 			tagCounts[tag]++
 		}
+		if input.Tree {
+			noteTags = append(noteTags, tags)
+		}
 	}
 
 	// Convert to sorted slice of TagInfo
@@ -631,10 +678,33 @@ func handleTags(ctx context.Context, req *mcp.CallToolRequest, input TagsInput)
 		return tagInfos[i].Tag < tagInfos[j].Tag
 	})
 
+	var tree []TagNode
+	if input.Tree {
+		tree = toTagNodes(tagtree.Build(noteTags, prefix))
+	}
+
 	return nil, TagsOutput{
 		Tags:          tagInfos,
+		Tree:          tree,
 		TotalTags:     len(tagInfos),
 		TotalNotes:    len(allFiles),
 		NotesWithTags: notesWithTags,
 	}, nil
 }
+
+func toTagNodes(nodes []*tagtree.Node) []TagNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	result := make([]TagNode, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, TagNode{
+			Name:     n.Name,
+			Tag:      n.Tag,
+			Count:    n.Count,
+			Total:    n.Total,
+			Children: toTagNodes(n.Children),
+		})
+	}
+	return result
+}
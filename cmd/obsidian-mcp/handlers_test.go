@@ -2,20 +2,26 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/taigrr/obsidian-mcp/internal/filesystem"
 	"github.com/taigrr/obsidian-mcp/internal/frontmatter"
 	"github.com/taigrr/obsidian-mcp/internal/pathfilter"
+	"github.com/taigrr/obsidian-mcp/internal/search"
+	"github.com/taigrr/obsidian-mcp/internal/types"
 )
 
 func setupTestVault(t *testing.T) string {
 	t.Helper()
 
 	vaultPath := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
 	pf := pathfilter.New(nil)
 	fh := frontmatter.New()
 	fileSystem = filesystem.New(vaultPath, pf, fh)
@@ -60,6 +66,20 @@ func TestExtractLinks(t *testing.T) {
 	}
 }
 
+func TestExtractSkipsNonProse(t *testing.T) {
+	content := "#real tag and [[Real Link]]\n" +
+		"```c\n#include <stdio.h>\n[[Code Link]]\n```\n" +
+		"Version `#2024` at https://example.com/page#anchor\n" +
+		"$#math$ <!-- #html [[Html Link]] --> %% #comment [[Comment Link]] %%\n"
+
+	if got, want := extractTags(nil, content), []string{"real"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("extractTags() = %v, want %v", got, want)
+	}
+	if got, want := extractLinks(content), []string{"real link"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("extractLinks() = %v, want %v", got, want)
+	}
+}
+
 func TestAddRelation(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -158,3 +178,241 @@ func TestHandleTagsCountsTaggedNotes(t *testing.T) {
 		t.Fatalf("handleTags() = %#v, want %#v", got, want)
 	}
 }
+
+func TestHandleTagsTreeAndPrefix(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "one.md", "---\ntags: [project, project/alpha]\n---\n")
+	writeTestNote(t, vaultPath, "two.md", "Body with #project/alpha/backend\n")
+	writeTestNote(t, vaultPath, "three.md", "#project/beta #daily\n")
+
+	_, got, err := handleTags(context.Background(), nil, TagsInput{Prefix: "project/", Tree: true})
+	if err != nil {
+		t.Fatalf("handleTags() error = %v", err)
+	}
+
+	wantTags := []TagInfo{
+		{Tag: "project/alpha", Count: 1},
+		{Tag: "project/alpha/backend", Count: 1},
+		{Tag: "project/beta", Count: 1},
+	}
+	if !reflect.DeepEqual(got.Tags, wantTags) {
+		t.Errorf("handleTags().Tags = %#v, want %#v", got.Tags, wantTags)
+	}
+
+	wantTree := []TagNode{
+		{Name: "project", Tag: "project", Count: 0, Total: 3, Children: []TagNode{
+			{Name: "alpha", Tag: "project/alpha", Count: 1, Total: 2, Children: []TagNode{
+				{Name: "backend", Tag: "project/alpha/backend", Count: 1, Total: 1},
+			}},
+			{Name: "beta", Tag: "project/beta", Count: 1, Total: 1},
+		}},
+	}
+	if !reflect.DeepEqual(got.Tree, wantTree) {
+		t.Errorf("handleTags().Tree = %#v, want %#v", got.Tree, wantTree)
+	}
+	if got.NotesWithTags != 3 || got.TotalTags != 3 {
+		t.Errorf("handleTags() notesWithTags = %d, totalTags = %d, want 3, 3", got.NotesWithTags, got.TotalTags)
+	}
+}
+
+func TestHandleRelatedNestedTags(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "source.md", "#project\n")
+	writeTestNote(t, vaultPath, "child.md", "#project/alpha\n")
+	writeTestNote(t, vaultPath, "other.md", "#projects\n")
+
+	_, got, err := handleRelated(context.Background(), nil, RelatedInput{Path: "source.md", Tags: true})
+	if err != nil {
+		t.Fatalf("handleRelated() error = %v", err)
+	}
+	if len(got.Related) != 0 {
+		t.Errorf("handleRelated() without nestedTags = %#v, want none", got.Related)
+	}
+
+	_, got, err = handleRelated(context.Background(), nil, RelatedInput{Path: "source.md", Tags: true, NestedTags: true})
+	if err != nil {
+		t.Fatalf("handleRelated() error = %v", err)
+	}
+	want := []RelatedNote{{Path: "child.md", Relation: "shared-tags", Tags: []string{"project"}}}
+	if !reflect.DeepEqual(got.Related, want) {
+		t.Errorf("handleRelated() = %#v, want %#v", got.Related, want)
+	}
+}
+
+func TestHandleSearchTagFilter(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	searchService = search.New(vaultPath, pathfilter.New(nil))
+
+	writeTestNote(t, vaultPath, "parent.md", "---\ntags: [project]\n---\nstatus update\n")
+	writeTestNote(t, vaultPath, "child.md", "#project/alpha\nstatus update\n")
+	writeTestNote(t, vaultPath, "untagged.md", "status update\n")
+
+	tests := []struct {
+		nested bool
+		want   []string
+	}{
+		{nested: false, want: []string{"parent.md"}},
+		{nested: true, want: []string{"child.md", "parent.md"}},
+	}
+
+	for _, tt := range tests {
+		_, got, err := handleSearch(context.Background(), nil, SearchInput{Query: "status", Tag: "#project", NestedTags: tt.nested})
+		if err != nil {
+			t.Fatalf("handleSearch() error = %v", err)
+		}
+		var paths []string
+		for _, r := range got.Results {
+			paths = append(paths, r.Path)
+		}
+		if !reflect.DeepEqual(paths, tt.want) {
+			t.Errorf("handleSearch(nestedTags=%v) = %v, want %v", tt.nested, paths, tt.want)
+		}
+	}
+}
+
+func TestHandleWriteAppend(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "journal.md", "---\ntitle: Journal\n---\n# Journal\n")
+
+	_, _, err := handleWrite(context.Background(), nil, WriteInput{Path: "journal.md", Content: "entry one", Mode: "append"})
+	if err != nil {
+		t.Fatalf("handleWrite(append) error = %v", err)
+	}
+	_, _, err = handleWrite(context.Background(), nil, WriteInput{Path: "journal.md", Content: "entry two", Mode: "Append"})
+	if err != nil {
+		t.Fatalf("handleWrite(Append) error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "journal.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if want := "---\ntitle: Journal\n---\n# Journal\nentry one\nentry two"; string(data) != want {
+		t.Errorf("journal.md = %q, want %q", data, want)
+	}
+
+	if _, _, err := handleWrite(context.Background(), nil, WriteInput{Path: "missing.md", Content: "x", Mode: "prepend"}); err == nil {
+		t.Error("handleWrite(prepend) on a missing note error = nil, want error")
+	}
+}
+
+func TestHandleEditIfMatch(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "note.md", "# Note\ndraft\n")
+
+	_, read, err := handleRead(context.Background(), nil, ReadInput{Path: "note.md"})
+	if err != nil {
+		t.Fatalf("handleRead() error = %v", err)
+	}
+	if read.Version == "" {
+		t.Fatal("handleRead() returned no version")
+	}
+
+	_, edited, err := handleEdit(context.Background(), nil, EditInput{Path: "note.md", OldText: "draft", NewText: "final", IfMatch: read.Version})
+	if err != nil {
+		t.Fatalf("handleEdit() error = %v", err)
+	}
+	if edited.Version == "" || edited.Version == read.Version {
+		t.Errorf("handleEdit() version = %q, want a new version", edited.Version)
+	}
+
+	// Someone else edits the note; the stale version is rejected.
+	writeTestNote(t, vaultPath, "note.md", "# Note\nfinal, edited in Obsidian\n")
+
+	_, _, err = handleEdit(context.Background(), nil, EditInput{Path: "note.md", OldText: "final", NewText: "done", IfMatch: edited.Version})
+	if err == nil || !strings.Contains(err.Error(), "+final, edited in Obsidian") {
+		t.Errorf("handleEdit() error = %v, want a conflict with a diff", err)
+	}
+	if _, _, err := handleDelete(context.Background(), nil, DeleteInput{Path: "note.md", Confirm: "yes", IfMatch: edited.Version}); err == nil {
+		t.Error("handleDelete() error = nil, want a conflict")
+	}
+	if !fileSystem.Exists("note.md") {
+		t.Error("note.md was deleted despite the conflict")
+	}
+}
+
+func TestHandleEditConcurrent(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "note.md", "---\ntitle: Note\n---\n# Note\n")
+
+	const editors = 20
+	var wg sync.WaitGroup
+	for i := range editors {
+		wg.Go(func() {
+			key := fmt.Sprintf("field%d", i)
+			if _, _, err := handleEdit(context.Background(), nil, EditInput{Path: "note.md", Frontmatter: map[string]any{key: i}}); err != nil {
+				t.Errorf("handleEdit(%s) error = %v", key, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	note, err := fileSystem.ReadNote("note.md")
+	if err != nil {
+		t.Fatalf("ReadNote() error = %v", err)
+	}
+	for i := range editors {
+		if _, ok := note.Frontmatter[fmt.Sprintf("field%d", i)]; !ok {
+			t.Errorf("frontmatter is missing field%d: %v", i, note.Frontmatter)
+		}
+	}
+	if note.Frontmatter["title"] != "Note" {
+		t.Errorf("frontmatter title = %v, want Note", note.Frontmatter["title"])
+	}
+}
+
+func TestHandleDryRun(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	original := "---\nstatus: draft\n---\n# Note\ndraft text\n"
+	writeTestNote(t, vaultPath, "note.md", original)
+
+	_, write, err := handleWrite(context.Background(), nil, WriteInput{Path: "note.md", Content: "appended", Mode: "append", DryRun: true})
+	if err != nil {
+		t.Fatalf("handleWrite(dryRun) error = %v", err)
+	}
+	if !write.DryRun || len(write.Changes) != 1 || write.Changes[0].Action != "modify" ||
+		!strings.Contains(write.Changes[0].Diff, "--- a/note.md\n+++ b/note.md\n") ||
+		!strings.Contains(write.Changes[0].Diff, "+appended") {
+		t.Errorf("handleWrite(dryRun) = %+v", write)
+	}
+
+	_, edit, err := handleEdit(context.Background(), nil, EditInput{Path: "note.md", Frontmatter: map[string]any{"status": "done"}, DryRun: true})
+	if err != nil {
+		t.Fatalf("handleEdit(dryRun) error = %v", err)
+	}
+	if len(edit.Changes) != 1 || !strings.Contains(edit.Changes[0].Diff, "-status: draft\n+status: done\n") {
+		t.Errorf("handleEdit(dryRun) = %+v", edit)
+	}
+
+	_, rename, err := handleRename(context.Background(), nil, RenameInput{Path: "note.md", NewPath: "archive/note.md", DryRun: true})
+	if err != nil {
+		t.Fatalf("handleRename(dryRun) error = %v", err)
+	}
+	if want := []types.NoteChange{{Path: "archive/note.md", Action: "rename", From: "note.md"}}; !reflect.DeepEqual(rename.Changes, want) {
+		t.Errorf("handleRename(dryRun).Changes = %+v, want %+v", rename.Changes, want)
+	}
+
+	_, del, err := handleDelete(context.Background(), nil, DeleteInput{Path: "note.md", Confirm: "yes", DryRun: true})
+	if err != nil {
+		t.Fatalf("handleDelete(dryRun) error = %v", err)
+	}
+	if want := []types.NoteChange{{Path: "note.md", Action: "delete"}}; !reflect.DeepEqual(del.Changes, want) {
+		t.Errorf("handleDelete(dryRun).Changes = %+v, want %+v", del.Changes, want)
+	}
+
+	// A failing edit fails the same way in a dry run.
+	if _, _, err := handleEdit(context.Background(), nil, EditInput{Path: "note.md", OldText: "missing", NewText: "x", DryRun: true}); err == nil {
+		t.Error("handleEdit(dryRun) with missing oldText error = nil, want error")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(vaultPath, "note.md"))
+	if string(data) != original || fileSystem.Exists("archive/note.md") {
+		t.Errorf("dry runs changed the vault: note.md = %q", data)
+	}
+}
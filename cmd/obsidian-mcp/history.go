@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/diff"
+	"github.com/taigrr/obsidian-mcp/internal/journal"
+)
+
+const defaultHistoryLimit = 20
+
+func handleHistory(ctx context.Context, req *mcp.CallToolRequest, input HistoryInput) (*mcp.CallToolResult, HistoryOutput, error) {
+	entries, err := fileSystem.History()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, HistoryOutput{}, err
+	}
+	undone := journal.UndoneBy(entries)
+
+	if input.ID != 0 {
+		entry, ok := journal.Find(entries, input.ID)
+		if !ok {
+			return &mcp.CallToolResult{IsError: true}, HistoryOutput{}, fmt.Errorf("operation not found: %d", input.ID)
+		}
+		op := historyEntry(entry, undone)
+		for i := range op.Changes {
+			op.Changes[i].Diff = entryDiff(entry, op.Changes[i].Path, op.Changes[i].From)
+		}
+		return nil, HistoryOutput{Operations: []HistoryEntry{op}, Total: 1}, nil
+	}
+
+	path := strings.TrimSpace(input.Path)
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	output := HistoryOutput{Operations: []HistoryEntry{}}
+	for _, entry := range slices.Backward(entries) {
+		if path != "" && !slices.ContainsFunc(entry.Notes, func(n journal.Note) bool { return n.Path == path }) {
+			continue
+		}
+		output.Total++
+		if len(output.Operations) < limit {
+			output.Operations = append(output.Operations, historyEntry(entry, undone))
+		}
+	}
+	return nil, output, nil
+}
+
+// historyEntry converts a journal entry for the history tool.
+func historyEntry(entry journal.Entry, undone map[int64]int64) HistoryEntry {
+	return HistoryEntry{
+		ID:       entry.ID,
+		Time:     entry.Time,
+		Tool:     entry.Tool,
+		Args:     entry.Args,
+		Changes:  slices.Clone(entry.Changes),
+		Undoes:   entry.Undoes,
+		UndoneBy: undone[entry.ID],
+	}
+}
+
+// entryDiff returns the diff of the note at path made by an entry. For a
+// rename, from is the note's previous path.
+func entryDiff(entry journal.Entry, path, from string) string {
+	var before, after *string
+	for _, note := range entry.Notes {
+		switch note.Path {
+		case path:
+			after = note.After
+			if from == "" {
+				before = note.Before
+			}
+		case from:
+			before = note.Before
+		}
+	}
+	if after == nil {
+		return ""
+	}
+
+	fromLabel, a := "/dev/null", ""
+	if before != nil {
+		if from == "" {
+			from = path
+		}
+		fromLabel, a = "a/"+from, *before
+	}
+	return diff.Unified(fromLabel, "b/"+path, a, *after, diff.DefaultContext)
+}
+
+func handleUndo(ctx context.Context, req *mcp.CallToolRequest, input UndoInput) (*mcp.CallToolResult, UndoOutput, error) {
+	var ids []int64
+	if input.ID != 0 {
+		ids = []int64{input.ID}
+	}
+
+	undone, changes, err := fileSystem.Undo(ids, input.Count, input.DryRun)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, UndoOutput{}, err
+	}
+
+	output := UndoOutput{Success: true, Undone: undone, Changes: changes, DryRun: input.DryRun}
+	if input.DryRun {
+		output.Message = fmt.Sprintf("Dry run: undoing %d operations would change %d notes", len(undone), len(changes))
+	} else {
+		output.Message = fmt.Sprintf("Undid %d operations, changing %d notes", len(undone), len(changes))
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandleHistoryUndo(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	ctx := context.Background()
+
+	if _, _, err := handleWrite(ctx, nil, WriteInput{Path: "note.md", Content: "# Note\n\n## Status\ndraft\n"}); err != nil {
+		t.Fatalf("handleWrite() error = %v", err)
+	}
+	if _, _, err := handleEditSection(ctx, nil, EditSectionInput{Path: "note.md", Heading: "Status", Operation: "replace", Content: "done\n"}); err != nil {
+		t.Fatalf("handleEditSection() error = %v", err)
+	}
+	writeTestNote(t, vaultPath, "other.md", "untracked\n")
+
+	_, history, err := handleHistory(ctx, nil, HistoryInput{Path: "note.md"})
+	if err != nil {
+		t.Fatalf("handleHistory() error = %v", err)
+	}
+	if history.Total != 2 || history.Operations[0].Tool != "edit_section" || history.Operations[1].Tool != "write" {
+		t.Fatalf("handleHistory() = %+v", history)
+	}
+	if !strings.Contains(string(history.Operations[0].Args), `"heading":"Status"`) {
+		t.Errorf("handleHistory() args = %s, want the tool call", history.Operations[0].Args)
+	}
+
+	_, detail, err := handleHistory(ctx, nil, HistoryInput{ID: history.Operations[0].ID})
+	if err != nil {
+		t.Fatalf("handleHistory(id) error = %v", err)
+	}
+	if diff := detail.Operations[0].Changes[0].Diff; !strings.Contains(diff, "-draft") || !strings.Contains(diff, "+done") {
+		t.Errorf("handleHistory(id) diff = %q", diff)
+	}
+
+	_, preview, err := handleUndo(ctx, nil, UndoInput{DryRun: true})
+	if err != nil {
+		t.Fatalf("handleUndo(dryRun) error = %v", err)
+	}
+	if len(preview.Changes) != 1 || !strings.Contains(preview.Changes[0].Diff, "+draft") {
+		t.Errorf("handleUndo(dryRun) = %+v", preview)
+	}
+
+	_, undone, err := handleUndo(ctx, nil, UndoInput{})
+	if err != nil {
+		t.Fatalf("handleUndo() error = %v", err)
+	}
+	if len(undone.Undone) != 1 || undone.Undone[0] != history.Operations[0].ID {
+		t.Errorf("handleUndo() = %+v", undone)
+	}
+	if data, _ := os.ReadFile(filepath.Join(vaultPath, "note.md")); string(data) != "# Note\n\n## Status\ndraft\n" {
+		t.Errorf("note.md after undo = %q", data)
+	}
+
+	_, history, _ = handleHistory(ctx, nil, HistoryInput{Limit: 1})
+	if history.Total != 3 || history.Operations[0].Tool != "undo" {
+		t.Errorf("handleHistory() after undo = %+v", history)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/markdown"
+	"github.com/taigrr/obsidian-mcp/internal/tokenizer"
+)
+
+// Link statuses reported by the links tool.
+const (
+	linkOK             = "ok"
+	linkMissingNote    = "missing-note"
+	linkMissingHeading = "missing-heading"
+	linkMissingBlock   = "missing-block"
+)
+
+// attachmentExtPattern matches file extensions of linked attachments.
+var attachmentExtPattern = regexp.MustCompile(`^\.[a-z0-9]{1,5}$`)
+
+// anchorRange returns the lines of content addressed by a link anchor:
+// either a heading path such as "Section#Subsection" or a block reference
+// such as "^block-id".
+func anchorRange(content, anchor string) (markdown.Range, error) {
+	anchor = strings.TrimPrefix(strings.TrimSpace(anchor), "#")
+	if block, ok := strings.CutPrefix(anchor, "^"); ok {
+		r, found := markdown.Block(content, block)
+		if !found {
+			return markdown.Range{}, fmt.Errorf("block not found: ^%s", block)
+		}
+		return r, nil
+	}
+
+	_, r, found := markdown.Section(content, markdown.SplitHeadingPath(anchor))
+	if !found {
+		return markdown.Range{}, fmt.Errorf("heading not found: %s", anchor)
+	}
+	return r, nil
+}
+
+// anchorStatus checks that the heading or block a link points at exists in
+// the target note's content.
+func anchorStatus(link noteLink, content string) string {
+	switch {
+	case link.Block != "":
+		if !slices.ContainsFunc(markdown.BlockIDs(content), func(id string) bool {
+			return strings.EqualFold(id, link.Block)
+		}) {
+			return linkMissingBlock
+		}
+	case link.Heading != "":
+		if _, ok := markdown.FindHeading(content, markdown.SplitHeadingPath(link.Heading)); !ok {
+			return linkMissingHeading
+		}
+	}
+	return linkOK
+}
+
+// isAttachment reports whether a link target names a non-markdown file.
+func isAttachment(target string) bool {
+	ext := strings.ToLower(filepath.Ext(target))
+	return ext != "" && ext != ".md" && attachmentExtPattern.MatchString(ext)
+}
+
+func handleLinks(ctx context.Context, req *mcp.CallToolRequest, input LinksInput) (*mcp.CallToolResult, LinksOutput, error) {
+	path := strings.TrimSpace(input.Path)
+
+	paths, err := listVaultNotes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, LinksOutput{}, err
+	}
+	resolver := newLinkResolver(paths)
+
+	sources := paths
+	if path != "" {
+		if !fileSystem.Exists(path) {
+			return &mcp.CallToolResult{IsError: true}, LinksOutput{}, fmt.Errorf("file not found: %s", path)
+		}
+		sources = []string{path}
+	}
+
+	contents := make(map[string]string)
+	readContent := func(notePath string) (string, bool) {
+		if content, ok := contents[notePath]; ok {
+			return content, true
+		}
+		note, err := fileSystem.ReadNote(notePath)
+		if err != nil {
+			return "", false
+		}
+		contents[notePath] = note.Content
+		return note.Content, true
+	}
+
+	output := LinksOutput{Links: []LinkInfo{}}
+	for _, source := range sources {
+		content, ok := readContent(source)
+		if !ok {
+			continue
+		}
+
+		for lineIdx, line := range markdown.Lines(tokenizer.Mask(content)) {
+			for _, link := range parseLinks(line) {
+				info := LinkInfo{
+					Source:  source,
+					Link:    link.Raw,
+					Target:  link.Target,
+					Heading: link.Heading,
+					Block:   link.Block,
+					Line:    lineIdx + 1,
+				}
+
+				resolved := source
+				if link.Target != "" {
+					var found bool
+					resolved, found = resolver.resolve(link.Target)
+					if !found {
+						resolved = ""
+					}
+				}
+
+				if resolved == "" && isAttachment(link.Target) {
+					// Embedded images, PDFs and other attachments are not
+					// notes; their existence is not checked.
+					continue
+				}
+
+				if resolved == "" {
+					info.Status = linkMissingNote
+				} else {
+					info.Resolved = resolved
+					targetContent, ok := readContent(resolved)
+					if !ok {
+						info.Status = linkMissingNote
+					} else {
+						info.Status = anchorStatus(link, targetContent)
+					}
+				}
+
+				output.Total++
+				if info.Status != linkOK {
+					output.Broken++
+				} else if input.BrokenOnly {
+					continue
+				}
+				output.Links = append(output.Links, info)
+			}
+		}
+	}
+
+	return nil, output, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseLinks(t *testing.T) {
+	content := "[[Note]] [[Note#Section#Sub|alias]] [[Other#^block-1]] [[#Local]] ![[image.png]] [[]]"
+
+	got := parseLinks(content)
+	want := []noteLink{
+		{Raw: "[[Note]]", Target: "Note"},
+		{Raw: "[[Note#Section#Sub|alias]]", Target: "Note", Heading: "Section#Sub"},
+		{Raw: "[[Other#^block-1]]", Target: "Other", Block: "block-1"},
+		{Raw: "[[#Local]]", Heading: "Local"},
+		{Raw: "[[image.png]]", Target: "image.png"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLinks() = %#v, want %#v", got, want)
+	}
+}
+
+func TestHandleReadAnchor(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	writeTestNote(t, vaultPath, "note.md", "---\ntitle: Note\n---\n# Top\nintro\n## Section\nbody line\n### Sub\nsub body\n## Next\n- item ^item-1\n")
+
+	tests := []struct {
+		anchor    string
+		want      string
+		startLine int
+	}{
+		{anchor: "Section", want: "## Section\nbody line\n### Sub\nsub body", startLine: 3},
+		{anchor: "Section#Sub", want: "### Sub\nsub body", startLine: 5},
+		{anchor: "#next", want: "## Next\n- item ^item-1\n", startLine: 7},
+		{anchor: "^item-1", want: "- item ^item-1", startLine: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.anchor, func(t *testing.T) {
+			_, got, err := handleRead(context.Background(), nil, ReadInput{Path: "note.md", Anchor: tt.anchor})
+			if err != nil {
+				t.Fatalf("handleRead() error = %v", err)
+			}
+			if got.Content != tt.want {
+				t.Errorf("handleRead().Content = %q, want %q", got.Content, tt.want)
+			}
+			if got.StartLine != tt.startLine {
+				t.Errorf("handleRead().StartLine = %d, want %d", got.StartLine, tt.startLine)
+			}
+		})
+	}
+
+	for _, anchor := range []string{"Missing", "^missing", "Next#Section"} {
+		if _, _, err := handleRead(context.Background(), nil, ReadInput{Path: "note.md", Anchor: anchor}); err == nil {
+			t.Errorf("handleRead(anchor=%q) error = nil, want error", anchor)
+		}
+	}
+}
+
+func TestHandleLinks(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	writeTestNote(t, vaultPath, "source.md", "See [[Target#Details]] and [[target#Nope]].\n[[Target#^abc]] [[Target#^zzz]] [[Ghost]]\n[[#Local]] ![[diagram.png]]\n## Local\n")
+	writeTestNote(t, vaultPath, "folder/target.md", "# Target\n## Details\ntext ^abc\n")
+
+	_, got, err := handleLinks(context.Background(), nil, LinksInput{Path: "source.md"})
+	if err != nil {
+		t.Fatalf("handleLinks() error = %v", err)
+	}
+
+	want := []LinkInfo{
+		{Source: "source.md", Link: "[[Target#Details]]", Target: "Target", Heading: "Details", Resolved: "folder/target.md", Line: 1, Status: linkOK},
+		{Source: "source.md", Link: "[[target#Nope]]", Target: "target", Heading: "Nope", Resolved: "folder/target.md", Line: 1, Status: linkMissingHeading},
+		{Source: "source.md", Link: "[[Target#^abc]]", Target: "Target", Block: "abc", Resolved: "folder/target.md", Line: 2, Status: linkOK},
+		{Source: "source.md", Link: "[[Target#^zzz]]", Target: "Target", Block: "zzz", Resolved: "folder/target.md", Line: 2, Status: linkMissingBlock},
+		{Source: "source.md", Link: "[[Ghost]]", Target: "Ghost", Line: 2, Status: linkMissingNote},
+		{Source: "source.md", Link: "[[#Local]]", Heading: "Local", Resolved: "source.md", Line: 3, Status: linkOK},
+	}
+	if !reflect.DeepEqual(got.Links, want) {
+		t.Fatalf("handleLinks().Links = %#v, want %#v", got.Links, want)
+	}
+	if got.Total != 6 || got.Broken != 3 {
+		t.Errorf("handleLinks() total = %d, broken = %d, want 6, 3", got.Total, got.Broken)
+	}
+
+	_, got, err = handleLinks(context.Background(), nil, LinksInput{BrokenOnly: true})
+	if err != nil {
+		t.Fatalf("handleLinks() error = %v", err)
+	}
+	if len(got.Links) != 3 || got.Broken != 3 || got.Total != 6 {
+		t.Errorf("handleLinks(brokenOnly) = %d links, total %d, broken %d, want 3, 6, 3", len(got.Links), got.Total, got.Broken)
+	}
+}
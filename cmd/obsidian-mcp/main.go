@@ -5,19 +5,29 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/charmbracelet/fang"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/spf13/cobra"
 	"github.com/taigrr/obsidian-mcp/internal/filesystem"
 	"github.com/taigrr/obsidian-mcp/internal/frontmatter"
+	"github.com/taigrr/obsidian-mcp/internal/gitvault"
+	"github.com/taigrr/obsidian-mcp/internal/graph"
+	"github.com/taigrr/obsidian-mcp/internal/journal"
 	"github.com/taigrr/obsidian-mcp/internal/pathfilter"
 	"github.com/taigrr/obsidian-mcp/internal/search"
+	"github.com/taigrr/obsidian-mcp/internal/snapshot"
 )
 
 var (
 	fileSystem    *filesystem.Service
 	searchService *search.Service
+	// gitRepo is the repository the vault is in, or nil.
+	gitRepo *gitvault.Repo
+	// snapshots is the vault's snapshot store, or nil if disabled.
+	snapshots *snapshot.Store
 )
 
 func main() {
@@ -33,6 +43,12 @@ boundaries.`,
 		Args:    cobra.MaximumNArgs(1),
 		RunE:    runServer,
 	}
+	cmd.Flags().Bool("git", false, "commit every change to the git repository the vault is in")
+	cmd.Flags().Bool("snapshots", false, "snapshot changed notes periodically and before batches (default on with --snapshot-dir, unless --git)")
+	cmd.Flags().String("snapshot-dir", "", "directory to store snapshots in, which turns snapshots on (default <vault>/.obsidian-mcp/snapshots)")
+	cmd.Flags().Duration("snapshot-interval", 30*time.Minute, "time between periodic snapshots (0 disables them)")
+	cmd.Flags().String("snapshot-retention", "last=10,hourly=24,daily=30,weekly=12", "snapshots to keep: the last n, and the newest of each of the last n hours, days and weeks")
+	cmd.AddCommand(newExportGraphCommand())
 
 	if err := fang.Execute(
 		context.Background(),
@@ -45,23 +61,152 @@ boundaries.`,
 	}
 }
 
-func runServer(cmd *cobra.Command, args []string) error {
-	var vaultPath string
+func newExportGraphCommand() *cobra.Command {
+	var (
+		format string
+		filter graphFilter
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export-graph [vault-path]",
+		Short: "Export the vault's note/link/tag graph",
+		Long: `export-graph writes the note/link/tag graph of a vault as JSON,
+Graphviz DOT or GraphML so it can be visualized and analyzed outside
+Obsidian.`,
+		Example: `obsidian-mcp export-graph ~/obsidian --format dot --folder projects | dot -Tsvg > vault.svg`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			graphFormat, err := graph.ParseFormat(format)
+			if err != nil {
+				return err
+			}
+
+			vaultPath, err := resolveVaultPath(args)
+			if err != nil {
+				return err
+			}
+			initServices(vaultPath)
+
+			w := cmd.OutOrStdout()
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create output file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			_, _, err = exportGraph(w, graphFormat, filter)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "json", "output format: json, dot or graphml")
+	cmd.Flags().StringVar(&filter.Folder, "folder", "", "only include notes inside this folder")
+	cmd.Flags().StringVar(&filter.Tag, "tag", "", "only include notes carrying this tag")
+	cmd.Flags().StringSliceVar(&filter.Fields, "field", nil, "frontmatter field to attach to note nodes (repeatable)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to this file instead of stdout")
+
+	return cmd
+}
+
+// resolveVaultPath returns the vault path given on the command line, or the
+// current directory when none was given.
+func resolveVaultPath(args []string) (string, error) {
 	if len(args) > 0 {
-		vaultPath = args[0]
-	} else {
-		var err error
-		vaultPath, err = os.Getwd()
-		if err != nil {
-			return fmt.Errorf("failed to get current directory: %w", err)
-		}
+		return args[0], nil
+	}
+	vaultPath, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
 	}
+	return vaultPath, nil
+}
 
-	// Initialize services
+// initServices initializes the services shared by the tool handlers.
+func initServices(vaultPath string) {
 	pf := pathfilter.New(nil)
 	fh := frontmatter.New()
 	fileSystem = filesystem.New(vaultPath, pf, fh)
 	searchService = search.New(vaultPath, pf)
+}
+
+// initSnapshots sets up the snapshot store from the command's flags and
+// starts taking periodic snapshots.
+func initSnapshots(cmd *cobra.Command, vaultPath string) error {
+	dir, _ := cmd.Flags().GetString("snapshot-dir")
+	if dir == "" {
+		if err := fileSystem.EnsureDataDir(); err != nil {
+			return fmt.Errorf("failed to create %s: %w", journal.Dir, err)
+		}
+		dir = filepath.Join(vaultPath, journal.Dir, "snapshots")
+	}
+	retention, _ := cmd.Flags().GetString("snapshot-retention")
+	policy, err := snapshot.ParsePolicy(retention)
+	if err != nil {
+		return err
+	}
+	pf := pathfilter.New(nil)
+	snapshots = snapshot.New(dir, fileSystem.GetVaultPath(), pf.IsAllowed, policy)
+
+	interval, _ := cmd.Flags().GetDuration("snapshot-interval")
+	if interval > 0 {
+		go takeSnapshots(cmd.Context(), interval)
+	}
+	return nil
+}
+
+// takeSnapshots snapshots the vault at start and then every interval until
+// ctx is done.
+func takeSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, _, err := snapshots.Take("periodic"); err != nil {
+			fmt.Fprintf(os.Stderr, "obsidian-mcp: snapshot failed: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runServer(cmd *cobra.Command, args []string) error {
+	vaultPath, err := resolveVaultPath(args)
+	if err != nil {
+		return err
+	}
+
+	// Initialize services
+	initServices(vaultPath)
+
+	autoCommit, _ := cmd.Flags().GetBool("git")
+	repo, err := gitvault.Open(vaultPath)
+	switch {
+	case err == nil:
+		gitRepo = repo
+		if autoCommit {
+			fileSystem.OnCommit(gitCommitHook(repo))
+		}
+	case autoCommit:
+		return fmt.Errorf("cannot commit changes: %w", err)
+	}
+
+	// Snapshots are opt-in. --snapshot-dir turns them on too, except with
+	// --git, whose history already keeps every change.
+	enabled, _ := cmd.Flags().GetBool("snapshots")
+	if !cmd.Flags().Changed("snapshots") {
+		enabled = cmd.Flags().Changed("snapshot-dir") && !autoCommit
+	}
+	if enabled {
+		if err := initSnapshots(cmd, vaultPath); err != nil {
+			return err
+		}
+	}
 
 	// Create MCP server
 	server := mcp.NewServer(&mcp.Implementation{
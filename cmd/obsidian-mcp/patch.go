@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/diff"
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// patchedNote is the planned result of a patch for one note.
+type patchedNote struct {
+	original string
+	content  string
+	created  bool
+}
+
+func handleApplyPatch(ctx context.Context, req *mcp.CallToolRequest, input ApplyPatchInput) (*mcp.CallToolResult, ApplyPatchOutput, error) {
+	files, err := diff.Parse(input.Patch)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, ApplyPatchOutput{}, fmt.Errorf("invalid patch: %w", err)
+	}
+
+	fuzz := diff.DefaultFuzz
+	if input.Fuzz != nil {
+		fuzz = max(*input.Fuzz, 0)
+	}
+
+	output := ApplyPatchOutput{Files: []PatchFileResult{}, DryRun: input.DryRun}
+	planned := make(map[string]*patchedNote)
+	var order []string
+	failed := false
+
+	for _, f := range files {
+		path := f.Path()
+		result := PatchFileResult{Path: path, Hunks: []diff.HunkResult{}}
+
+		note, err := planPatch(f, planned[path])
+		if err == nil {
+			var hunkErr error
+			var updated string
+			updated, result.Hunks, hunkErr = diff.Apply(note.content, f.Hunks, fuzz)
+			for _, h := range result.Hunks {
+				if h.Applied {
+					output.Applied++
+				} else {
+					output.Rejected++
+				}
+			}
+			note.content = updated
+			err = hunkErr
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failed = true
+		}
+
+		if planned[path] == nil && note != nil {
+			planned[path] = note
+			order = append(order, path)
+		}
+		result.Created = note != nil && note.created
+		if input.DryRun && note != nil {
+			result.Content = note.content
+		}
+		output.Files = append(output.Files, result)
+	}
+
+	if failed {
+		output.Message = fmt.Sprintf("Patch not applied: %d of %d hunks rejected; no notes were changed", output.Rejected, output.Applied+output.Rejected)
+		return &mcp.CallToolResult{IsError: true}, output, nil
+	}
+
+	if input.DryRun {
+		output.Success = true
+		output.Message = fmt.Sprintf("Patch applies cleanly: %d hunks in %d notes", output.Applied, len(order))
+		return nil, output, nil
+	}
+
+	var staged *filesystem.Tx
+	_, err = fileSystem.Apply(order, false, func(tx *filesystem.Tx) error {
+		staged = tx
+		tx.SetCause("apply_patch", input)
+		for _, path := range order {
+			if err := stagePatchedNote(tx, path, planned[path]); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+	for i := range output.Files {
+		output.Files[i].Version = staged.Version(output.Files[i].Path)
+	}
+
+	output.Success = true
+	output.Message = fmt.Sprintf("Applied %d hunks to %d notes", output.Applied, len(order))
+	return nil, output, nil
+}
+
+// planPatch returns the note a file diff applies to. Earlier diffs for the
+// same path in the patch are applied on top of each other.
+func planPatch(f diff.FileDiff, previous *patchedNote) (*patchedNote, error) {
+	switch {
+	case f.NewPath == "":
+		return nil, fmt.Errorf("deleting notes with a patch is not supported; use the delete tool")
+	case f.OldPath != "" && f.OldPath != f.NewPath:
+		return nil, fmt.Errorf("renaming notes with a patch is not supported; use the rename tool")
+	case previous != nil:
+		return previous, nil
+	}
+
+	path := f.NewPath
+	if f.OldPath == "" {
+		if fileSystem.Exists(path) {
+			return nil, fmt.Errorf("cannot create %s: note already exists", path)
+		}
+		return &patchedNote{created: true}, nil
+	}
+
+	note, err := fileSystem.ReadNote(path)
+	if err != nil {
+		return nil, err
+	}
+	return &patchedNote{original: note.OriginalContent, content: note.OriginalContent}, nil
+}
+
+// stagePatchedNote stages a planned note, refusing to overwrite changes
+// made since the patch was checked.
+func stagePatchedNote(tx *filesystem.Tx, path string, note *patchedNote) error {
+	if note.created {
+		if fileSystem.Exists(path) {
+			return fmt.Errorf("note was created concurrently")
+		}
+		return tx.WriteNote(types.NoteWriteParams{Path: path, Content: note.content})
+	}
+
+	return tx.UpdateNote(path, "", func(current string) (string, error) {
+		if current != note.original {
+			return "", fmt.Errorf("note changed while the patch was applied")
+		}
+		return note.content, nil
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPatch = `--- a/notes/one.md
++++ b/notes/one.md
+@@ -3,3 +3,3 @@
+ ---
+ # One
+-draft
++final
+--- /dev/null
++++ b/notes/two.md
+@@ -0,0 +1,2 @@
++# Two
++created by patch
+`
+
+func TestHandleApplyPatch(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "notes/one.md", "---\nstatus: wip\n---\n# One\ndraft\n")
+
+	_, dry, err := handleApplyPatch(context.Background(), nil, ApplyPatchInput{Patch: testPatch, DryRun: true})
+	if err != nil {
+		t.Fatalf("handleApplyPatch(dryRun) error = %v", err)
+	}
+	if !dry.Success || dry.Applied != 2 || len(dry.Files) != 2 {
+		t.Fatalf("handleApplyPatch(dryRun) = %+v", dry)
+	}
+	if dry.Files[0].Content != "---\nstatus: wip\n---\n# One\nfinal\n" || !dry.Files[1].Created {
+		t.Errorf("handleApplyPatch(dryRun).Files = %+v", dry.Files)
+	}
+	if fileSystem.Exists("notes/two.md") {
+		t.Fatal("dry run created notes/two.md")
+	}
+
+	_, got, err := handleApplyPatch(context.Background(), nil, ApplyPatchInput{Patch: testPatch})
+	if err != nil {
+		t.Fatalf("handleApplyPatch() error = %v", err)
+	}
+	if !got.Success || got.Files[0].Content != "" {
+		t.Errorf("handleApplyPatch() = %+v", got)
+	}
+
+	for path, want := range map[string]string{
+		"notes/one.md": "---\nstatus: wip\n---\n# One\nfinal\n",
+		"notes/two.md": "# Two\ncreated by patch\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(vaultPath, path))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", path, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", path, data, want)
+		}
+	}
+}
+
+func TestHandleApplyPatchRejectsConflicts(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "notes/one.md", "# One\nrewritten\n")
+
+	result, got, err := handleApplyPatch(context.Background(), nil, ApplyPatchInput{Patch: testPatch})
+	if err != nil {
+		t.Fatalf("handleApplyPatch() error = %v", err)
+	}
+	if result == nil || !result.IsError || got.Success {
+		t.Fatalf("handleApplyPatch() = %+v, want an error result", got)
+	}
+	if got.Rejected != 1 || got.Applied != 1 {
+		t.Errorf("handleApplyPatch() applied = %d, rejected = %d, want 1, 1", got.Applied, got.Rejected)
+	}
+	if hunks := got.Files[0].Hunks; len(hunks) != 1 || hunks[0].Applied || !strings.Contains(hunks[0].Error, "context does not match") {
+		t.Errorf("handleApplyPatch().Files[0].Hunks = %+v", hunks)
+	}
+
+	// Nothing is written when any hunk is rejected.
+	if fileSystem.Exists("notes/two.md") {
+		t.Error("notes/two.md was created despite the rejected hunk")
+	}
+	data, _ := os.ReadFile(filepath.Join(vaultPath, "notes/one.md"))
+	if string(data) != "# One\nrewritten\n" {
+		t.Errorf("notes/one.md = %q, want it unchanged", data)
+	}
+}
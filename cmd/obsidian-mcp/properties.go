@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/properties"
+)
+
+func handleProperties(ctx context.Context, req *mcp.CallToolRequest, input PropertiesInput) (*mcp.CallToolResult, PropertiesOutput, error) {
+	folder := strings.Trim(strings.TrimSpace(input.Path), "/")
+
+	declared, err := fileSystem.PropertyTypes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, PropertiesOutput{}, err
+	}
+
+	notes, err := loadVaultNotes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, PropertiesOutput{}, err
+	}
+
+	counts := make(map[string]int)
+	inferred := make(map[string]map[string]int)
+	for _, note := range notes {
+		if folder != "" && !strings.HasPrefix(note.Path, folder+"/") {
+			continue
+		}
+		for name, value := range note.Note.Frontmatter {
+			counts[name]++
+			if t := properties.Infer(name, value); t != "" {
+				if inferred[name] == nil {
+					inferred[name] = make(map[string]int)
+				}
+				inferred[name][t]++
+			}
+		}
+	}
+	if folder == "" {
+		// Obsidian keeps the types of properties no note uses any more.
+		for name := range declared {
+			if _, ok := counts[name]; !ok {
+				counts[name] = 0
+			}
+		}
+	}
+
+	infos := make([]PropertyInfo, 0, len(counts))
+	for name, count := range counts {
+		info := PropertyInfo{Name: name, Count: count}
+		if t, ok := declared[name]; ok {
+			info.Type, info.Declared = t, true
+		} else {
+			info.Type = mostCommon(inferred[name])
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return nil, PropertiesOutput{Properties: infos, Total: len(infos)}, nil
+}
+
+// mostCommon returns the key with the highest count, preferring the
+// alphabetically first on ties.
+func mostCommon(counts map[string]int) string {
+	best := ""
+	for key, count := range counts {
+		if best == "" || count > counts[best] || (count == counts[best] && key < best) {
+			best = key
+		}
+	}
+	return best
+}
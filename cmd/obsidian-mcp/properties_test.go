@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/taigrr/obsidian-mcp/internal/properties"
+)
+
+func TestHandleWriteCoercesProperties(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, properties.File, `{"types":{"due":"date","rating":"number","done":"checkbox","tags":"tags"}}`)
+
+	_, _, err := handleWrite(context.Background(), nil, WriteInput{
+		Path:        "task.md",
+		Content:     "# Task\n",
+		Frontmatter: map[string]any{"due": "2024-01-05", "rating": "3", "done": "false", "tags": "#project", "code": "007"},
+	})
+	if err != nil {
+		t.Fatalf("handleWrite() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "task.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	want := "---\ncode: \"007\"\ndone: false\ndue: 2024-01-05\nrating: 3\ntags:\n  - project\n---\n# Task\n"
+	if string(data) != want {
+		t.Errorf("task.md = %q, want %q", data, want)
+	}
+
+	result, _, err := handleEdit(context.Background(), nil, EditInput{Path: "task.md", Frontmatter: map[string]any{"rating": "high"}})
+	if err == nil || result == nil || !result.IsError {
+		t.Errorf("handleEdit() error = %v, want an error for a non-number rating", err)
+	}
+}
+
+func TestHandleFrontmatterReturnsCoercedProperties(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, properties.File, `{"types":{"rating":"number"}}`)
+	writeTestNote(t, vaultPath, "note.md", "# Note\n")
+
+	for _, dryRun := range []bool{true, false} {
+		_, got, err := handleFrontmatter(context.Background(), nil, FrontmatterInput{
+			Path:       "note.md",
+			Operations: []FrontmatterOperation{{Op: "set", Key: "rating", Value: "3"}},
+			DryRun:     dryRun,
+		})
+		if err != nil {
+			t.Fatalf("handleFrontmatter(dryRun=%v) error = %v", dryRun, err)
+		}
+		if want := map[string]any{"rating": 3}; !reflect.DeepEqual(got.Frontmatter, want) {
+			t.Errorf("handleFrontmatter(dryRun=%v) frontmatter = %#v, want %#v", dryRun, got.Frontmatter, want)
+		}
+	}
+}
+
+func TestHandleProperties(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, properties.File, `{"types":{"due":"date","unused":"checkbox"}}`)
+	writeTestNote(t, vaultPath, "projects/a.md", "---\ndue: 2024-01-05\nrating: 3\ntags: [a]\n---\n")
+	writeTestNote(t, vaultPath, "projects/b.md", "---\ndue: soon\nrating: 4\n---\n")
+	writeTestNote(t, vaultPath, "notes/c.md", "---\ntitle: C\nrating: high\n---\n")
+
+	_, got, err := handleProperties(context.Background(), nil, PropertiesInput{})
+	if err != nil {
+		t.Fatalf("handleProperties() error = %v", err)
+	}
+	want := []PropertyInfo{
+		{Name: "due", Type: "date", Declared: true, Count: 2},
+		{Name: "rating", Type: "number", Count: 3},
+		{Name: "tags", Type: "tags", Count: 1},
+		{Name: "title", Type: "text", Count: 1},
+		{Name: "unused", Type: "checkbox", Declared: true, Count: 0},
+	}
+	if got.Total != len(want) || !reflect.DeepEqual(got.Properties, want) {
+		t.Errorf("handleProperties() = %+v, want %+v", got.Properties, want)
+	}
+
+	_, got, _ = handleProperties(context.Background(), nil, PropertiesInput{Path: "notes"})
+	if got.Total != 2 || got.Properties[0].Name != "rating" || got.Properties[0].Type != "text" {
+		t.Errorf("handleProperties(notes) = %+v", got.Properties)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/query"
+)
+
+func handleQuery(ctx context.Context, req *mcp.CallToolRequest, input QueryInput) (*mcp.CallToolResult, QueryOutput, error) {
+	source := strings.TrimSpace(input.Query)
+	if source == "" {
+		return &mcp.CallToolResult{IsError: true}, QueryOutput{}, fmt.Errorf("query cannot be empty")
+	}
+	q, err := query.Parse(source)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, QueryOutput{}, fmt.Errorf("invalid query: %w", err)
+	}
+
+	pages, err := loadQueryPages()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, QueryOutput{}, err
+	}
+	result := q.Run(pages)
+
+	output := QueryOutput{
+		Type:    result.Type,
+		Columns: result.Columns,
+		Rows:    make([]QueryRow, len(result.Rows)),
+		Total:   len(result.Rows),
+	}
+	for i, row := range result.Rows {
+		output.Rows[i] = QueryRow{Path: row.Path, Values: row.Values}
+	}
+	return nil, output, nil
+}
+
+// loadQueryPages returns every note of the vault as a query page, with
+// its links resolved to paths.
+func loadQueryPages() ([]query.Page, error) {
+	notes, err := loadVaultNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(notes))
+	for i, note := range notes {
+		paths[i] = note.Path
+	}
+	resolver := newLinkResolver(paths)
+
+	pages := make([]query.Page, len(notes))
+	index := make(map[string]int, len(notes))
+	for i, note := range notes {
+		page := query.Page{
+			Path:         note.Path,
+			Frontmatter:  note.Note.Frontmatter,
+			InlineFields: note.Note.InlineFields,
+			Tags:         note.Tags,
+		}
+		seen := make(map[string]bool, len(note.Links))
+		for _, link := range note.Links {
+			if target, ok := resolver.resolve(link); ok {
+				link = target
+			}
+			if !seen[link] {
+				seen[link] = true
+				page.Outlinks = append(page.Outlinks, link)
+			}
+		}
+		if fullPath, err := fileSystem.ResolvePath(note.Path); err == nil {
+			if info, err := os.Stat(fullPath); err == nil {
+				page.Size, page.Modified = info.Size(), info.ModTime()
+			}
+		}
+		pages[i] = page
+		index[note.Path] = i
+	}
+	for _, page := range pages {
+		for _, link := range page.Outlinks {
+			if i, ok := index[link]; ok && link != page.Path {
+				pages[i].Inlinks = append(pages[i].Inlinks, page.Path)
+			}
+		}
+	}
+	return pages, nil
+}
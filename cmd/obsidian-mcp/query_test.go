@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestHandleQuery(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "projects/alpha.md", "---\nstatus: active\ntags: [project]\n---\n# Alpha\n\ndue:: 2024-06-01\nSee [[beta]].\n")
+	writeTestNote(t, vaultPath, "projects/beta.md", "---\nstatus: active\ntags: [project]\n---\n# Beta\n\n- [ ] Ship it [due:: 2024-05-01]\n")
+	writeTestNote(t, vaultPath, "projects/gamma.md", "---\nstatus: done\ntags: [project]\n---\n# Gamma\n")
+	writeTestNote(t, vaultPath, "inbox.md", "# Inbox\n\nstatus:: active\n")
+
+	_, got, err := handleQuery(context.Background(), nil, QueryInput{
+		Query: `TABLE due, file.inlinks AS "Linked from" FROM #project WHERE status = "active" SORT due`,
+	})
+	if err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+
+	want := QueryOutput{
+		Type:    "table",
+		Columns: []string{"due", "Linked from"},
+		Rows: []QueryRow{
+			{Path: "projects/beta.md", Values: []any{"2024-05-01", []any{"projects/alpha.md"}}},
+			{Path: "projects/alpha.md", Values: []any{"2024-06-01", []any{}}},
+		},
+		Total: 2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("handleQuery() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHandleQueryInvalid(t *testing.T) {
+	setupTestVault(t)
+
+	result, _, err := handleQuery(context.Background(), nil, QueryInput{Query: "SELECT * FROM notes"})
+	if err == nil || result == nil || !result.IsError {
+		t.Fatalf("handleQuery() error = %v, want an invalid query", err)
+	}
+}
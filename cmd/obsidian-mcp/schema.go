@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/schema"
+)
+
+func handleValidateVault(ctx context.Context, req *mcp.CallToolRequest, input ValidateVaultInput) (*mcp.CallToolResult, ValidateVaultOutput, error) {
+	folder := strings.Trim(strings.TrimSpace(input.Path), "/")
+
+	schemas, err := fileSystem.Schemas()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, ValidateVaultOutput{}, err
+	}
+
+	notes, err := loadVaultNotes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, ValidateVaultOutput{}, err
+	}
+
+	output := ValidateVaultOutput{Schemas: schemas.Len(), Violations: []schema.Violation{}}
+	for _, note := range notes {
+		if folder != "" && !strings.HasPrefix(note.Path, folder+"/") {
+			continue
+		}
+		if len(schemas.For(note.Path, note.Note.Frontmatter)) == 0 {
+			continue
+		}
+		output.Checked++
+		if violations := schemas.Validate(note.Path, note.Note.Frontmatter); len(violations) > 0 {
+			output.Invalid++
+			output.Violations = append(output.Violations, violations...)
+		}
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/taigrr/obsidian-mcp/internal/schema"
+)
+
+func TestHandleValidateVault(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "projects/alpha.md", "---\nstatus: active\ndue: 2024-06-01\n---\n# Alpha\n")
+	writeTestNote(t, vaultPath, "projects/beta.md", "---\nstatus: someday\n---\n# Beta\n")
+	writeTestNote(t, vaultPath, "notes/idea.md", "# Idea\n")
+	writeTestNote(t, vaultPath, schema.File, "project:\n  folder: projects\n  fields:\n    status: {type: string, required: true, enum: [active, paused, done]}\n    due: {type: date, required: true}\n")
+
+	_, got, err := handleValidateVault(context.Background(), nil, ValidateVaultInput{})
+	if err != nil {
+		t.Fatalf("handleValidateVault() error = %v", err)
+	}
+	want := []schema.Violation{
+		{Path: "projects/beta.md", Schema: "project", Field: "due", Message: "is required"},
+		{Path: "projects/beta.md", Schema: "project", Field: "status", Message: "must be one of active, paused, done, got someday"},
+	}
+	if got.Schemas != 1 || got.Checked != 2 || got.Invalid != 1 || !reflect.DeepEqual(got.Violations, want) {
+		t.Errorf("handleValidateVault() = %+v, want violations %+v", got, want)
+	}
+
+	_, got, _ = handleValidateVault(context.Background(), nil, ValidateVaultInput{Path: "notes"})
+	if got.Checked != 0 || len(got.Violations) != 0 {
+		t.Errorf("handleValidateVault(notes) = %+v, want nothing checked", got)
+	}
+
+	result, _, err := handleEdit(context.Background(), nil, EditInput{Path: "projects/alpha.md", Frontmatter: map[string]any{"due": "soon"}})
+	if err == nil || result == nil || !result.IsError || !strings.Contains(err.Error(), `due must be of type date, got "soon" (schema project)`) {
+		t.Errorf("handleEdit() error = %v, want a schema error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/markdown"
+)
+
+func handleEditSection(ctx context.Context, req *mcp.CallToolRequest, input EditSectionInput) (*mcp.CallToolResult, EditSectionOutput, error) {
+	path := strings.TrimSpace(input.Path)
+	headingPath := markdown.SplitHeadingPath(input.Heading)
+	if len(headingPath) == 0 {
+		return &mcp.CallToolResult{IsError: true}, EditSectionOutput{Path: path}, fmt.Errorf("heading cannot be empty")
+	}
+
+	edit := markdown.SectionEdit{
+		Op:      markdown.SectionOp(strings.ToLower(strings.TrimSpace(input.Operation))),
+		Text:    input.Content,
+		Heading: input.NewHeading,
+	}
+
+	// Headings are looked up in the body so that YAML comments in the
+	// frontmatter are not mistaken for headings.
+	var heading markdown.Heading
+	changes, version, err := updateNoteBody("edit_section", input, path, input.IfMatch, input.DryRun, func(body string) (string, error) {
+		updated, h, err := markdown.EditSection(body, headingPath, edit)
+		heading = h
+		return updated, err
+	})
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, EditSectionOutput{Path: path}, err
+	}
+
+	return nil, EditSectionOutput{
+		Success: true,
+		Path:    path,
+		Heading: heading.Text,
+		Line:    heading.Line + 1,
+		Version: version,
+		DryRun:  input.DryRun,
+		Changes: changes,
+	}, nil
+}
+
+func handleEditLines(ctx context.Context, req *mcp.CallToolRequest, input EditLinesInput) (*mcp.CallToolResult, EditLinesOutput, error) {
+	path := strings.TrimSpace(input.Path)
+	op := markdown.LineOp(strings.ToLower(strings.TrimSpace(input.Operation)))
+
+	var (
+		newLines   []string
+		totalLines int
+		first      int
+	)
+	changes, version, err := updateNoteBody("edit_lines", input, path, input.IfMatch, input.DryRun, func(body string) (string, error) {
+		updated, r, err := markdown.EditLines(body, op, input.Start, input.End, strings.TrimSpace(input.Hash), input.Content)
+		if err != nil {
+			return "", err
+		}
+		lines := markdown.Lines(updated)
+		newLines = lines[r.Start:r.End]
+		totalLines = len(lines)
+		first = r.Start + 1
+		return updated, nil
+	})
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, EditLinesOutput{Path: path}, err
+	}
+
+	return nil, EditLinesOutput{
+		Success: true,
+		Path:    path,
+		Range: LineRange{
+			Start: first,
+			End:   first + len(newLines) - 1,
+			Hash:  markdown.HashLines(newLines),
+		},
+		TotalLines: totalLines,
+		Version:    version,
+		DryRun:     input.DryRun,
+		Changes:    changes,
+	}, nil
+}
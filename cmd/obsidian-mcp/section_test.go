@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taigrr/obsidian-mcp/internal/markdown"
+)
+
+func TestHandleEditSection(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "log.md", "---\n# yaml comment\ntags: [log]\n---\n# Log\n\n## Today\n\n- 09:00 start\n\n## Notes\n")
+
+	_, got, err := handleEditSection(context.Background(), nil, EditSectionInput{
+		Path:      "log.md",
+		Heading:   "Log > Today",
+		Operation: "append",
+		Content:   "- 10:00 review",
+	})
+	if err != nil {
+		t.Fatalf("handleEditSection() error = %v", err)
+	}
+	if !got.Success || got.Heading != "Today" || got.Line != 3 {
+		t.Errorf("handleEditSection() = %+v, want success at line 3 for heading Today", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "log.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	want := "---\n# yaml comment\ntags: [log]\n---\n# Log\n\n## Today\n\n- 09:00 start\n- 10:00 review\n\n## Notes\n"
+	if string(data) != want {
+		t.Errorf("log.md = %q, want %q", data, want)
+	}
+
+	_, _, err = handleEditSection(context.Background(), nil, EditSectionInput{
+		Path:      "log.md",
+		Heading:   "yaml comment",
+		Operation: "delete",
+	})
+	if err == nil {
+		t.Error("handleEditSection() on a frontmatter comment error = nil, want heading not found")
+	}
+}
+
+func TestHandleEditLines(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "note.md", "---\ntitle: Note\n---\none\ntwo\nthree\n")
+
+	_, read, err := handleRead(context.Background(), nil, ReadInput{Path: "note.md", Offset: 1, Limit: 2, LineNumbers: true})
+	if err != nil {
+		t.Fatalf("handleRead() error = %v", err)
+	}
+	if read.Content != "2\ttwo\n3\tthree" {
+		t.Errorf("handleRead().Content = %q, want numbered lines 2-3", read.Content)
+	}
+	if read.Range == nil || read.Range.Start != 2 || read.Range.End != 3 {
+		t.Fatalf("handleRead().Range = %+v, want lines 2-3", read.Range)
+	}
+
+	_, got, err := handleEditLines(context.Background(), nil, EditLinesInput{
+		Path:      "note.md",
+		Operation: "replace",
+		Start:     read.Range.Start,
+		End:       read.Range.End,
+		Hash:      read.Range.Hash,
+		Content:   "TWO\n",
+	})
+	if err != nil {
+		t.Fatalf("handleEditLines() error = %v", err)
+	}
+	if got.Range.Start != 2 || got.Range.End != 2 || got.TotalLines != 3 {
+		t.Errorf("handleEditLines() = %+v, want range 2-2 of 3 lines", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "note.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if want := "---\ntitle: Note\n---\none\nTWO\n"; string(data) != want {
+		t.Errorf("note.md = %q, want %q", data, want)
+	}
+
+	// The same hash no longer matches lines 2-3.
+	_, _, err = handleEditLines(context.Background(), nil, EditLinesInput{
+		Path:      "note.md",
+		Operation: "delete",
+		Start:     2,
+		End:       3,
+		Hash:      read.Range.Hash,
+	})
+	if err == nil {
+		t.Error("handleEditLines() with a stale hash error = nil, want error")
+	}
+}
+
+func TestHandleEditSectionAndLinesDryRunAndIfMatch(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	const original = "# Log\n\n## Today\n\n- 09:00 start\n"
+	writeTestNote(t, vaultPath, "log.md", original)
+
+	_, section, err := handleEditSection(context.Background(), nil, EditSectionInput{
+		Path:      "log.md",
+		Heading:   "Log > Today",
+		Operation: "append",
+		Content:   "- 10:00 review",
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("handleEditSection() error = %v", err)
+	}
+	if !section.DryRun || len(section.Changes) != 1 || !strings.Contains(section.Changes[0].Diff, "+- 10:00 review") {
+		t.Errorf("handleEditSection(dryRun) = %+v, want one change with a diff", section)
+	}
+
+	_, lines, err := handleEditLines(context.Background(), nil, EditLinesInput{
+		Path:      "log.md",
+		Operation: "delete",
+		Start:     1,
+		End:       1,
+		Hash:      markdown.HashLines([]string{"# Log"}),
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("handleEditLines() error = %v", err)
+	}
+	if !lines.DryRun || len(lines.Changes) != 1 {
+		t.Errorf("handleEditLines(dryRun) = %+v, want one change", lines)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, "log.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != original {
+		t.Errorf("log.md = %q after dry runs, want it unchanged", data)
+	}
+
+	_, _, err = handleEditSection(context.Background(), nil, EditSectionInput{
+		Path:      "log.md",
+		Heading:   "Log > Today",
+		Operation: "delete",
+		IfMatch:   "stale",
+	})
+	if err == nil {
+		t.Error("handleEditSection() with a stale ifMatch error = nil, want a conflict")
+	}
+	_, _, err = handleEditLines(context.Background(), nil, EditLinesInput{
+		Path:      "log.md",
+		Operation: "delete",
+		Start:     1,
+		End:       1,
+		Hash:      markdown.HashLines([]string{"# Log"}),
+		IfMatch:   "stale",
+	})
+	if err == nil {
+		t.Error("handleEditLines() with a stale ifMatch error = nil, want a conflict")
+	}
+}
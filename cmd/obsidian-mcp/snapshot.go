@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/diff"
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/snapshot"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// errSnapshotsDisabled is returned by the snapshot tools when the server
+// runs without snapshots.
+var errSnapshotsDisabled = fmt.Errorf("snapshots are disabled")
+
+// snapshotBefore snapshots the vault before an operation that changes many
+// notes at once. It does nothing if snapshots are disabled.
+func snapshotBefore(reason string) error {
+	if snapshots == nil {
+		return nil
+	}
+	if _, _, err := snapshots.Take(reason); err != nil {
+		return fmt.Errorf("failed to snapshot the vault before the %s: %w", reason, err)
+	}
+	return nil
+}
+
+func handleSnapshots(ctx context.Context, req *mcp.CallToolRequest, input SnapshotsInput) (*mcp.CallToolResult, SnapshotsOutput, error) {
+	if snapshots == nil {
+		return &mcp.CallToolResult{IsError: true}, SnapshotsOutput{}, errSnapshotsDisabled
+	}
+
+	output := SnapshotsOutput{}
+	if input.Take {
+		info, taken, err := snapshots.Take("manual")
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true}, output, err
+		}
+		output.Taken = &info
+		output.Unchanged = !taken
+	}
+
+	infos, err := snapshots.List()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	output.Total = len(infos)
+	output.Snapshots = infos[:min(limit, len(infos))]
+	return nil, output, nil
+}
+
+func handleSnapshotDiff(ctx context.Context, req *mcp.CallToolRequest, input SnapshotDiffInput) (*mcp.CallToolResult, SnapshotDiffOutput, error) {
+	path := strings.TrimSpace(input.Path)
+	output := SnapshotDiffOutput{Path: path, Snapshot: input.Snapshot, To: input.To}
+	if snapshots == nil {
+		return &mcp.CallToolResult{IsError: true}, output, errSnapshotsDisabled
+	}
+
+	snap, err := snapshots.Get(strings.TrimSpace(input.Snapshot))
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+	before, existed, err := snapshots.Read(snap, path)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+
+	var after string
+	exists := false
+	toLabel := path
+	if to := strings.TrimSpace(input.To); to != "" {
+		toSnap, err := snapshots.Get(to)
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true}, output, err
+		}
+		if after, exists, err = snapshots.Read(toSnap, path); err != nil {
+			return &mcp.CallToolResult{IsError: true}, output, err
+		}
+		toLabel = path + "@" + to
+	} else if note, err := fileSystem.ReadNote(path); err == nil {
+		after, exists = note.OriginalContent, true
+	} else if fileSystem.Exists(path) {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+
+	if !existed && !exists {
+		return &mcp.CallToolResult{IsError: true}, output, fmt.Errorf("%s is in neither snapshot %s nor %s", path, snap.ID, toLabel)
+	}
+	fromLabel := path + "@" + snap.ID
+	if !existed {
+		fromLabel = "/dev/null"
+	}
+	if !exists {
+		toLabel = "/dev/null"
+	}
+	output.Diff = diff.Unified(fromLabel, toLabel, before, after, diff.DefaultContext)
+	return nil, output, nil
+}
+
+func handleSnapshotRestore(ctx context.Context, req *mcp.CallToolRequest, input SnapshotRestoreInput) (*mcp.CallToolResult, SnapshotRestoreOutput, error) {
+	path := strings.Trim(strings.TrimSpace(input.Path), "/")
+	if path == "." {
+		path = ""
+	}
+	output := SnapshotRestoreOutput{Path: path, Snapshot: input.Snapshot, Changes: []types.NoteChange{}}
+	if snapshots == nil {
+		return &mcp.CallToolResult{IsError: true}, output, errSnapshotsDisabled
+	}
+
+	snap, err := snapshots.Get(strings.TrimSpace(input.Snapshot))
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+	paths, err := restorePaths(snap, path)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+	if len(paths) == 0 {
+		return &mcp.CallToolResult{IsError: true}, output, fmt.Errorf("no notes under %q in snapshot %s or in the vault", input.Path, snap.ID)
+	}
+
+	if !input.DryRun {
+		if err := snapshotBefore("restore"); err != nil {
+			return &mcp.CallToolResult{IsError: true}, output, err
+		}
+	}
+
+	changes, err := fileSystem.Apply(paths, input.DryRun, func(tx *filesystem.Tx) error {
+		tx.SetCause("snapshot_restore", input)
+		for _, p := range paths {
+			content, ok, err := snapshots.Read(snap, p)
+			if err != nil {
+				return err
+			}
+			if ok {
+				err = tx.WriteNote(types.NoteWriteParams{Path: p, Content: content})
+			} else {
+				err = tx.DeleteNote(types.DeleteNoteParams{Path: p, ConfirmPath: p})
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restore %s: %w", p, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, output, err
+	}
+
+	output.Success = true
+	output.DryRun = input.DryRun
+	output.Changes = changes
+	verb := "Restored"
+	if input.DryRun {
+		verb = "Dry run: restoring would change"
+	}
+	output.Message = fmt.Sprintf("%s %d notes to snapshot %s", verb, len(changes), snap.ID)
+	return nil, output, nil
+}
+
+// restorePaths returns the notes restoring path to snap touches: path
+// itself if it is a note in the snapshot or the vault, otherwise every
+// note under the folder path in either of them. An empty path is the
+// whole vault.
+func restorePaths(snap snapshot.Snapshot, path string) ([]string, error) {
+	if _, ok := snap.Notes[path]; ok && path != "" {
+		return []string{path}, nil
+	}
+	if isDir, _ := fileSystem.IsDirectory(path); !isDir && fileSystem.Exists(path) && path != "" {
+		return []string{path}, nil
+	}
+
+	current, err := snapshots.Notes()
+	if err != nil {
+		return nil, err
+	}
+	inFolder := func(p string) bool {
+		return path == "" || strings.HasPrefix(p, path+"/")
+	}
+
+	var paths []string
+	for p := range snap.Notes {
+		if inFolder(p) {
+			paths = append(paths, p)
+		}
+	}
+	for _, p := range current {
+		if inFolder(p) {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths), nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/taigrr/obsidian-mcp/internal/pathfilter"
+	"github.com/taigrr/obsidian-mcp/internal/schema"
+	"github.com/taigrr/obsidian-mcp/internal/snapshot"
+)
+
+func setupSnapshots(t *testing.T) {
+	t.Helper()
+	snapshots = snapshot.New(t.TempDir(), fileSystem.GetVaultPath(), pathfilter.New(nil).IsAllowed, snapshot.Policy{})
+	t.Cleanup(func() { snapshots = nil })
+}
+
+func TestHandleSnapshotRestore(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	setupSnapshots(t)
+	ctx := context.Background()
+
+	writeTestNote(t, vaultPath, "projects/a.md", "# A\n")
+	writeTestNote(t, vaultPath, "projects/b.md", "# B\n")
+	writeTestNote(t, vaultPath, "inbox.md", "# Inbox\n")
+
+	_, listed, err := handleSnapshots(ctx, nil, SnapshotsInput{Take: true})
+	if err != nil {
+		t.Fatalf("handleSnapshots() error = %v", err)
+	}
+	if listed.Taken == nil || listed.Taken.Notes != 3 || listed.Total != 1 {
+		t.Fatalf("handleSnapshots() = %+v", listed)
+	}
+	id := listed.Taken.ID
+
+	writeTestNote(t, vaultPath, "projects/a.md", "# A\nchanged\n")
+	writeTestNote(t, vaultPath, "projects/c.md", "# C\n")
+	writeTestNote(t, vaultPath, "inbox.md", "# Inbox\nkept\n")
+	handleDelete(ctx, nil, DeleteInput{Path: "projects/b.md", Confirm: "yes"})
+
+	_, diff, err := handleSnapshotDiff(ctx, nil, SnapshotDiffInput{Path: "projects/a.md", Snapshot: id})
+	if err != nil {
+		t.Fatalf("handleSnapshotDiff() error = %v", err)
+	}
+	if !strings.Contains(diff.Diff, "+changed") {
+		t.Errorf("handleSnapshotDiff() = %q", diff.Diff)
+	}
+
+	_, preview, err := handleSnapshotRestore(ctx, nil, SnapshotRestoreInput{Snapshot: id, Path: "projects", DryRun: true})
+	if err != nil {
+		t.Fatalf("handleSnapshotRestore(dryRun) error = %v", err)
+	}
+	if len(preview.Changes) != 3 || fileSystem.Exists("projects/b.md") {
+		t.Errorf("handleSnapshotRestore(dryRun) = %+v", preview.Changes)
+	}
+
+	_, restored, err := handleSnapshotRestore(ctx, nil, SnapshotRestoreInput{Snapshot: id, Path: "projects/"})
+	if err != nil {
+		t.Fatalf("handleSnapshotRestore() error = %v", err)
+	}
+	actions := map[string]string{}
+	for _, change := range restored.Changes {
+		actions[change.Path] = change.Action
+	}
+	want := map[string]string{"projects/a.md": "modify", "projects/b.md": "create", "projects/c.md": "delete"}
+	if len(actions) != len(want) {
+		t.Errorf("handleSnapshotRestore() changes = %v, want %v", actions, want)
+	}
+	for path, action := range want {
+		if actions[path] != action {
+			t.Errorf("handleSnapshotRestore() %s = %q, want %q", path, actions[path], action)
+		}
+	}
+	if note, _ := fileSystem.ReadNote("inbox.md"); note.OriginalContent != "# Inbox\nkept\n" {
+		t.Errorf("restoring projects/ changed inbox.md: %q", note.OriginalContent)
+	}
+
+	// The state before the restore was snapshotted, so it can be restored too.
+	_, listed, _ = handleSnapshots(ctx, nil, SnapshotsInput{})
+	if listed.Total != 2 || listed.Snapshots[0].Reason != "restore" {
+		t.Errorf("handleSnapshots() after restore = %+v", listed.Snapshots)
+	}
+}
+
+func TestHandleSnapshotRestoreValidates(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	setupSnapshots(t)
+	ctx := context.Background()
+
+	writeTestNote(t, vaultPath, "projects/a.md", "# A\n")
+	_, listed, err := handleSnapshots(ctx, nil, SnapshotsInput{Take: true})
+	if err != nil {
+		t.Fatalf("handleSnapshots() error = %v", err)
+	}
+	writeTestNote(t, vaultPath, "projects/a.md", "---\nstatus: active\n---\n# A\n")
+	writeTestNote(t, vaultPath, schema.File, "project:\n  folder: projects\n  fields:\n    status: {type: string, required: true}\n")
+
+	if _, _, err := handleSnapshotRestore(ctx, nil, SnapshotRestoreInput{Snapshot: listed.Taken.ID, Path: "projects/a.md"}); err == nil || !strings.Contains(err.Error(), "status") {
+		t.Errorf("handleSnapshotRestore() error = %v, want a schema violation", err)
+	}
+}
+
+func TestHandleBatchTakesSnapshot(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	setupSnapshots(t)
+	writeTestNote(t, vaultPath, "a.md", "# A\n")
+
+	if _, _, err := handleBatch(context.Background(), nil, BatchInput{Operations: []BatchOperation{
+		{Op: "write", Path: "a.md", Content: "# A v2\n"},
+	}}); err != nil {
+		t.Fatalf("handleBatch() error = %v", err)
+	}
+
+	infos, _ := snapshots.List()
+	if len(infos) != 1 || infos[0].Reason != "batch" {
+		t.Fatalf("snapshots = %+v, want one taken before the batch", infos)
+	}
+	snap, _ := snapshots.Get(infos[0].ID)
+	if content, _, _ := snapshots.Read(snap, "a.md"); content != "# A\n" {
+		t.Errorf("snapshot a.md = %q, want the content before the batch", content)
+	}
+}
+
+func TestHandleRenameTagTakesSnapshot(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	setupSnapshots(t)
+	ctx := context.Background()
+	writeTestNote(t, vaultPath, "a.md", "# A #todo\n")
+
+	if _, _, err := handleRenameTag(ctx, nil, RenameTagInput{From: []string{"todo"}, To: "task", DryRun: true}); err != nil {
+		t.Fatalf("handleRenameTag(dryRun) error = %v", err)
+	}
+	if infos, _ := snapshots.List(); len(infos) != 0 {
+		t.Fatalf("snapshots after a dry run = %+v, want none", infos)
+	}
+
+	if _, _, err := handleRenameTag(ctx, nil, RenameTagInput{From: []string{"todo"}, To: "task"}); err != nil {
+		t.Fatalf("handleRenameTag() error = %v", err)
+	}
+	infos, _ := snapshots.List()
+	if len(infos) != 1 || infos[0].Reason != "rename_tag" {
+		t.Fatalf("snapshots = %+v, want one taken before the rename", infos)
+	}
+	snap, _ := snapshots.Get(infos[0].ID)
+	if content, _, _ := snapshots.Read(snap, "a.md"); content != "# A #todo\n" {
+		t.Errorf("snapshot a.md = %q, want the content before the rename", content)
+	}
+}
+
+func TestHandleBulkFrontmatterTakesSnapshot(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	setupSnapshots(t)
+	writeTestNote(t, vaultPath, "projects/a.md", "---\nstatus: active\n---\n# A\n")
+
+	if _, _, err := handleBulkFrontmatter(context.Background(), nil, BulkFrontmatterInput{
+		Folder:     "projects",
+		Operations: []FrontmatterOperation{{Op: "set", Key: "status", Value: "done"}},
+	}); err != nil {
+		t.Fatalf("handleBulkFrontmatter() error = %v", err)
+	}
+	infos, _ := snapshots.List()
+	if len(infos) != 1 || infos[0].Reason != "bulk_frontmatter" {
+		t.Fatalf("snapshots = %+v, want one taken before the change", infos)
+	}
+}
+
+func TestSnapshotToolsDisabled(t *testing.T) {
+	setupTestVault(t)
+	if _, _, err := handleSnapshots(context.Background(), nil, SnapshotsInput{}); err != errSnapshotsDisabled {
+		t.Errorf("handleSnapshots() error = %v, want errSnapshotsDisabled", err)
+	}
+}
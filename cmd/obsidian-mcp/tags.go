@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/frontmatter"
+	"github.com/taigrr/obsidian-mcp/internal/markdown"
+	"github.com/taigrr/obsidian-mcp/internal/tagtree"
+)
+
+// tagRename is the result of renaming tags in a single note.
+type tagRename struct {
+	content     string
+	frontmatter int
+	inline      int
+	changes     map[[2]string]int
+}
+
+// renameTagsInNote renames tags in the frontmatter tags field and in inline
+// #tags of a note's raw content. Frontmatter is only rewritten when one of
+// its tags changes, so notes with only inline tags keep their frontmatter
+// byte for byte.
+func renameTagsInNote(content string, renamer *tagtree.Renamer) (tagRename, error) {
+	result := tagRename{content: content, changes: make(map[[2]string]int)}
+	rename := func(tag string) (string, bool) {
+		newTag, ok := renamer.Rename(tag)
+		if ok {
+			result.changes[[2]string{tagtree.Normalize(tag), tagtree.Normalize(newTag)}]++
+		}
+		return newTag, ok
+	}
+
+	fh := frontmatter.New()
+	note := fh.Parse(content)
+	body, inline := markdown.ReplaceInlineTags(note.Content, rename)
+	result.inline = inline
+
+	fm := note.Frontmatter
+	switch tags := fm["tags"].(type) {
+	case string:
+		if newTag, ok := rename(tags); ok {
+			fm["tags"] = newTag
+			result.frontmatter++
+		}
+	case []any:
+		var renamed []any
+		seen := make(map[string]bool)
+		for _, item := range tags {
+			if tag, ok := item.(string); ok {
+				if newTag, ok := rename(tag); ok {
+					item = newTag
+					result.frontmatter++
+				}
+				// Merging tags can leave the same tag twice.
+				key := tagtree.Normalize(item.(string))
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			renamed = append(renamed, item)
+		}
+		fm["tags"] = renamed
+	}
+
+	switch {
+	case result.frontmatter > 0:
+		updated, err := fh.Update(content, fm, body)
+		if err != nil {
+			return tagRename{}, err
+		}
+		result.content = updated
+	case inline > 0:
+		result.content = content[:len(content)-len(note.Content)] + body
+	}
+	return result, nil
+}
+
+func handleRenameTag(ctx context.Context, req *mcp.CallToolRequest, input RenameTagInput) (*mcp.CallToolResult, RenameTagOutput, error) {
+	renamer, err := tagtree.NewRenamer(input.From, input.To)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, RenameTagOutput{}, err
+	}
+
+	paths, err := listVaultNotes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, RenameTagOutput{}, err
+	}
+
+	output := RenameTagOutput{
+		Files:   []RenameTagFile{},
+		Changes: []TagChange{},
+		DryRun:  input.DryRun,
+	}
+	changes := make(map[[2]string]int)
+
+	if !input.DryRun {
+		if err := snapshotBefore("rename_tag"); err != nil {
+			return &mcp.CallToolResult{IsError: true}, output, err
+		}
+	}
+
+	_, err = fileSystem.Apply(paths, input.DryRun, func(tx *filesystem.Tx) error {
+		tx.SetCause("rename_tag", input)
+		for _, path := range paths {
+			var result tagRename
+			err := tx.UpdateNote(path, "", func(content string) (string, error) {
+				var err error
+				result, err = renameTagsInNote(content, renamer)
+				return result.content, err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to rename tags in %s: %w", path, err)
+			}
+
+			if result.frontmatter+result.inline == 0 {
+				continue
+			}
+			output.Files = append(output.Files, RenameTagFile{
+				Path:        path,
+				Frontmatter: result.frontmatter,
+				Inline:      result.inline,
+			})
+			output.FilesChanged++
+			output.Replacements += result.frontmatter + result.inline
+			for key, count := range result.changes {
+				changes[key] += count
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, RenameTagOutput{}, err
+	}
+
+	for key, count := range changes {
+		output.Changes = append(output.Changes, TagChange{From: key[0], To: key[1], Count: count})
+	}
+	sort.Slice(output.Changes, func(i, j int) bool {
+		return output.Changes[i].From < output.Changes[j].From
+	})
+
+	verb := "Renamed"
+	if input.DryRun {
+		verb = "Would rename"
+	}
+	output.Message = fmt.Sprintf("%s %d tag occurrences in %d files", verb, output.Replacements, output.FilesChanged)
+
+	return nil, output, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHandleRenameTag(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "list.md", "---\ntags:\n  - proj\n  - project\n  - daily\n---\nBody\n")
+	writeTestNote(t, vaultPath, "string.md", "---\ntags: proj/alpha\n---\nBody\n")
+	writeTestNote(t, vaultPath, "inline.md", "---\ntitle: Keep\n---\nWork on #proj and #Proj/beta.\n\n```\n#proj\n```\n\nSee https://example.com/#proj and `#proj`.\n")
+	writeTestNote(t, vaultPath, "other.md", "#projects only\n")
+
+	_, dry, err := handleRenameTag(context.Background(), nil, RenameTagInput{From: []string{"#proj"}, To: "project", DryRun: true})
+	if err != nil {
+		t.Fatalf("handleRenameTag(dryRun) error = %v", err)
+	}
+
+	wantFiles := []RenameTagFile{
+		{Path: "inline.md", Inline: 2},
+		{Path: "list.md", Frontmatter: 1},
+		{Path: "string.md", Frontmatter: 1},
+	}
+	if !reflect.DeepEqual(dry.Files, wantFiles) {
+		t.Errorf("handleRenameTag(dryRun).Files = %#v, want %#v", dry.Files, wantFiles)
+	}
+	wantChanges := []TagChange{
+		{From: "proj", To: "project", Count: 2},
+		{From: "proj/alpha", To: "project/alpha", Count: 1},
+		{From: "proj/beta", To: "project/beta", Count: 1},
+	}
+	if !reflect.DeepEqual(dry.Changes, wantChanges) {
+		t.Errorf("handleRenameTag(dryRun).Changes = %#v, want %#v", dry.Changes, wantChanges)
+	}
+	if dry.FilesChanged != 3 || dry.Replacements != 4 {
+		t.Errorf("handleRenameTag(dryRun) filesChanged = %d, replacements = %d, want 3, 4", dry.FilesChanged, dry.Replacements)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(vaultPath, "list.md"))
+	if string(data) != "---\ntags:\n  - proj\n  - project\n  - daily\n---\nBody\n" {
+		t.Fatalf("dry run modified list.md: %q", data)
+	}
+
+	if _, _, err := handleRenameTag(context.Background(), nil, RenameTagInput{From: []string{"proj"}, To: "project"}); err != nil {
+		t.Fatalf("handleRenameTag() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "list.md", want: "---\ntags:\n  - project\n  - daily\n---\nBody\n"},
+		{path: "string.md", want: "---\ntags: project/alpha\n---\nBody\n"},
+		{path: "inline.md", want: "---\ntitle: Keep\n---\nWork on #project and #project/beta.\n\n```\n#proj\n```\n\nSee https://example.com/#proj and `#proj`.\n"},
+		{path: "other.md", want: "#projects only\n"},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join(vaultPath, tt.path))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", tt.path, err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, data, tt.want)
+		}
+	}
+}
+
+func TestHandleRenameTagMerge(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "note.md", "---\ntags: [todo, task]\n---\n#todo and #tasks\n")
+
+	_, got, err := handleRenameTag(context.Background(), nil, RenameTagInput{From: []string{"todo", "tasks"}, To: "task"})
+	if err != nil {
+		t.Fatalf("handleRenameTag() error = %v", err)
+	}
+	if got.Replacements != 3 {
+		t.Errorf("handleRenameTag().Replacements = %d, want 3", got.Replacements)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(vaultPath, "note.md"))
+	if want := "---\ntags: [task]\n---\n#task and #task\n"; string(data) != want {
+		t.Errorf("note.md = %q, want %q", data, want)
+	}
+}
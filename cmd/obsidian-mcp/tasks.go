@@ -0,0 +1,187 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/tagtree"
+	"github.com/taigrr/obsidian-mcp/internal/tasks"
+)
+
+// defaultTasksLimit is how many tasks the tasks tool returns unless limit
+// says otherwise.
+const defaultTasksLimit = 100
+
+// taskDateFields are the dates tasks can be filtered and sorted by;
+// happens is any of due, scheduled and start, as in the Tasks plugin.
+var taskDateFields = []string{"due", "scheduled", "start", "created", "done", "cancelled", "happens"}
+
+func handleTasks(ctx context.Context, req *mcp.CallToolRequest, input TasksInput) (*mcp.CallToolResult, TasksOutput, error) {
+	folder := strings.Trim(strings.TrimSpace(input.Path), "/")
+	text := strings.ToLower(strings.TrimSpace(input.Text))
+	tag := strings.TrimSpace(input.Tag)
+	priority := strings.ToLower(strings.TrimSpace(input.Priority))
+
+	statuses := make(map[string]bool, len(input.Status))
+	for _, status := range input.Status {
+		if status != " " {
+			status = strings.ToLower(strings.TrimSpace(status))
+		}
+		switch status {
+		case "open", tasks.Todo, tasks.InProgress, tasks.Done, tasks.Cancelled, tasks.Other:
+		default:
+			if len([]rune(status)) != 1 {
+				return &mcp.CallToolResult{IsError: true}, TasksOutput{},
+					fmt.Errorf("unknown status %q (use open, todo, in_progress, done, cancelled, other or a status character such as /)", status)
+			}
+		}
+		statuses[status] = true
+	}
+
+	dateField := strings.ToLower(strings.TrimSpace(input.DateField))
+	if dateField == "" {
+		dateField = "due"
+	}
+	if !slices.Contains(taskDateFields, dateField) {
+		return &mcp.CallToolResult{IsError: true}, TasksOutput{},
+			fmt.Errorf("unknown dateField %q (use %s)", dateField, strings.Join(taskDateFields, ", "))
+	}
+	after, err := parseTaskDate(input.After)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, TasksOutput{}, fmt.Errorf("after: %w", err)
+	}
+	before, err := parseTaskDate(input.Before)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, TasksOutput{}, fmt.Errorf("before: %w", err)
+	}
+
+	sortBy := strings.ToLower(strings.TrimSpace(input.SortBy))
+	switch sortBy {
+	case "", "path", "priority", "due", "scheduled", "start", "created", "done", "cancelled":
+	default:
+		return &mcp.CallToolResult{IsError: true}, TasksOutput{},
+			fmt.Errorf("unknown sortBy %q (use path, priority, due, scheduled, start, created, done or cancelled)", sortBy)
+	}
+
+	notes, err := loadVaultNotes()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, TasksOutput{}, err
+	}
+
+	type found struct {
+		path string
+		task tasks.Task
+	}
+	var matches []found
+	for _, note := range notes {
+		if folder != "" && !strings.HasPrefix(note.Path, folder+"/") {
+			continue
+		}
+		for _, task := range tasks.Parse(note.Note.Content) {
+			switch {
+			case len(statuses) > 0 && !statuses[task.Status] && !statuses[task.Type()] && !(statuses["open"] && task.Open()):
+			case text != "" && !strings.Contains(strings.ToLower(task.Text), text):
+			case tag != "" && !tagtree.MatchesAny(task.Tags, tag, input.NestedTags):
+			case priority != "" && task.Priority != priority:
+			case (after != "" || before != "") && !taskDateInRange(task, dateField, after, before):
+			default:
+				matches = append(matches, found{note.Path, task})
+			}
+		}
+	}
+
+	if sortBy != "" && sortBy != "path" {
+		slices.SortStableFunc(matches, func(a, b found) int {
+			if sortBy == "priority" {
+				return cmp.Compare(tasks.PriorityRank(a.task.Priority), tasks.PriorityRank(b.task.Priority))
+			}
+			// Tasks without the date come last.
+			x, y := a.task.Date(sortBy), b.task.Date(sortBy)
+			switch {
+			case x == y:
+				return 0
+			case x == "":
+				return 1
+			case y == "":
+				return -1
+			}
+			return strings.Compare(x, y)
+		})
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultTasksLimit
+	}
+	output := TasksOutput{Tasks: []TaskInfo{}, Total: len(matches)}
+	if len(matches) > limit {
+		matches = matches[:limit]
+		output.Truncated = true
+	}
+	for _, m := range matches {
+		info := TaskInfo{
+			Path:       m.path,
+			Line:       m.task.Line + 1,
+			Status:     m.task.Status,
+			Type:       m.task.Type(),
+			Text:       m.task.Text,
+			Depth:      m.task.Depth,
+			Due:        m.task.Due,
+			Scheduled:  m.task.Scheduled,
+			Start:      m.task.Start,
+			Created:    m.task.Created,
+			Done:       m.task.Done,
+			Cancelled:  m.task.Cancelled,
+			Recurrence: m.task.Recurrence,
+			Priority:   m.task.Priority,
+			Tags:       m.task.Tags,
+		}
+		if m.task.Parent >= 0 {
+			info.Parent = m.task.Parent + 1
+		}
+		output.Tasks = append(output.Tasks, info)
+	}
+	return nil, output, nil
+}
+
+// parseTaskDate parses a date bound: a date in the form YYYY-MM-DD, or
+// today, yesterday or tomorrow.
+func parseTaskDate(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := time.Now()
+	switch s {
+	case "":
+		return "", nil
+	case "today":
+		return today.Format(time.DateOnly), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1).Format(time.DateOnly), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1).Format(time.DateOnly), nil
+	}
+	if _, err := time.Parse(time.DateOnly, s); err != nil {
+		return "", fmt.Errorf("%q is not a date in the form YYYY-MM-DD", s)
+	}
+	return s, nil
+}
+
+// taskDateInRange reports whether a date of the task lies within after
+// and before, both inclusive. A task without the date is out of range.
+func taskDateInRange(task tasks.Task, field, after, before string) bool {
+	fields := []string{field}
+	if field == "happens" {
+		fields = []string{"due", "scheduled", "start"}
+	}
+	for _, f := range fields {
+		date := task.Date(f)
+		if date != "" && (after == "" || date >= after) && (before == "" || date <= before) {
+			return true
+		}
+	}
+	return false
+}
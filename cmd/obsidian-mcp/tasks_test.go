@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestHandleTasks(t *testing.T) {
+	vaultPath := setupTestVault(t)
+
+	writeTestNote(t, vaultPath, "projects/alpha.md", "---\ntags: [project]\n---\n# Alpha\n\n- [ ] Write spec #work 📅 2024-05-10\n    - [x] Outline ✅ 2024-05-01\n- [/] Review PR [due:: 2024-05-03] ⏫\n- [-] Drop feature 📅 2024-05-05\n")
+	writeTestNote(t, vaultPath, "daily/2024-05-02.md", "- [ ] Buy milk 📅 2024-06-01\n- [ ] Call Ann #work\n")
+
+	_, got, err := handleTasks(context.Background(), nil, TasksInput{
+		Status: []string{"open"},
+		After:  "2024-05-01",
+		Before: "2024-05-31",
+		SortBy: "due",
+	})
+	if err != nil {
+		t.Fatalf("handleTasks() error = %v", err)
+	}
+	want := []TaskInfo{
+		{Path: "projects/alpha.md", Line: 5, Status: "/", Type: "in_progress", Text: "Review PR", Due: "2024-05-03", Priority: "high"},
+		{Path: "projects/alpha.md", Line: 3, Status: " ", Type: "todo", Text: "Write spec #work", Due: "2024-05-10", Tags: []string{"work"}},
+	}
+	if !reflect.DeepEqual(got.Tasks, want) || got.Total != 2 {
+		t.Errorf("handleTasks() = %+v, want %+v", got, want)
+	}
+
+	_, got, err = handleTasks(context.Background(), nil, TasksInput{Status: []string{"x"}, Path: "projects"})
+	if err != nil {
+		t.Fatalf("handleTasks() error = %v", err)
+	}
+	want = []TaskInfo{
+		{Path: "projects/alpha.md", Line: 4, Status: "x", Type: "done", Text: "Outline", Depth: 1, Parent: 3, Done: "2024-05-01"},
+	}
+	if !reflect.DeepEqual(got.Tasks, want) {
+		t.Errorf("handleTasks() = %+v, want %+v", got.Tasks, want)
+	}
+
+	_, got, err = handleTasks(context.Background(), nil, TasksInput{Tag: "work", Text: "ann", Limit: 1})
+	if err != nil {
+		t.Fatalf("handleTasks() error = %v", err)
+	}
+	if len(got.Tasks) != 1 || got.Tasks[0].Path != "daily/2024-05-02.md" || got.Tasks[0].Line != 2 || got.Truncated {
+		t.Errorf("handleTasks() = %+v", got)
+	}
+}
+
+func TestHandleTasksInvalid(t *testing.T) {
+	setupTestVault(t)
+
+	for _, input := range []TasksInput{
+		{Status: []string{"finished"}},
+		{After: "May 1"},
+		{DateField: "deadline"},
+		{SortBy: "happens"},
+	} {
+		result, _, err := handleTasks(context.Background(), nil, input)
+		if err == nil || result == nil || !result.IsError {
+			t.Errorf("handleTasks(%+v) error = %v, want an error", input, err)
+		}
+	}
+}
@@ -1,46 +1,85 @@
 package main
 
-import "github.com/modelcontextprotocol/go-sdk/mcp"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/taigrr/obsidian-mcp/internal/diff"
+	"github.com/taigrr/obsidian-mcp/internal/gitvault"
+	"github.com/taigrr/obsidian-mcp/internal/schema"
+	"github.com/taigrr/obsidian-mcp/internal/snapshot"
+	"github.com/taigrr/obsidian-mcp/internal/trash"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
 
 type (
 	// ReadInput contains parameters for reading a note.
 	ReadInput struct {
-		Path   string `json:"path" jsonschema:"Path to the note relative to vault root"`
-		Offset int    `json:"offset,omitempty" jsonschema:"Line offset to start reading from (default: 0)"`
-		Limit  int    `json:"limit,omitempty" jsonschema:"Maximum number of lines to return (default: all)"`
+		Path        string `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Offset      int    `json:"offset,omitempty" jsonschema:"Line offset to start reading from (default: 0)"`
+		Limit       int    `json:"limit,omitempty" jsonschema:"Maximum number of lines to return (default: all)"`
+		Anchor      string `json:"anchor,omitempty" jsonschema:"Only read this heading section (e.g. 'Section' or 'Section#Subsection') or block (e.g. '^block-id'), as written after # in a link"`
+		LineNumbers bool   `json:"lineNumbers,omitempty" jsonschema:"Prefix each line with its line number and return the range with a hash for edit_lines (default: false)"`
+	}
+
+	// LineRange is a range of note lines (1-based, inclusive) and the hash
+	// of their content.
+	LineRange struct {
+		Start int    `json:"start"`
+		End   int    `json:"end"`
+		Hash  string `json:"hash"`
 	}
 
 	// ReadOutput contains the result of reading a note.
 	ReadOutput struct {
-		Frontmatter map[string]any `json:"fm,omitempty"`
-		Content     string         `json:"content"`
-		TotalLines  int            `json:"totalLines"`
-		Truncated   bool           `json:"truncated,omitempty"`
+		Frontmatter  map[string]any `json:"fm,omitempty"`
+		InlineFields map[string]any `json:"fields,omitempty"`
+		Content      string         `json:"content"`
+		TotalLines   int            `json:"totalLines"`
+		StartLine    int            `json:"startLine,omitempty"`
+		Truncated    bool           `json:"truncated,omitempty"`
+		Range        *LineRange     `json:"range,omitempty"`
+		Version      string         `json:"version"`
 	}
 
 	// WriteInput contains parameters for writing a note.
 	WriteInput struct {
-		Path        string         `json:"path" jsonschema:"Path to the note relative to vault root"`
-		Content     string         `json:"content" jsonschema:"Content of the note"`
-		Frontmatter map[string]any `json:"frontmatter,omitempty" jsonschema:"Frontmatter object (optional)"`
+		Path            string         `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Content         string         `json:"content" jsonschema:"Content of the note"`
+		Frontmatter     map[string]any `json:"frontmatter,omitempty" jsonschema:"Frontmatter object (optional). In append/prepend mode it is merged with the existing frontmatter"`
+		Mode            string         `json:"mode,omitempty" jsonschema:"overwrite, append or prepend (default: overwrite). prepend inserts right after the frontmatter"`
+		Separator       string         `json:"separator,omitempty" jsonschema:"Inserted between existing and new content in append/prepend mode unless already present (default: newline)"`
+		CreateIfMissing bool           `json:"createIfMissing,omitempty" jsonschema:"In append/prepend mode, create the note and any missing headings of the path (default: false)"`
+		Heading         string         `json:"heading,omitempty" jsonschema:"In append/prepend mode, insert at the end/start of this heading's section, e.g. 'Journal > Log'"`
+		IfMatch         string         `json:"ifMatch,omitempty" jsonschema:"Version token from read or a previous write; the change fails with a conflict if the note changed since"`
+		DryRun          bool           `json:"dryRun,omitempty" jsonschema:"Return the planned change and a unified diff without writing anything (default: false)"`
 	}
 
 	// WriteOutput contains the result of writing a note.
 	WriteOutput struct {
-		Success bool   `json:"success"`
-		Path    string `json:"path"`
+		Success bool               `json:"success"`
+		Path    string             `json:"path"`
+		Version string             `json:"version,omitempty"`
+		DryRun  bool               `json:"dryRun,omitempty"`
+		Changes []types.NoteChange `json:"changes,omitempty"`
 	}
 
 	// DeleteInput contains parameters for deleting a note.
 	DeleteInput struct {
 		Path    string `json:"path" jsonschema:"Path to the note relative to vault root"`
 		Confirm string `json:"confirm" jsonschema:"Must be set to 'yes' to confirm deletion"`
+		IfMatch string `json:"ifMatch,omitempty" jsonschema:"Version token from read or a previous write; the change fails with a conflict if the note changed since"`
+		DryRun  bool   `json:"dryRun,omitempty" jsonschema:"Report what would be deleted without deleting anything (default: false)"`
 	}
 
 	// DeleteOutput contains the result of deleting a note.
 	DeleteOutput struct {
-		Success bool   `json:"success"`
-		Path    string `json:"path"`
+		Success bool               `json:"success"`
+		Path    string             `json:"path"`
+		Trash   string             `json:"trash,omitempty"`
+		DryRun  bool               `json:"dryRun,omitempty"`
+		Changes []types.NoteChange `json:"changes,omitempty"`
 	}
 
 	// RenameInput contains parameters for renaming/moving a note.
@@ -48,13 +87,18 @@ type (
 		Path      string `json:"path" jsonschema:"Current path of the note"`
 		NewPath   string `json:"newPath" jsonschema:"New path for the note"`
 		Overwrite bool   `json:"overwrite,omitempty" jsonschema:"Allow overwriting existing file (default: false)"`
+		IfMatch   string `json:"ifMatch,omitempty" jsonschema:"Version token from read or a previous write; the change fails with a conflict if the note changed since"`
+		DryRun    bool   `json:"dryRun,omitempty" jsonschema:"Report the planned move without moving anything (default: false)"`
 	}
 
 	// RenameOutput contains the result of renaming a note.
 	RenameOutput struct {
-		Success bool   `json:"success"`
-		OldPath string `json:"oldPath"`
-		NewPath string `json:"newPath"`
+		Success bool               `json:"success"`
+		OldPath string             `json:"oldPath"`
+		NewPath string             `json:"newPath"`
+		Version string             `json:"version,omitempty"`
+		DryRun  bool               `json:"dryRun,omitempty"`
+		Changes []types.NoteChange `json:"changes,omitempty"`
 	}
 
 	// EditInput contains parameters for editing a note.
@@ -64,13 +108,432 @@ type (
 		NewText     string         `json:"newText,omitempty" jsonschema:"New text to insert in place of oldText"`
 		ReplaceAll  bool           `json:"replaceAll,omitempty" jsonschema:"If true, replace all occurrences of oldText"`
 		Frontmatter map[string]any `json:"frontmatter,omitempty" jsonschema:"Frontmatter fields to update (merged with existing)"`
+		IfMatch     string         `json:"ifMatch,omitempty" jsonschema:"Version token from read or a previous write; the change fails with a conflict if the note changed since"`
+		DryRun      bool           `json:"dryRun,omitempty" jsonschema:"Return the planned change and a unified diff without writing anything (default: false)"`
 	}
 
 	// EditOutput contains the result of editing a note.
 	EditOutput struct {
-		Success      bool   `json:"success"`
+		Success      bool               `json:"success"`
+		Path         string             `json:"path"`
+		Replacements int                `json:"replacements,omitempty"`
+		Version      string             `json:"version,omitempty"`
+		DryRun       bool               `json:"dryRun,omitempty"`
+		Changes      []types.NoteChange `json:"changes,omitempty"`
+	}
+
+	// FrontmatterOperation is a single change in a frontmatter call.
+	FrontmatterOperation struct {
+		Op     string `json:"op" jsonschema:"set, unset, rename, append, remove, merge, merge_patch or json_patch"`
+		Key    string `json:"key,omitempty" jsonschema:"Frontmatter key for set, unset, rename, append and remove"`
+		NewKey string `json:"newKey,omitempty" jsonschema:"New name of the key for rename"`
+		Value  any    `json:"value,omitempty" jsonschema:"Value to set; item or items to append or remove; object to merge; merge patch object (null removes a key); or list of JSON Patch operations with JSON Pointer paths"`
+	}
+
+	// FrontmatterInput contains parameters for changing a note's frontmatter.
+	FrontmatterInput struct {
+		Path       string                 `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Operations []FrontmatterOperation `json:"operations" jsonschema:"Operations to apply in order; if any fails, nothing is written"`
+		IfMatch    string                 `json:"ifMatch,omitempty" jsonschema:"Version token from read or a previous write; the change fails with a conflict if the note changed since"`
+		DryRun     bool                   `json:"dryRun,omitempty" jsonschema:"Return the planned change and a unified diff without writing anything (default: false)"`
+	}
+
+	// FrontmatterOutput contains the result of changing a note's frontmatter.
+	FrontmatterOutput struct {
+		Success     bool               `json:"success"`
+		Path        string             `json:"path"`
+		Frontmatter map[string]any     `json:"frontmatter"`
+		Version     string             `json:"version,omitempty"`
+		DryRun      bool               `json:"dryRun,omitempty"`
+		Changes     []types.NoteChange `json:"changes,omitempty"`
+	}
+
+	// FrontmatterFilter selects notes by a frontmatter value.
+	FrontmatterFilter struct {
+		Key   string `json:"key" jsonschema:"Frontmatter key to test"`
+		Op    string `json:"op,omitempty" jsonschema:"eq (default), ne, exists, missing, contains (list item or substring), gt, gte, lt or lte; numbers and dates such as 2024-05-01 compare by value"`
+		Value any    `json:"value,omitempty" jsonschema:"Value to compare with"`
+	}
+
+	// BulkFrontmatterInput contains parameters for changing the frontmatter
+	// of many notes.
+	BulkFrontmatterInput struct {
+		Folder     string                 `json:"folder,omitempty" jsonschema:"Only notes in this folder, including subfolders"`
+		Glob       string                 `json:"glob,omitempty" jsonschema:"Only notes whose path matches this glob; ** matches across folders, e.g. 'journal/2024-*.md'"`
+		Tag        string                 `json:"tag,omitempty" jsonschema:"Only notes with this tag, in frontmatter or inline"`
+		NestedTags bool                   `json:"nestedTags,omitempty" jsonschema:"Let a parent tag match its nested tags, e.g. project matches project/alpha (default: false)"`
+		Where      []FrontmatterFilter    `json:"where,omitempty" jsonschema:"Only notes whose frontmatter passes every filter"`
+		Operations []FrontmatterOperation `json:"operations" jsonschema:"Operations to apply to each note, as in the frontmatter tool"`
+		MaxFiles   int                    `json:"maxFiles,omitempty" jsonschema:"Fail without changing anything if more notes match (default: 100)"`
+		DryRun     bool                   `json:"dryRun,omitempty" jsonschema:"Return the planned changes with diffs without writing anything (default: false)"`
+	}
+
+	// BulkFrontmatterFile is the result for a single note.
+	BulkFrontmatterFile struct {
+		Path    string `json:"path"`
+		Changed bool   `json:"changed"`
+		Error   string `json:"error,omitempty"`
+	}
+
+	// BulkFrontmatterOutput contains the result of a bulk frontmatter
+	// change.
+	BulkFrontmatterOutput struct {
+		Success bool                  `json:"success"`
+		Matched int                   `json:"matched"`
+		Changed int                   `json:"changed"`
+		DryRun  bool                  `json:"dryRun,omitempty"`
+		Files   []BulkFrontmatterFile `json:"files"`
+		Changes []types.NoteChange    `json:"changes,omitempty"`
+		Message string                `json:"message"`
+	}
+
+	// QueryInput contains parameters for a Dataview query.
+	QueryInput struct {
+		Query string `json:"query" jsonschema:"DQL query, e.g. 'TABLE status, due FROM \"projects\" AND #active WHERE due < date(today) SORT due ASC LIMIT 10'"`
+	}
+
+	// QueryRow is a note in a query result and its column values.
+	QueryRow struct {
+		Path   string `json:"path"`
+		Values []any  `json:"values,omitempty"`
+	}
+
+	// QueryOutput contains the result of a Dataview query.
+	QueryOutput struct {
+		Type    string     `json:"type"`
+		Columns []string   `json:"columns,omitempty"`
+		Rows    []QueryRow `json:"rows"`
+		Total   int        `json:"total"`
+	}
+
+	// TasksInput contains parameters for finding tasks.
+	TasksInput struct {
+		Path       string   `json:"path,omitempty" jsonschema:"Only tasks in notes in this folder, including subfolders"`
+		Status     []string `json:"status,omitempty" jsonschema:"Only tasks with one of these statuses: open (not done or cancelled), todo, in_progress, done, cancelled, other, or a status character such as / or > (default: all)"`
+		Text       string   `json:"text,omitempty" jsonschema:"Only tasks whose description contains this text (case insensitive)"`
+		Tag        string   `json:"tag,omitempty" jsonschema:"Only tasks carrying this tag in their description"`
+		NestedTags bool     `json:"nestedTags,omitempty" jsonschema:"Let a parent tag match its nested tags, e.g. project matches project/alpha (default: false)"`
+		Priority   string   `json:"priority,omitempty" jsonschema:"Only tasks with this priority: highest, high, medium, low or lowest"`
+		DateField  string   `json:"dateField,omitempty" jsonschema:"Date that after and before apply to: due, scheduled, start, created, done, cancelled, or happens for any of due, scheduled and start (default: due)"`
+		After      string   `json:"after,omitempty" jsonschema:"Only tasks with the date on or after this day (YYYY-MM-DD, today, yesterday or tomorrow)"`
+		Before     string   `json:"before,omitempty" jsonschema:"Only tasks with the date on or before this day (YYYY-MM-DD, today, yesterday or tomorrow)"`
+		SortBy     string   `json:"sortBy,omitempty" jsonschema:"path (default), priority, due, scheduled, start, created, done or cancelled; tasks without the date come last"`
+		Limit      int      `json:"limit,omitempty" jsonschema:"Maximum tasks returned (default: 100)"`
+	}
+
+	// TaskInfo is a task found in a note. Line and Parent are line numbers
+	// as returned by read.
+	TaskInfo struct {
+		Path       string   `json:"path"`
+		Line       int      `json:"line"`
+		Status     string   `json:"status"`
+		Type       string   `json:"type"`
+		Text       string   `json:"text"`
+		Depth      int      `json:"depth,omitempty"`
+		Parent     int      `json:"parent,omitempty"`
+		Due        string   `json:"due,omitempty"`
+		Scheduled  string   `json:"scheduled,omitempty"`
+		Start      string   `json:"start,omitempty"`
+		Created    string   `json:"created,omitempty"`
+		Done       string   `json:"done,omitempty"`
+		Cancelled  string   `json:"cancelled,omitempty"`
+		Recurrence string   `json:"recurrence,omitempty"`
+		Priority   string   `json:"priority,omitempty"`
+		Tags       []string `json:"tags,omitempty"`
+	}
+
+	// TasksOutput contains the tasks found.
+	TasksOutput struct {
+		Tasks     []TaskInfo `json:"tasks"`
+		Total     int        `json:"total"`
+		Truncated bool       `json:"truncated,omitempty"`
+	}
+
+	// EditSectionInput contains parameters for editing a heading section.
+	EditSectionInput struct {
+		Path       string `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Heading    string `json:"heading" jsonschema:"Heading path of the section, e.g. 'Projects > Alpha > Status'"`
+		Operation  string `json:"operation" jsonschema:"replace, append or prepend the section's own content (subsections are kept), insert a new subsection, or delete the section"`
+		Content    string `json:"content,omitempty" jsonschema:"Markdown to write into the section"`
+		NewHeading string `json:"newHeading,omitempty" jsonschema:"Title of the subsection to add (insert only)"`
+		IfMatch    string `json:"ifMatch,omitempty" jsonschema:"Version token from read or a previous write; the change fails with a conflict if the note changed since"`
+		DryRun     bool   `json:"dryRun,omitempty" jsonschema:"Return the planned change and a unified diff without writing anything (default: false)"`
+	}
+
+	// EditSectionOutput contains the result of editing a heading section.
+	EditSectionOutput struct {
+		Success bool               `json:"success"`
+		Path    string             `json:"path"`
+		Heading string             `json:"heading"`
+		Line    int                `json:"line"`
+		Version string             `json:"version,omitempty"`
+		DryRun  bool               `json:"dryRun,omitempty"`
+		Changes []types.NoteChange `json:"changes,omitempty"`
+	}
+
+	// EditLinesInput contains parameters for editing a range of lines.
+	EditLinesInput struct {
+		Path      string `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Operation string `json:"operation" jsonschema:"replace or delete lines start-end, or insert content after line end"`
+		Start     int    `json:"start" jsonschema:"First line of the range (1-based, as returned by read with lineNumbers=true)"`
+		End       int    `json:"end" jsonschema:"Last line of the range (inclusive). Use end=start-1 for an empty range to insert before start"`
+		Hash      string `json:"hash" jsonschema:"Hash of lines start-end from read; the edit is rejected if the lines changed"`
+		Content   string `json:"content,omitempty" jsonschema:"New lines for replace and insert"`
+		IfMatch   string `json:"ifMatch,omitempty" jsonschema:"Version token from read or a previous write; the change fails with a conflict if the note changed since"`
+		DryRun    bool   `json:"dryRun,omitempty" jsonschema:"Return the planned change and a unified diff without writing anything (default: false)"`
+	}
+
+	// EditLinesOutput contains the result of editing a range of lines.
+	EditLinesOutput struct {
+		Success    bool               `json:"success"`
+		Path       string             `json:"path"`
+		Range      LineRange          `json:"range"`
+		TotalLines int                `json:"totalLines"`
+		Version    string             `json:"version,omitempty"`
+		DryRun     bool               `json:"dryRun,omitempty"`
+		Changes    []types.NoteChange `json:"changes,omitempty"`
+	}
+
+	// ApplyPatchInput contains parameters for applying a unified diff.
+	ApplyPatchInput struct {
+		Patch  string `json:"patch" jsonschema:"Unified diff with ---/+++ file headers (paths relative to vault root, a/ and b/ prefixes allowed) and @@ hunks. May touch several notes; use /dev/null as the old path to create a note"`
+		Fuzz   *int   `json:"fuzz,omitempty" jsonschema:"Context lines that may be ignored at each end of a hunk when it does not match exactly (default: 2)"`
+		DryRun bool   `json:"dryRun,omitempty" jsonschema:"Return the patched text without writing anything (default: false)"`
+	}
+
+	// PatchFileResult reports how a patch applied to a single note.
+	PatchFileResult struct {
+		Path    string            `json:"path"`
+		Created bool              `json:"created,omitempty"`
+		Hunks   []diff.HunkResult `json:"hunks"`
+		Error   string            `json:"error,omitempty"`
+		Content string            `json:"content,omitempty"`
+		Version string            `json:"version,omitempty"`
+	}
+
+	// ApplyPatchOutput contains the result of applying a unified diff.
+	ApplyPatchOutput struct {
+		Success  bool              `json:"success"`
+		Files    []PatchFileResult `json:"files"`
+		Applied  int               `json:"applied"`
+		Rejected int               `json:"rejected"`
+		DryRun   bool              `json:"dryRun"`
+		Message  string            `json:"message"`
+	}
+
+	// BatchOperation is a single operation in a batch. Fields apply to the
+	// operation of the same name.
+	BatchOperation struct {
+		Op              string         `json:"op" jsonschema:"write, edit, rename or delete"`
+		Path            string         `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Content         string         `json:"content,omitempty" jsonschema:"write: content of the note"`
+		Frontmatter     map[string]any `json:"frontmatter,omitempty" jsonschema:"write: frontmatter object; edit: frontmatter fields to merge"`
+		Mode            string         `json:"mode,omitempty" jsonschema:"write: overwrite, append or prepend (default: overwrite)"`
+		Separator       string         `json:"separator,omitempty" jsonschema:"write: separator for append/prepend (default: newline)"`
+		CreateIfMissing bool           `json:"createIfMissing,omitempty" jsonschema:"write: create the note or heading in append/prepend mode"`
+		Heading         string         `json:"heading,omitempty" jsonschema:"write: append/prepend within this heading's section"`
+		OldText         string         `json:"oldText,omitempty" jsonschema:"edit: exact text to replace"`
+		NewText         string         `json:"newText,omitempty" jsonschema:"edit: replacement text"`
+		ReplaceAll      bool           `json:"replaceAll,omitempty" jsonschema:"edit: replace all occurrences of oldText"`
+		NewPath         string         `json:"newPath,omitempty" jsonschema:"rename: new path for the note"`
+		Overwrite       bool           `json:"overwrite,omitempty" jsonschema:"rename: allow overwriting an existing note"`
+		Confirm         string         `json:"confirm,omitempty" jsonschema:"delete: must be 'yes'"`
+		IfMatch         string         `json:"ifMatch,omitempty" jsonschema:"Version token from read; the batch fails with a conflict if the note changed since"`
+	}
+
+	// BatchInput contains parameters for a batch of operations.
+	BatchInput struct {
+		Operations []BatchOperation `json:"operations" jsonschema:"Operations to apply in order; each sees the result of the ones before it"`
+	}
+
+	// BatchResult reports the outcome of one operation in a batch.
+	BatchResult struct {
+		Index        int    `json:"index"`
+		Op           string `json:"op"`
 		Path         string `json:"path"`
+		NewPath      string `json:"newPath,omitempty"`
+		Success      bool   `json:"success"`
 		Replacements int    `json:"replacements,omitempty"`
+		Error        string `json:"error,omitempty"`
+		Version      string `json:"version,omitempty"`
+	}
+
+	// BatchOutput contains the result of a batch of operations.
+	BatchOutput struct {
+		Success bool               `json:"success"`
+		Results []BatchResult      `json:"results"`
+		Changes []types.NoteChange `json:"changes"`
+		Message string             `json:"message"`
+	}
+
+	// TrashInput contains parameters for listing trashed notes.
+	TrashInput struct{}
+
+	// TrashOutput lists the notes deleted from the vault that are still in
+	// the trash.
+	TrashOutput struct {
+		Option string       `json:"option"`
+		Items  []trash.Item `json:"items"`
+	}
+
+	// RestoreInput contains parameters for restoring a trashed note.
+	RestoreInput struct {
+		ID   string `json:"id" jsonschema:"ID of the trash item, as returned by delete or trash"`
+		Path string `json:"path,omitempty" jsonschema:"Path to restore the note to (default: its original path)"`
+	}
+
+	// RestoreOutput contains the result of restoring a note.
+	RestoreOutput struct {
+		Success bool   `json:"success"`
+		Path    string `json:"path"`
+		Version string `json:"version,omitempty"`
+	}
+
+	// EmptyTrashInput contains parameters for emptying the trash.
+	EmptyTrashInput struct {
+		Confirm string   `json:"confirm" jsonschema:"Must be set to 'yes' to confirm permanent deletion"`
+		IDs     []string `json:"ids,omitempty" jsonschema:"IDs of the trash items to delete permanently (default: every item from this vault)"`
+	}
+
+	// EmptyTrashOutput contains the result of emptying the trash.
+	EmptyTrashOutput struct {
+		Success bool `json:"success"`
+		Removed int  `json:"removed"`
+	}
+
+	// HistoryInput contains parameters for listing journaled operations.
+	HistoryInput struct {
+		Limit int    `json:"limit,omitempty" jsonschema:"Maximum number of operations to return, newest first (default: 20)"`
+		Path  string `json:"path,omitempty" jsonschema:"Only list operations that changed this note"`
+		ID    int64  `json:"id,omitempty" jsonschema:"Only show this operation, with a diff of each change"`
+	}
+
+	// HistoryEntry is a journaled operation.
+	HistoryEntry struct {
+		ID       int64              `json:"id"`
+		Time     time.Time          `json:"time"`
+		Tool     string             `json:"tool"`
+		Args     json.RawMessage    `json:"args,omitempty"`
+		Changes  []types.NoteChange `json:"changes"`
+		Undoes   []int64            `json:"undoes,omitempty"`
+		UndoneBy int64              `json:"undoneBy,omitempty"`
+	}
+
+	// HistoryOutput lists journaled operations.
+	HistoryOutput struct {
+		Operations []HistoryEntry `json:"operations"`
+		Total      int            `json:"total"`
+	}
+
+	// UndoInput contains parameters for reverting operations.
+	UndoInput struct {
+		Count  int   `json:"count,omitempty" jsonschema:"Number of most recent operations to revert (default: 1)"`
+		ID     int64 `json:"id,omitempty" jsonschema:"Revert this operation instead of the most recent ones"`
+		DryRun bool  `json:"dryRun,omitempty" jsonschema:"Report the changes with diffs without writing anything (default: false)"`
+	}
+
+	// UndoOutput contains the result of reverting operations.
+	UndoOutput struct {
+		Success bool               `json:"success"`
+		Undone  []int64            `json:"undone"`
+		DryRun  bool               `json:"dryRun,omitempty"`
+		Changes []types.NoteChange `json:"changes"`
+		Message string             `json:"message"`
+	}
+
+	// GitLogInput contains parameters for listing a note's git history.
+	GitLogInput struct {
+		Path  string `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Limit int    `json:"limit,omitempty" jsonschema:"Maximum number of commits to return, newest first (default: 20)"`
+	}
+
+	// GitLogOutput lists the commits that changed a note.
+	GitLogOutput struct {
+		Path    string            `json:"path"`
+		Commits []gitvault.Commit `json:"commits"`
+	}
+
+	// GitDiffInput contains parameters for diffing a note between revisions.
+	GitDiffInput struct {
+		Path string `json:"path" jsonschema:"Path to the note relative to vault root"`
+		From string `json:"from,omitempty" jsonschema:"Revision to diff from, e.g. a hash from git_log or HEAD~2 (default: HEAD)"`
+		To   string `json:"to,omitempty" jsonschema:"Revision to diff to (default: the note as it is now)"`
+	}
+
+	// GitDiffOutput contains the diff of a note between revisions.
+	GitDiffOutput struct {
+		Path string `json:"path"`
+		From string `json:"from"`
+		To   string `json:"to,omitempty"`
+		Diff string `json:"diff"`
+	}
+
+	// GitRestoreInput contains parameters for restoring a note from git.
+	GitRestoreInput struct {
+		Path       string `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Revision   string `json:"revision" jsonschema:"Revision to restore the note from, e.g. a hash from git_log"`
+		SourcePath string `json:"sourcePath,omitempty" jsonschema:"Path of the note in that revision if it was renamed since, as listed by git_log (default: path)"`
+		IfMatch    string `json:"ifMatch,omitempty" jsonschema:"Version token from read or a previous write; the change fails with a conflict if the note changed since"`
+		DryRun     bool   `json:"dryRun,omitempty" jsonschema:"Report the change with a diff without writing anything (default: false)"`
+	}
+
+	// GitRestoreOutput contains the result of restoring a note from git.
+	GitRestoreOutput struct {
+		Success  bool               `json:"success"`
+		Path     string             `json:"path"`
+		Revision string             `json:"revision"`
+		Version  string             `json:"version,omitempty"`
+		DryRun   bool               `json:"dryRun,omitempty"`
+		Changes  []types.NoteChange `json:"changes,omitempty"`
+	}
+
+	// SnapshotsInput contains parameters for listing snapshots.
+	SnapshotsInput struct {
+		Limit int  `json:"limit,omitempty" jsonschema:"Maximum number of snapshots to return, newest first (default: 20)"`
+		Take  bool `json:"take,omitempty" jsonschema:"Take a snapshot first (default: false)"`
+	}
+
+	// SnapshotsOutput lists snapshots.
+	SnapshotsOutput struct {
+		Taken     *snapshot.Info  `json:"taken,omitempty"`
+		Unchanged bool            `json:"unchanged,omitempty"` // nothing changed since the taken snapshot
+		Snapshots []snapshot.Info `json:"snapshots"`
+		Total     int             `json:"total"`
+	}
+
+	// SnapshotDiffInput contains parameters for diffing a note against a
+	// snapshot.
+	SnapshotDiffInput struct {
+		Path     string `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Snapshot string `json:"snapshot" jsonschema:"ID of the snapshot to diff from"`
+		To       string `json:"to,omitempty" jsonschema:"ID of a snapshot to diff to (default: the note as it is now)"`
+	}
+
+	// SnapshotDiffOutput contains the diff of a note against a snapshot.
+	SnapshotDiffOutput struct {
+		Path     string `json:"path"`
+		Snapshot string `json:"snapshot"`
+		To       string `json:"to,omitempty"`
+		Diff     string `json:"diff"`
+	}
+
+	// SnapshotRestoreInput contains parameters for restoring notes from a
+	// snapshot.
+	SnapshotRestoreInput struct {
+		Snapshot string `json:"snapshot" jsonschema:"ID of the snapshot to restore from"`
+		Path     string `json:"path" jsonschema:"Note or folder to restore; use '.' for the whole vault"`
+		DryRun   bool   `json:"dryRun,omitempty" jsonschema:"Report the changes with diffs without writing anything (default: false)"`
+	}
+
+	// SnapshotRestoreOutput contains the result of restoring from a
+	// snapshot.
+	SnapshotRestoreOutput struct {
+		Success  bool               `json:"success"`
+		Path     string             `json:"path"`
+		Snapshot string             `json:"snapshot"`
+		DryRun   bool               `json:"dryRun,omitempty"`
+		Changes  []types.NoteChange `json:"changes"`
+		Message  string             `json:"message"`
 	}
 
 	// SearchInput contains parameters for searching notes.
@@ -81,6 +544,8 @@ type (
 		ContextLines  int    `json:"contextLines,omitempty" jsonschema:"Lines of context before/after match (default: 2)"`
 		Limit         int    `json:"limit,omitempty" jsonschema:"Maximum results (default: 15)"`
 		Offset        int    `json:"offset,omitempty" jsonschema:"Skip first N results for pagination (default: 0)"`
+		Tag           string `json:"tag,omitempty" jsonschema:"Only search notes carrying this tag"`
+		NestedTags    bool   `json:"nestedTags,omitempty" jsonschema:"Let a parent tag match its nested tags, e.g. project matches project/alpha (default: false)"`
 	}
 
 	// SearchMatch represents a single match within a file.
@@ -105,9 +570,10 @@ type (
 
 	// RelatedInput contains parameters for finding related notes.
 	RelatedInput struct {
-		Path  string `json:"path" jsonschema:"Path to the note relative to vault root"`
-		Tags  bool   `json:"tags,omitempty" jsonschema:"Find notes sharing tags with this note (default: false)"`
-		Links bool   `json:"links,omitempty" jsonschema:"Find notes linked to/from this note (default: false)"`
+		Path       string `json:"path" jsonschema:"Path to the note relative to vault root"`
+		Tags       bool   `json:"tags,omitempty" jsonschema:"Find notes sharing tags with this note (default: false)"`
+		Links      bool   `json:"links,omitempty" jsonschema:"Find notes linked to/from this note (default: false)"`
+		NestedTags bool   `json:"nestedTags,omitempty" jsonschema:"Let a parent tag match its nested tags, e.g. project matches project/alpha (default: false)"`
 	}
 
 	// RelatedNote represents a related note.
@@ -124,7 +590,10 @@ type (
 	}
 
 	// TagsInput contains parameters for listing all tags.
-	TagsInput struct{}
+	TagsInput struct {
+		Prefix string `json:"prefix,omitempty" jsonschema:"Only include tags starting with this prefix, e.g. 'project/' for tags nested under project"`
+		Tree   bool   `json:"tree,omitempty" jsonschema:"Also return nested tags as a tree with rolled-up counts (default: false)"`
+	}
 
 	// TagInfo represents a tag with its occurrence count.
 	TagInfo struct {
@@ -132,14 +601,178 @@ type (
 		Count int    `json:"count"`
 	}
 
+	// TagNode represents a tag in the nested tag hierarchy. Count is the
+	// number of notes with exactly this tag, Total the number of notes with
+	// this tag or any tag nested below it.
+	TagNode struct {
+		Name     string    `json:"name"`
+		Tag      string    `json:"tag"`
+		Count    int       `json:"count"`
+		Total    int       `json:"total"`
+		Children []TagNode `json:"children,omitempty"`
+	}
+
 	// TagsOutput contains all unique tags in the vault with counts.
 	TagsOutput struct {
 		Tags          []TagInfo `json:"tags"`
+		Tree          []TagNode `json:"tree,omitempty"`
 		TotalTags     int       `json:"totalTags"`
 		TotalNotes    int       `json:"totalNotes"`
 		NotesWithTags int       `json:"notesWithTags"`
 	}
 
+	// RenameTagInput contains parameters for renaming or merging tags.
+	RenameTagInput struct {
+		From   []string `json:"from" jsonschema:"Tags to rename; several tags are merged into the target. Nested tags move with their parent (proj/alpha becomes project/alpha)"`
+		To     string   `json:"to" jsonschema:"New tag name"`
+		DryRun bool     `json:"dryRun,omitempty" jsonschema:"List the affected files without changing them (default: false)"`
+	}
+
+	// RenameTagFile describes the tag changes in a single note.
+	RenameTagFile struct {
+		Path        string `json:"path"`
+		Frontmatter int    `json:"frontmatter"`
+		Inline      int    `json:"inline"`
+	}
+
+	// TagChange counts how often a tag was renamed to a new name.
+	TagChange struct {
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Count int    `json:"count"`
+	}
+
+	// RenameTagOutput contains the result of a tag rename.
+	RenameTagOutput struct {
+		Files        []RenameTagFile `json:"files"`
+		Changes      []TagChange     `json:"changes"`
+		FilesChanged int             `json:"filesChanged"`
+		Replacements int             `json:"replacements"`
+		DryRun       bool            `json:"dryRun"`
+		Message      string          `json:"message"`
+	}
+
+	// GraphInput contains parameters for querying the note graph.
+	GraphInput struct {
+		Mode      string `json:"mode" jsonschema:"Query to run: neighborhood, path or centrality"`
+		Path      string `json:"path,omitempty" jsonschema:"Starting note for neighborhood and path queries, by path or by name as in a wiki link"`
+		Target    string `json:"target,omitempty" jsonschema:"Destination note for path queries, by path or by name as in a wiki link"`
+		Depth     int    `json:"depth,omitempty" jsonschema:"Maximum number of hops for neighborhood queries (default: 2)"`
+		Direction string `json:"direction,omitempty" jsonschema:"Link direction to follow: out, in or both (default: both)"`
+		Tags      bool   `json:"tags,omitempty" jsonschema:"Treat shared tags as edges between notes (default: false)"`
+		Limit     int    `json:"limit,omitempty" jsonschema:"Maximum results for centrality queries (default: 20)"`
+	}
+
+	// GraphNode represents a note reached during a neighborhood query.
+	GraphNode struct {
+		Path     string `json:"path"`
+		Depth    int    `json:"depth"`
+		Via      string `json:"via"`
+		Relation string `json:"relation"`
+		Tag      string `json:"tag,omitempty"`
+	}
+
+	// GraphStep represents a single note on a path between two notes.
+	GraphStep struct {
+		Path     string `json:"path"`
+		Relation string `json:"relation,omitempty"`
+		Tag      string `json:"tag,omitempty"`
+	}
+
+	// GraphCentrality represents the centrality scores of a note.
+	GraphCentrality struct {
+		Path      string  `json:"path"`
+		InDegree  int     `json:"inDegree"`
+		OutDegree int     `json:"outDegree"`
+		Tags      int     `json:"tags"`
+		PageRank  float64 `json:"pageRank"`
+	}
+
+	// GraphOutput contains the result of a graph query.
+	GraphOutput struct {
+		Mode       string            `json:"mode"`
+		Nodes      []GraphNode       `json:"nodes,omitempty"`
+		Path       []GraphStep       `json:"path,omitempty"`
+		Connected  bool              `json:"connected,omitempty"`
+		Centrality []GraphCentrality `json:"centrality,omitempty"`
+		TotalNotes int               `json:"totalNotes"`
+	}
+
+	// ExportGraphInput contains parameters for exporting the note graph.
+	ExportGraphInput struct {
+		Format string   `json:"format,omitempty" jsonschema:"Output format: json, dot or graphml (default: json)"`
+		Folder string   `json:"folder,omitempty" jsonschema:"Only include notes inside this folder"`
+		Tag    string   `json:"tag,omitempty" jsonschema:"Only include notes carrying this tag or one nested under it"`
+		Fields []string `json:"fields,omitempty" jsonschema:"Frontmatter fields to attach to note nodes as attributes"`
+	}
+
+	// ExportGraphOutput contains the serialized note graph.
+	ExportGraphOutput struct {
+		Format string `json:"format"`
+		Nodes  int    `json:"nodes"`
+		Edges  int    `json:"edges"`
+		Graph  string `json:"graph"`
+	}
+
+	// LinksInput contains parameters for checking wiki-links.
+	LinksInput struct {
+		Path       string `json:"path,omitempty" jsonschema:"Note whose outgoing links to check (default: every note in the vault)"`
+		BrokenOnly bool   `json:"brokenOnly,omitempty" jsonschema:"Only return links whose note, heading or block does not exist (default: false)"`
+	}
+
+	// LinkInfo describes a wiki-link and whether its target exists.
+	LinkInfo struct {
+		Source   string `json:"source"`
+		Link     string `json:"link"`
+		Target   string `json:"target,omitempty"`
+		Heading  string `json:"heading,omitempty"`
+		Block    string `json:"block,omitempty"`
+		Resolved string `json:"resolved,omitempty"`
+		Line     int    `json:"line,omitempty"`
+		Status   string `json:"status"`
+	}
+
+	// LinksOutput contains the checked links.
+	LinksOutput struct {
+		Links  []LinkInfo `json:"links"`
+		Total  int        `json:"total"`
+		Broken int        `json:"broken"`
+	}
+
+	// PropertiesInput contains parameters for listing frontmatter
+	// properties.
+	PropertiesInput struct {
+		Path string `json:"path,omitempty" jsonschema:"Folder whose notes to count properties in (default: the whole vault)"`
+	}
+
+	// PropertyInfo describes a frontmatter property.
+	PropertyInfo struct {
+		Name     string `json:"name"`
+		Type     string `json:"type,omitempty"`
+		Declared bool   `json:"declared,omitempty"`
+		Count    int    `json:"count"`
+	}
+
+	// PropertiesOutput contains the properties used in the vault.
+	PropertiesOutput struct {
+		Properties []PropertyInfo `json:"properties"`
+		Total      int            `json:"total"`
+	}
+
+	// ValidateVaultInput contains parameters for checking notes against
+	// the vault's schemas.
+	ValidateVaultInput struct {
+		Path string `json:"path,omitempty" jsonschema:"Folder whose notes to check (default: the whole vault)"`
+	}
+
+	// ValidateVaultOutput contains the schema violations found.
+	ValidateVaultOutput struct {
+		Schemas    int                `json:"schemas"`
+		Checked    int                `json:"checked"`
+		Invalid    int                `json:"invalid"`
+		Violations []schema.Violation `json:"violations"`
+	}
+
 	// ListInput contains parameters for listing a directory.
 	ListInput struct {
 		Path string `json:"path,omitempty" jsonschema:"Directory path relative to vault root (default: root)"`
@@ -155,29 +788,114 @@ type (
 func registerTools(server *mcp.Server) {
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "read",
-		Description: "Read a note from the vault. Returns frontmatter and content. Supports pagination with offset/limit for large files.",
+		Description: "Read a note from the vault. Returns frontmatter, Dataview inline fields (key:: value) and content. Supports pagination with offset/limit for large files, and reading a single heading section or block with anchor. Use lineNumbers=true to number lines and get a range hash for edit_lines. Returns a version token to pass as ifMatch to write, edit, rename and delete.",
 	}, handleRead)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "write",
-		Description: "Create or overwrite a note in the vault with the given content and optional frontmatter.",
+		Description: "Create or overwrite a note in the vault with the given content and optional frontmatter. Use mode=append or mode=prepend to add to an existing note without rewriting it, optionally within a heading's section; set createIfMissing to create the note when it does not exist. Pass ifMatch to fail with a conflict if the note changed since it was read. The frontmatter must match the vault's schemas (see validate_vault). Use dryRun=true to preview the change without writing.",
 	}, handleWrite)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "delete",
-		Description: "Delete a note from the vault. Requires confirm='yes' for safety.",
+		Description: "Delete a note from the vault. Requires confirm='yes' for safety. Following the vault's 'Deleted files' setting, the note is moved to the vault's .trash folder or the system trash (on Linux and BSD; elsewhere the .trash folder), and the returned trash ID can be passed to restore. Pass ifMatch to fail with a conflict if the note changed since it was read. Use dryRun=true to preview the plan.",
 	}, handleDelete)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "trash",
+		Description: "List the notes deleted from the vault that are still in the vault's .trash folder or the system trash, newest first, with their IDs, original paths and deletion times.",
+	}, handleTrash)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "restore",
+		Description: "Restore a trashed note by ID to its original path, or to path. Fails if a note already exists there.",
+	}, handleRestore)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "empty_trash",
+		Description: "Permanently delete trashed notes from this vault, or only the given IDs. Requires confirm='yes'. This cannot be undone.",
+	}, handleEmptyTrash)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "rename",
-		Description: "Move or rename a note to a new path.",
+		Description: "Move or rename a note to a new path. Pass ifMatch to fail with a conflict if the note changed since it was read. Use dryRun=true to preview the plan.",
 	}, handleRename)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "batch",
+		Description: "Apply an ordered list of write, edit, rename and delete operations as one transaction. Every operation is validated against the result of the ones before it first; if any fails, nothing is written. Returns a result per operation.",
+	}, handleBatch)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "history",
+		Description: "List the operations that changed the vault, newest first, with the tool call behind each and the notes it changed. Filter by path, or pass id to see one operation with diffs. Every change made through this server is journaled.",
+	}, handleHistory)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "undo",
+		Description: "Revert the last count operations from history, or the operation with the given id, in one step. Fails without changing anything if a note was changed again after the operation; undo the later operation first. Undos are journaled too, so undoing an undo redoes it. Use dryRun=true to preview.",
+	}, handleUndo)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "git_log",
+		Description: "List the git commits that changed a note, newest first, following renames. Requires the vault to be in a git repository.",
+	}, handleGitLog)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "git_diff",
+		Description: "Show the unified diff of a note between two git revisions, or between a revision (default HEAD) and the note as it is now.",
+	}, handleGitDiff)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "git_restore",
+		Description: "Restore a note to its content at a git revision. The restore is a normal write: it is journaled, can be undone, and honors ifMatch and dryRun.",
+	}, handleGitRestore)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "snapshots",
+		Description: "List vault snapshots, newest first, with how many notes each holds and how many changed since the previous one. Snapshots are taken periodically and before batches, tag renames and bulk frontmatter changes when the server runs with snapshots on; set take=true to take one now.",
+	}, handleSnapshots)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "snapshot_diff",
+		Description: "Show the unified diff of a note between a snapshot and the note as it is now, or another snapshot.",
+	}, handleSnapshotDiff)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "snapshot_restore",
+		Description: "Restore a note or a whole folder to its state in a snapshot: notes are rewritten, recreated, or deleted to the trash if they did not exist then. The restore is journaled and can be undone. Use dryRun=true to preview.",
+	}, handleSnapshotRestore)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "edit",
-		Description: "Edit a note by replacing text and/or updating frontmatter. For text replacement, oldText must match exactly. For frontmatter, fields are merged with existing.",
+		Description: "Edit a note by replacing text and/or updating frontmatter. For text replacement, oldText must match exactly. For frontmatter, fields are merged with existing; use the frontmatter tool to remove or rename keys. Changed frontmatter must match the vault's schemas (see validate_vault). Pass ifMatch to fail with a conflict, including a diff of what changed, if the note changed since it was read. Use dryRun=true to preview the change without writing.",
 	}, handleEdit)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "frontmatter",
+		Description: "Change a note's frontmatter with a list of operations: set, unset or rename a key; append items to or remove items from a list such as tags or aliases; deep merge an object; or apply a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902). Operations apply in order, and nothing is written if any fails. Returns the resulting frontmatter. Supports ifMatch and dryRun like edit.",
+	}, handleFrontmatter)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "bulk_frontmatter",
+		Description: "Apply frontmatter operations (set, unset, append, remove and the others of the frontmatter tool) to every note selected by folder, glob, tag and frontmatter filters, e.g. set reviewed=true on notes tagged weekly with a date in a given month. All notes are changed in one transaction with the same validation as write; if any note fails, nothing is written. Fails if more than maxFiles notes match. Use dryRun=true to preview the changes.",
+	}, handleBulkFrontmatter)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "edit_section",
+		Description: "Edit a note by heading path instead of exact text, e.g. heading='Projects > Alpha > Status'. Operations: replace, append or prepend the section's own content, insert a new subsection (newHeading), or delete the section with its subsections. Blank lines around the section are normalized.",
+	}, handleEditSection)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "edit_lines",
+		Description: "Replace, insert or delete a range of lines. First read the note with lineNumbers=true to get line numbers and the range hash; the edit only applies if the hash of lines start-end still matches. Returns the new range and hash so edits can be chained.",
+	}, handleEditLines)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "apply_patch",
+		Description: "Apply a unified diff to one or more notes. Hunks are matched with line-offset and fuzz tolerance. If any hunk does not apply, nothing is written and a per-hunk report explains the mismatch. Use dryRun=true to get the patched text without writing.",
+	}, handleApplyPatch)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "search",
 		Description: "Full-text search across all notes. Supports regex and case-insensitive search. Results sorted by tag matches first, then content matches. Returns matching lines with context.",
@@ -190,9 +908,49 @@ func registerTools(server *mcp.Server) {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "tags",
-		Description: "List all unique tags across the vault with occurrence counts. Returns tags from both frontmatter and inline #tags.",
+		Description: "List all unique tags across the vault with occurrence counts. Returns tags from both frontmatter and inline #tags. Use prefix to filter (e.g. 'project/') and tree=true for the nested tag hierarchy with rolled-up counts.",
 	}, handleTags)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "rename_tag",
+		Description: "Rename a tag across the vault, in frontmatter tags and inline #tags. Nested tags move with their parent. Pass several tags in from to merge them into one. Tags in code blocks, inline code and URLs are left alone. Use dryRun=true to preview the affected files.",
+	}, handleRenameTag)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "graph",
+		Description: "Query the note graph. mode=neighborhood lists notes within depth hops of path, mode=path finds the shortest connection between path and target, mode=centrality ranks hub notes by degree and PageRank. Use direction to follow outgoing, incoming or both link directions and tags=true to connect notes sharing a tag.",
+	}, handleGraph)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "export_graph",
+		Description: "Export the note/link/tag graph as json (nodes and edges), Graphviz dot or graphml. Optionally limit it to a folder or tag and attach frontmatter fields to note nodes.",
+	}, handleExportGraph)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "links",
+		Description: "Check wiki-links, including [[Note#Heading]] and [[Note#^block]] anchors. Reports where each link resolves and whether the target note, heading or block exists. Use read with anchor to read the linked section or block.",
+	}, handleLinks)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "tasks",
+		Description: "Find tasks (- [ ] checklist items) across the vault, filtered by status, folder, tag, text, priority and a date range. Reads custom statuses such as [/] and [-], nesting, and the due, scheduled, start, created, done and cancelled dates, recurrence and priority written as Tasks plugin emoji (📅 ⏳ 🛫 ➕ ✅ ❌ 🔁 ⏫) or Dataview fields ([due:: 2024-05-01]). Returns each task's path and line number, counted as in read, so it can be changed with edit_lines.",
+	}, handleTasks)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "query",
+		Description: "Run a Dataview (DQL) query over the vault: TABLE [WITHOUT ID] expr [AS \"name\"], ... or LIST [expr], then FROM \"folder\" or #tag combined with and, or and -, then any of WHERE, SORT expr [ASC|DESC] and LIMIT n. Fields are frontmatter keys, inline fields (key:: value) and file.name, file.path, file.folder, file.mtime, file.size, file.tags, file.etags, file.outlinks, file.inlinks and file.day. Expressions support =, !=, <, <=, >, >=, and, or, !, date literals such as 2024-05-01 or date(today), and the functions contains, icontains, length, lower, upper, startswith, endswith, regexmatch, default and date. Returns each matching note's path and column values.",
+	}, handleQuery)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "properties",
+		Description: "List every frontmatter property in the vault with its type and the number of notes using it. Types come from Obsidian's property settings (declared=true) or are inferred from the values: text, multitext, number, checkbox, date, datetime, aliases or tags. Values written to typed properties are converted to their type, e.g. \"3\" to 3 for a number property.",
+	}, handleProperties)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "validate_vault",
+		Description: "Check notes against the frontmatter schemas in .obsidian-mcp/schemas.yaml and report every violation. Schemas apply to notes by folder, frontmatter tag or type field, and are also enforced when notes are written or edited.",
+	}, handleValidateVault)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "list",
 		Description: "List files and subdirectories in a vault directory. Defaults to vault root if no path provided.",
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func handleTrash(ctx context.Context, req *mcp.CallToolRequest, input TrashInput) (*mcp.CallToolResult, TrashOutput, error) {
+	items, err := fileSystem.ListTrash()
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, TrashOutput{}, err
+	}
+	return nil, TrashOutput{Option: string(fileSystem.TrashOption()), Items: items}, nil
+}
+
+func handleRestore(ctx context.Context, req *mcp.CallToolRequest, input RestoreInput) (*mcp.CallToolResult, RestoreOutput, error) {
+	id := strings.TrimSpace(input.ID)
+	if id == "" {
+		return &mcp.CallToolResult{IsError: true}, RestoreOutput{}, fmt.Errorf("id cannot be empty")
+	}
+
+	path, version, err := fileSystem.RestoreNote(id, strings.TrimSpace(input.Path))
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, RestoreOutput{Path: path}, err
+	}
+	return nil, RestoreOutput{Success: true, Path: path, Version: version}, nil
+}
+
+func handleEmptyTrash(ctx context.Context, req *mcp.CallToolRequest, input EmptyTrashInput) (*mcp.CallToolResult, EmptyTrashOutput, error) {
+	if input.Confirm != "yes" {
+		return &mcp.CallToolResult{IsError: true}, EmptyTrashOutput{},
+			fmt.Errorf("permanent deletion not confirmed: set confirm='yes' to proceed")
+	}
+
+	removed, err := fileSystem.EmptyTrash(input.IDs)
+	if err != nil {
+		return &mcp.CallToolResult{IsError: true}, EmptyTrashOutput{Removed: removed}, err
+	}
+	return nil, EmptyTrashOutput{Success: true, Removed: removed}, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHandleTrashRestore(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	os.MkdirAll(filepath.Join(vaultPath, ".obsidian"), 0o755)
+	os.WriteFile(filepath.Join(vaultPath, ".obsidian", "app.json"), []byte(`{"trashOption":"local"}`), 0o644)
+	writeTestNote(t, vaultPath, "notes/a.md", "# A\n")
+	writeTestNote(t, vaultPath, "b.md", "# B\n")
+
+	ctx := context.Background()
+	_, deleted, err := handleDelete(ctx, nil, DeleteInput{Path: "notes/a.md", Confirm: "yes"})
+	if err != nil {
+		t.Fatalf("handleDelete() error = %v", err)
+	}
+	if deleted.Trash != "local:a.md" {
+		t.Errorf("handleDelete().Trash = %q, want %q", deleted.Trash, "local:a.md")
+	}
+	if _, err := os.Stat(filepath.Join(vaultPath, ".trash", "a.md")); err != nil {
+		t.Errorf("note not in .trash: %v", err)
+	}
+	if _, _, err := handleDelete(ctx, nil, DeleteInput{Path: "b.md", Confirm: "yes"}); err != nil {
+		t.Fatalf("handleDelete() error = %v", err)
+	}
+
+	_, listed, err := handleTrash(ctx, nil, TrashInput{})
+	if err != nil {
+		t.Fatalf("handleTrash() error = %v", err)
+	}
+	if listed.Option != "local" || len(listed.Items) != 2 {
+		t.Fatalf("handleTrash() = %+v, want 2 local items", listed)
+	}
+	paths := map[string]bool{}
+	for _, item := range listed.Items {
+		paths[item.Path] = true
+	}
+	if !paths["notes/a.md"] || !paths["b.md"] {
+		t.Errorf("handleTrash() paths = %v, want notes/a.md and b.md", paths)
+	}
+
+	writeTestNote(t, vaultPath, "notes/a.md", "# New A\n")
+	if _, _, err := handleRestore(ctx, nil, RestoreInput{ID: deleted.Trash}); err == nil {
+		t.Error("handleRestore() over an existing note succeeded")
+	}
+	_, restored, err := handleRestore(ctx, nil, RestoreInput{ID: deleted.Trash, Path: "notes/old-a.md"})
+	if err != nil {
+		t.Fatalf("handleRestore() error = %v", err)
+	}
+	if restored.Path != "notes/old-a.md" || restored.Version == "" {
+		t.Errorf("handleRestore() = %+v", restored)
+	}
+	if data, _ := os.ReadFile(filepath.Join(vaultPath, "notes", "old-a.md")); string(data) != "# A\n" {
+		t.Errorf("restored content = %q, want %q", data, "# A\n")
+	}
+
+	if _, _, err := handleEmptyTrash(ctx, nil, EmptyTrashInput{}); err == nil {
+		t.Error("handleEmptyTrash() without confirm succeeded")
+	}
+	_, emptied, err := handleEmptyTrash(ctx, nil, EmptyTrashInput{Confirm: "yes"})
+	if err != nil {
+		t.Fatalf("handleEmptyTrash() error = %v", err)
+	}
+	if emptied.Removed != 1 {
+		t.Errorf("handleEmptyTrash().Removed = %d, want 1", emptied.Removed)
+	}
+	if _, listed, _ = handleTrash(ctx, nil, TrashInput{}); len(listed.Items) != 0 {
+		t.Errorf("handleTrash() after empty_trash = %+v", listed.Items)
+	}
+}
+
+func TestHandleDeletePermanently(t *testing.T) {
+	vaultPath := setupTestVault(t)
+	os.MkdirAll(filepath.Join(vaultPath, ".obsidian"), 0o755)
+	os.WriteFile(filepath.Join(vaultPath, ".obsidian", "app.json"), []byte(`{"trashOption":"none"}`), 0o644)
+	writeTestNote(t, vaultPath, "a.md", "# A\n")
+
+	_, got, err := handleDelete(context.Background(), nil, DeleteInput{Path: "a.md", Confirm: "yes"})
+	if err != nil {
+		t.Fatalf("handleDelete() error = %v", err)
+	}
+	if got.Trash != "" || fileSystem.Exists("a.md") {
+		t.Errorf("handleDelete() = %+v, want permanent deletion", got)
+	}
+	if _, err := os.Stat(filepath.Join(vaultPath, ".trash")); !os.IsNotExist(err) {
+		t.Errorf(".trash created with trashOption none")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/taigrr/obsidian-mcp/internal/filesystem"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// vaultNote is a parsed note along with the tags and links extracted from it.
+type vaultNote struct {
+	Path  string
+	Note  types.ParsedNote
+	Tags  []string
+	Links []string
+}
+
+// listVaultNotes returns the sorted vault-relative paths of every markdown
+// note, skipping hidden directories.
+func listVaultNotes() ([]string, error) {
+	vaultPath := fileSystem.GetVaultPath()
+
+	var paths []string
+	err := filepath.Walk(vaultPath, func(fullPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if fullPath != vaultPath && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(info.Name(), ".md") {
+			return nil
+		}
+		relPath, _ := filepath.Rel(vaultPath, fullPath)
+		paths = append(paths, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// loadVaultNotes reads and parses every note in the vault in parallel.
+// Notes that cannot be read are skipped. The result is sorted by path.
+func loadVaultNotes() ([]vaultNote, error) {
+	paths, err := listVaultNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]vaultNote, len(paths))
+	loaded := make([]bool, len(paths))
+
+	numWorkers := max(min(runtime.NumCPU(), len(paths)), 1)
+	idxCh := make(chan int, len(paths))
+	for i := range paths {
+		idxCh <- i
+	}
+	close(idxCh)
+
+	var wg sync.WaitGroup
+	for range numWorkers {
+		wg.Go(func() {
+			for i := range idxCh {
+				note, err := fileSystem.ReadNote(paths[i])
+				if err != nil {
+					continue
+				}
+				notes[i] = vaultNote{
+					Path:  paths[i],
+					Note:  note,
+					Tags:  extractTags(note.Frontmatter, note.Content),
+					Links: extractLinks(note.Content),
+				}
+				loaded[i] = true
+			}
+		})
+	}
+	wg.Wait()
+
+	result := make([]vaultNote, 0, len(notes))
+	for i, note := range notes {
+		if loaded[i] {
+			result = append(result, note)
+		}
+	}
+	return result, nil
+}
+
+// linkResolver maps wiki-link targets to vault paths the way Obsidian does:
+// either by full path without extension, or by note name. When several
+// notes share a name the one with the shortest path wins.
+type linkResolver struct {
+	byPath map[string]string
+	byName map[string]string
+}
+
+func newLinkResolver(paths []string) *linkResolver {
+	r := &linkResolver{
+		byPath: make(map[string]string, len(paths)),
+		byName: make(map[string]string, len(paths)),
+	}
+	for _, p := range paths {
+		key := strings.ToLower(strings.TrimSuffix(p, ".md"))
+		r.byPath[key] = p
+
+		name := key
+		if idx := strings.LastIndex(key, "/"); idx != -1 {
+			name = key[idx+1:]
+		}
+		if existing, ok := r.byName[name]; !ok || len(p) < len(existing) || (len(p) == len(existing) && p < existing) {
+			r.byName[name] = p
+		}
+	}
+	return r
+}
+
+// resolve returns the vault path a link target points at.
+func (r *linkResolver) resolve(link string) (string, bool) {
+	key := strings.ToLower(strings.TrimSpace(link))
+	key = strings.TrimPrefix(key, "/")
+	key = strings.TrimSuffix(key, ".md")
+	if key == "" {
+		return "", false
+	}
+	if p, ok := r.byPath[key]; ok {
+		return p, true
+	}
+	if strings.Contains(key, "/") {
+		return "", false
+	}
+	p, ok := r.byName[key]
+	return p, ok
+}
+
+// updateNoteBody rewrites the body of a note, leaving its frontmatter byte
+// for byte. Line numbers seen by fn match those returned by the read tool.
+// The change is journaled as caused by tool called with args and fails if
+// ifMatch is set and the note changed since. It returns the staged changes
+// and, unless dryRun is set, the note's new version token.
+func updateNoteBody(tool string, args any, path, ifMatch string, dryRun bool, fn func(body string) (string, error)) ([]types.NoteChange, string, error) {
+	var staged *filesystem.Tx
+	changes, err := fileSystem.Apply([]string{path}, dryRun, func(tx *filesystem.Tx) error {
+		staged = tx
+		tx.SetCause(tool, args)
+		return tx.UpdateNote(path, ifMatch, func(content string) (string, error) {
+			body := fileSystem.Frontmatter().Parse(content).Content
+			prefix := content[:len(content)-len(body)]
+
+			updated, err := fn(body)
+			if err != nil {
+				return "", err
+			}
+			return prefix + updated, nil
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if dryRun {
+		return changes, "", nil
+	}
+	return nil, staged.Version(path), nil
+}
@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultFuzz is the number of context lines that may be ignored at each
+// end of a hunk, matching patch(1).
+const DefaultFuzz = 2
+
+// HunkResult reports how a hunk was applied. Line is the 1-based line in
+// the patched text where the hunk's lines begin, Offset the distance from
+// the position given in its header and Fuzz the number of context lines
+// ignored at each end. Error explains why a hunk was rejected.
+type HunkResult struct {
+	Hunk    int    `json:"hunk"`
+	Applied bool   `json:"applied"`
+	Line    int    `json:"line,omitempty"`
+	Offset  int    `json:"offset,omitempty"`
+	Fuzz    int    `json:"fuzz,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Apply applies hunks to content. Each hunk is matched at the position in
+// its header, adjusted by the changes of earlier hunks, or the nearest
+// position where its context matches; if that fails, up to fuzz context
+// lines are dropped from each end and the search is repeated. Rejected
+// hunks are skipped and reported; the returned error is non-nil if any
+// hunk was rejected.
+func Apply(content string, hunks []Hunk, fuzz int) (string, []HunkResult, error) {
+	lines, trailingNewline := splitLines(content)
+
+	results := make([]HunkResult, 0, len(hunks))
+	shift, minLine, rejected := 0, 0, 0
+	for i, hunk := range hunks {
+		result := HunkResult{Hunk: i + 1}
+
+		var (
+			at, top      int
+			oldBlock     []string
+			newBlock     []string
+			matched      bool
+			firstFailure string
+		)
+		for f := 0; f <= fuzz && !matched; f++ {
+			var trimmed Hunk
+			trimmed, top = trimContext(hunk, f)
+			if f > 0 && top == 0 && len(trimmed.Lines) == len(hunk.Lines) {
+				break
+			}
+			oldBlock, newBlock = trimmed.Old(), trimmed.New()
+
+			expected := hunk.OldStart - 1 + top + shift
+			if hunk.OldLines == 0 {
+				// Pure insertions name the line they follow.
+				expected = hunk.OldStart + shift
+			}
+			expected = min(max(expected, minLine), len(lines))
+
+			at, matched = search(lines, oldBlock, expected, minLine)
+			if !matched && firstFailure == "" {
+				firstFailure = mismatch(lines, oldBlock, expected)
+			}
+			if matched {
+				result.Offset = at - expected
+				result.Fuzz = f
+			}
+		}
+
+		if !matched {
+			result.Error = "context does not match: " + firstFailure
+			results = append(results, result)
+			rejected++
+			continue
+		}
+
+		lines = append(lines[:at], append(append([]string{}, newBlock...), lines[at+len(oldBlock):]...)...)
+		shift += result.Offset + len(newBlock) - len(oldBlock)
+		minLine = at + len(newBlock)
+
+		result.Applied = true
+		result.Line = at + 1
+		if hunk.NoNewlineNew {
+			trailingNewline = false
+		} else if hunk.NoNewlineOld {
+			trailingNewline = true
+		}
+		results = append(results, result)
+	}
+
+	output := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		output += "\n"
+	}
+	if rejected > 0 {
+		return output, results, fmt.Errorf("%d of %d hunks rejected", rejected, len(hunks))
+	}
+	return output, results, nil
+}
+
+// splitLines splits content into lines, reporting whether it ended with a
+// newline. Empty content has no lines; text added to it ends with a
+// newline unless a hunk says otherwise.
+func splitLines(content string) ([]string, bool) {
+	if content == "" {
+		return []string{}, true
+	}
+	trailing := strings.HasSuffix(content, "\n")
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n"), trailing
+}
+
+// trimContext drops up to n leading and trailing context lines from a
+// hunk. It returns the trimmed hunk and the number of leading lines
+// dropped.
+func trimContext(h Hunk, n int) (Hunk, int) {
+	top := 0
+	for top < n && top < len(h.Lines) && h.Lines[top].Kind == Context {
+		top++
+	}
+	bottom := 0
+	for bottom < n && len(h.Lines)-bottom > top && h.Lines[len(h.Lines)-1-bottom].Kind == Context {
+		bottom++
+	}
+	h.Lines = h.Lines[top : len(h.Lines)-bottom]
+	return h, top
+}
+
+// search looks for block in lines, starting at expected and moving outward
+// in both directions, never before minLine.
+func search(lines, block []string, expected, minLine int) (int, bool) {
+	last := len(lines) - len(block)
+	if last < minLine {
+		return 0, false
+	}
+	for d := 0; ; d++ {
+		below, above := expected+d, expected-d
+		if below > last && above < minLine {
+			return 0, false
+		}
+		if below <= last && below >= minLine && matchAt(lines, block, below) {
+			return below, true
+		}
+		if d > 0 && above >= minLine && above <= last && matchAt(lines, block, above) {
+			return above, true
+		}
+	}
+}
+
+func matchAt(lines, block []string, at int) bool {
+	for i, line := range block {
+		if lines[at+i] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// mismatch describes the first line where block differs from lines at
+// expected.
+func mismatch(lines, block []string, expected int) string {
+	for i, want := range block {
+		if expected+i >= len(lines) {
+			return fmt.Sprintf("expected %q at line %d, found end of file", want, expected+i+1)
+		}
+		if got := lines[expected+i]; got != want {
+			return fmt.Sprintf("expected %q at line %d, found %q", want, expected+i+1, got)
+		}
+	}
+	return fmt.Sprintf("no match near line %d", expected+1)
+}
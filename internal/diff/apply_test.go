@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseHunks(t *testing.T, patch string) []Hunk {
+	t.Helper()
+	files, err := Parse("--- note.md\n+++ note.md\n" + patch)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return files[0].Hunks
+}
+
+func TestApply(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		res   []HunkResult
+	}{
+		{
+			name:  "exact",
+			patch: "@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n",
+			want:  "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\n",
+			res:   []HunkResult{{Hunk: 1, Applied: true, Line: 2}},
+		},
+		{
+			name:  "offset",
+			patch: "@@ -1,3 +1,3 @@\n four\n-five\n+FIVE\n six\n",
+			want:  "one\ntwo\nthree\nfour\nFIVE\nsix\nseven\n",
+			res:   []HunkResult{{Hunk: 1, Applied: true, Line: 4, Offset: 3}},
+		},
+		{
+			name:  "fuzz",
+			patch: "@@ -4,4 +4,4 @@\n changed\n five\n-six\n+SIX\n seven\n",
+			want:  "one\ntwo\nthree\nfour\nfive\nSIX\nseven\n",
+			res:   []HunkResult{{Hunk: 1, Applied: true, Line: 5, Fuzz: 1}},
+		},
+		{
+			name:  "two hunks shift lines",
+			patch: "@@ -1,2 +1,3 @@\n one\n+one and a half\n two\n@@ -6,2 +7,1 @@\n six\n-seven\n",
+			want:  "one\none and a half\ntwo\nthree\nfour\nfive\nsix\n",
+			res:   []HunkResult{{Hunk: 1, Applied: true, Line: 1}, {Hunk: 2, Applied: true, Line: 7}},
+		},
+		{
+			name:  "no newline at end",
+			patch: "@@ -7 +7 @@\n-seven\n+SEVEN\n\\ No newline at end of file\n",
+			want:  "one\ntwo\nthree\nfour\nfive\nsix\nSEVEN",
+			res:   []HunkResult{{Hunk: 1, Applied: true, Line: 7}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, res, err := Apply(content, parseHunks(t, tt.patch), DefaultFuzz)
+			if err != nil {
+				t.Fatalf("Apply() error = %v (results %+v)", err, res)
+			}
+			if got != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(res, tt.res) {
+				t.Errorf("Apply() results = %+v, want %+v", res, tt.res)
+			}
+		})
+	}
+}
+
+func TestApplyRejectsConflicts(t *testing.T) {
+	content := "one\ntwo\nthree\n"
+	hunks := parseHunks(t, "@@ -1,2 +1,2 @@\n-one\n+ONE\n two\n@@ -3 +3 @@\n-tree\n+THREE\n")
+
+	got, res, err := Apply(content, hunks, DefaultFuzz)
+	if err == nil {
+		t.Fatal("Apply() error = nil, want rejected hunk")
+	}
+	if got != "ONE\ntwo\nthree\n" {
+		t.Errorf("Apply() = %q, want the first hunk applied", got)
+	}
+	if !res[0].Applied || res[1].Applied {
+		t.Fatalf("Apply() results = %+v, want hunk 1 applied and hunk 2 rejected", res)
+	}
+	if !strings.Contains(res[1].Error, `expected "tree" at line 3, found "three"`) {
+		t.Errorf("hunk 2 error = %q", res[1].Error)
+	}
+}
+
+func TestApplyCreatesContent(t *testing.T) {
+	got, _, err := Apply("", parseHunks(t, "@@ -0,0 +1,2 @@\n+# New\n+body\n"), DefaultFuzz)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got != "# New\nbody\n" {
+		t.Errorf("Apply() = %q, want %q", got, "# New\nbody\n")
+	}
+}
@@ -0,0 +1,248 @@
+// Package diff parses unified diffs and applies them to text with offset
+// and fuzz tolerance, the way patch(1) does.
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Line kinds within a hunk.
+const (
+	Context = ' '
+	Delete  = '-'
+	Insert  = '+'
+)
+
+type (
+	// Line is a single line of a hunk.
+	Line struct {
+		Kind byte
+		Text string
+	}
+
+	// Hunk is a set of changes to a contiguous region of a file. OldStart
+	// and NewStart are 1-based, as in the "@@ -l,s +l,s @@" header.
+	// NoNewlineOld and NoNewlineNew record "\ No newline at end of file"
+	// markers for the last line of the old and new text.
+	Hunk struct {
+		OldStart     int
+		OldLines     int
+		NewStart     int
+		NewLines     int
+		Section      string
+		Lines        []Line
+		NoNewlineOld bool
+		NoNewlineNew bool
+	}
+
+	// FileDiff holds the hunks for a single file. OldPath is empty when the
+	// file is created and NewPath is empty when it is deleted.
+	FileDiff struct {
+		OldPath string
+		NewPath string
+		Hunks   []Hunk
+	}
+)
+
+const devNull = "/dev/null"
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// Path returns the path the diff applies to: the new path, or the old path
+// when the file is deleted.
+func (f FileDiff) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Old returns the lines the hunk expects to find.
+func (h Hunk) Old() []string {
+	return h.side(Delete)
+}
+
+// New returns the lines the hunk leaves in place of Old.
+func (h Hunk) New() []string {
+	return h.side(Insert)
+}
+
+func (h Hunk) side(kind byte) []string {
+	lines := []string{}
+	for _, l := range h.Lines {
+		if l.Kind == Context || l.Kind == kind {
+			lines = append(lines, l.Text)
+		}
+	}
+	return lines
+}
+
+// Parse parses a unified diff that may cover several files. Git headers
+// ("diff --git", "index", mode lines) are skipped. The line counts in hunk
+// headers are used to find the end of each hunk but are not required to be
+// exact, since hand-written diffs often get them wrong.
+func Parse(patch string) ([]FileDiff, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+
+	var (
+		files   []FileDiff
+		current *FileDiff
+	)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			files = append(files, FileDiff{
+				OldPath: parsePath(line[4:]),
+				NewPath: parsePath(lines[i+1][4:]),
+			})
+			current = &files[len(files)-1]
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: hunk without a file header", i+1)
+			}
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			current.Hunks = append(current.Hunks, hunk)
+			i = next - 1
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file headers found in patch")
+	}
+	for _, f := range files {
+		if f.OldPath == "" && f.NewPath == "" {
+			return nil, fmt.Errorf("diff has no file path")
+		}
+	}
+	stripGitPrefixes(files)
+	return files, nil
+}
+
+// parseHunk parses the hunk whose header is lines[start] and returns it
+// along with the index of the first line after it.
+func parseHunk(lines []string, start int) (Hunk, int, error) {
+	m := hunkHeaderPattern.FindStringSubmatch(lines[start])
+	if m == nil {
+		return Hunk{}, 0, fmt.Errorf("line %d: invalid hunk header: %s", start+1, lines[start])
+	}
+	hunk := Hunk{
+		OldStart: atoi(m[1], 0),
+		OldLines: atoi(m[2], 1),
+		NewStart: atoi(m[3], 0),
+		NewLines: atoi(m[4], 1),
+		Section:  m[5],
+	}
+
+	oldSeen, newSeen := 0, 0
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		complete := oldSeen >= hunk.OldLines && newSeen >= hunk.NewLines
+
+		if strings.HasPrefix(line, `\`) {
+			if n := len(hunk.Lines); n > 0 {
+				switch hunk.Lines[n-1].Kind {
+				case Delete:
+					hunk.NoNewlineOld = true
+				case Insert:
+					hunk.NoNewlineNew = true
+				default:
+					hunk.NoNewlineOld, hunk.NoNewlineNew = true, true
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "@@") || isFileHeader(lines, i) || strings.HasPrefix(line, "diff ") {
+			break
+		}
+		if line == "" {
+			// Editors often strip the space from empty context lines.
+			if complete || i == len(lines)-1 {
+				break
+			}
+			line = " "
+		}
+
+		kind := line[0]
+		switch kind {
+		case Context:
+			oldSeen++
+			newSeen++
+		case Delete:
+			oldSeen++
+		case Insert:
+			newSeen++
+		default:
+			if complete {
+				return finishHunk(hunk), i, nil
+			}
+			return Hunk{}, 0, fmt.Errorf("line %d: unexpected line in hunk: %s", i+1, line)
+		}
+		hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: line[1:]})
+	}
+	return finishHunk(hunk), i, nil
+}
+
+// finishHunk replaces the header line counts with the real ones.
+func finishHunk(h Hunk) Hunk {
+	h.OldLines, h.NewLines = len(h.Old()), len(h.New())
+	return h
+}
+
+func isFileHeader(lines []string, i int) bool {
+	return strings.HasPrefix(lines[i], "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
+
+// parsePath extracts the path from a "---" or "+++" header, dropping any
+// timestamp and surrounding quotes.
+func parsePath(header string) string {
+	if idx := strings.IndexByte(header, '\t'); idx != -1 {
+		header = header[:idx]
+	}
+	header = strings.TrimSpace(header)
+	if unquoted, err := strconv.Unquote(header); err == nil {
+		header = unquoted
+	}
+	if header == devNull {
+		return ""
+	}
+	return header
+}
+
+// stripGitPrefixes removes the "a/" and "b/" prefixes git adds to paths,
+// but only when every path in the patch carries them.
+func stripGitPrefixes(files []FileDiff) {
+	for _, f := range files {
+		if f.OldPath != "" && !strings.HasPrefix(f.OldPath, "a/") ||
+			f.NewPath != "" && !strings.HasPrefix(f.NewPath, "b/") {
+			return
+		}
+	}
+	for i := range files {
+		if files[i].OldPath != "" {
+			files[i].OldPath = files[i].OldPath[2:]
+		}
+		if files[i].NewPath != "" {
+			files[i].NewPath = files[i].NewPath[2:]
+		}
+	}
+}
+
+func atoi(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	patch := `diff --git a/notes/one.md b/notes/one.md
+index 83db48f..bf269f4 100644
+--- a/notes/one.md
++++ b/notes/one.md
+@@ -1,3 +1,3 @@ # Title
+ first
+
+-second
++SECOND
+@@ -10 +10,2 @@
+ tenth
++eleventh
+\ No newline at end of file
+--- /dev/null
++++ b/new.md
+@@ -0,0 +1 @@
++created
+`
+
+	files, err := Parse(patch)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Parse() returned %d files, want 2", len(files))
+	}
+
+	one := files[0]
+	if one.OldPath != "notes/one.md" || one.NewPath != "notes/one.md" || len(one.Hunks) != 2 {
+		t.Fatalf("Parse()[0] = %+v", one)
+	}
+	first := one.Hunks[0]
+	if first.OldStart != 1 || first.Section != "# Title" {
+		t.Errorf("hunk 1 header = %+v", first)
+	}
+	if got, want := first.Old(), []string{"first", "", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hunk 1 Old() = %q, want %q", got, want)
+	}
+	if got, want := first.New(), []string{"first", "", "SECOND"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hunk 1 New() = %q, want %q", got, want)
+	}
+	if second := one.Hunks[1]; !second.NoNewlineNew || second.NoNewlineOld || second.OldLines != 1 || second.NewLines != 2 {
+		t.Errorf("hunk 2 = %+v", second)
+	}
+
+	created := files[1]
+	if created.OldPath != "" || created.Path() != "new.md" {
+		t.Errorf("Parse()[1] paths = %q, %q", created.OldPath, created.NewPath)
+	}
+}
+
+func TestParseWrongCounts(t *testing.T) {
+	// The header undercounts the lines; trailing hunk lines are still read.
+	patch := "--- note.md\n+++ note.md\n@@ -1,1 +1,1 @@\n a\n-b\n+c\n"
+
+	files, err := Parse(patch)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if files[0].Path() != "note.md" {
+		t.Errorf("Path() = %q, want note.md", files[0].Path())
+	}
+	hunk := files[0].Hunks[0]
+	if hunk.OldLines != 2 || hunk.NewLines != 2 {
+		t.Errorf("hunk counts = %d, %d, want 2, 2", hunk.OldLines, hunk.NewLines)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no headers":      "just some text\n",
+		"orphan hunk":     "@@ -1 +1 @@\n-a\n+b\n",
+		"bad hunk header": "--- a\n+++ a\n@@ bogus @@\n",
+		"bad hunk line":   "--- a\n+++ a\n@@ -1,2 +1,2 @@\n a\nmissing prefix\n",
+	}
+
+	for name, patch := range tests {
+		if _, err := Parse(patch); err == nil {
+			t.Errorf("Parse(%s) error = nil, want error", name)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+// in a unified diff.
+const DefaultContext = 3
+
+// maxLCSCells bounds the work spent on finding a minimal diff; beyond it
+// the changed region is reported as a single replacement.
+const maxLCSCells = 4 << 20
+
+// Unified returns a unified diff turning a into b, with the given file
+// names in the header. It returns an empty string when a and b have the
+// same lines.
+func Unified(fromName, toName, a, b string, context int) string {
+	oldLines, oldNewline := splitLines(a)
+	newLines, newNewline := splitLines(b)
+	ops := lineOps(oldLines, newLines)
+
+	var changes []int
+	for i, op := range ops {
+		if op.Kind != Context {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	// Position of each op in the old and new text.
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if op.Kind != Insert {
+			oldPos[i+1]++
+		}
+		if op.Kind != Delete {
+			newPos[i+1]++
+		}
+	}
+
+	var b2 strings.Builder
+	fmt.Fprintf(&b2, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context {
+			j++
+		}
+		start := max(changes[i]-context, 0)
+		end := min(changes[j]+context+1, len(ops))
+
+		oldCount := oldPos[end] - oldPos[start]
+		newCount := newPos[end] - newPos[start]
+		fmt.Fprintf(&b2, "@@ -%s +%s @@\n", hunkRange(oldPos[start], oldCount), hunkRange(newPos[start], newCount))
+
+		for k := start; k < end; k++ {
+			op := ops[k]
+			b2.WriteByte(op.Kind)
+			b2.WriteString(op.Text)
+			b2.WriteByte('\n')
+
+			lastOld := op.Kind != Insert && oldPos[k] == len(oldLines)-1 && !oldNewline
+			lastNew := op.Kind != Delete && newPos[k] == len(newLines)-1 && !newNewline
+			if lastOld || lastNew {
+				b2.WriteString("\\ No newline at end of file\n")
+			}
+		}
+		i = j + 1
+	}
+	return b2.String()
+}
+
+// hunkRange formats the start,count part of a hunk header. start is the
+// 0-based index of the first line.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// lineOps returns the edit script turning a into b: common lines as
+// context, plus deletions and insertions.
+func lineOps(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []Line
+	for _, line := range a[:prefix] {
+		ops = append(ops, Line{Kind: Context, Text: line})
+	}
+	ops = append(ops, middleOps(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, Line{Kind: Context, Text: line})
+	}
+	return ops
+}
+
+// middleOps diffs the region between the common prefix and suffix using
+// the longest common subsequence of lines.
+func middleOps(a, b []string) []Line {
+	var ops []Line
+	if len(a)*len(b) > maxLCSCells {
+		for _, line := range a {
+			ops = append(ops, Line{Kind: Delete, Text: line})
+		}
+		for _, line := range b {
+			ops = append(ops, Line{Kind: Insert, Text: line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, Line{Kind: Context, Text: a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, Line{Kind: Insert, Text: b[j]})
+			j++
+		default:
+			ops = append(ops, Line{Kind: Delete, Text: a[i]})
+			i++
+		}
+	}
+	return ops
+}
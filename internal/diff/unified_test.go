@@ -0,0 +1,67 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo\n",
+			want: "",
+		},
+		{
+			name: "change in the middle",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name: "insert into empty",
+			a:    "",
+			b:    "new\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n",
+		},
+		{
+			name: "no newline at end",
+			a:    "one\ntwo",
+			b:    "one\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n one\n-two\n\\ No newline at end of file\n+three\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a", "b", tt.a, tt.b, DefaultContext); got != tt.want {
+				t.Errorf("Unified() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedRoundTrip(t *testing.T) {
+	a := "# Title\n\nintro\n- one\n- two\n- three\n\n## Notes\nkeep\nthis\n"
+	b := "# Title\n\nnew intro\n- one\n- three\n- four\n\n## Notes\nkeep\n"
+
+	files, err := Parse(Unified("note.md", "note.md", a, b, DefaultContext))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	got, _, err := Apply(a, files[0].Hunks, 0)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got != b {
+		t.Errorf("Apply(Unified()) = %q, want %q", got, b)
+	}
+}
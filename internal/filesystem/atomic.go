@@ -0,0 +1,67 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultFileMode is the mode of newly created notes.
+const defaultFileMode fs.FileMode = 0o644
+
+// writeFileAtomic replaces the file at fullPath with data so that readers
+// and crashes only ever see the old or the new content. The data is
+// written to a temporary file in the same directory, synced and renamed
+// over the target. The file gets mode perm; if perm is zero, an existing
+// file keeps its mode and a new one gets defaultFileMode.
+func writeFileAtomic(fullPath string, data []byte, perm fs.FileMode) (err error) {
+	mode := perm
+	if mode == 0 {
+		mode = defaultFileMode
+		if info, statErr := os.Stat(fullPath); statErr == nil {
+			mode = info.Mode().Perm()
+		} else if !errors.Is(statErr, fs.ErrNotExist) {
+			return statErr
+		}
+	}
+
+	dir := filepath.Dir(fullPath)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(fullPath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), fullPath); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes a rename in dir to disk. Errors are ignored since not
+// every platform can sync a directory.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -4,6 +4,7 @@ package filesystem
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"maps"
 	"os"
@@ -12,7 +13,11 @@ import (
 	"strings"
 
 	"github.com/taigrr/obsidian-mcp/internal/frontmatter"
+	"github.com/taigrr/obsidian-mcp/internal/journal"
+	"github.com/taigrr/obsidian-mcp/internal/markdown"
 	"github.com/taigrr/obsidian-mcp/internal/pathfilter"
+	"github.com/taigrr/obsidian-mcp/internal/properties"
+	"github.com/taigrr/obsidian-mcp/internal/schema"
 	"github.com/taigrr/obsidian-mcp/internal/types"
 )
 
@@ -21,6 +26,12 @@ type Service struct {
 	vaultPath          string
 	pathFilter         *pathfilter.PathFilter
 	frontmatterHandler *frontmatter.Handler
+	versions           *versionCache
+	locks              *pathLocks
+	journal            *journal.Journal
+	hooks              []CommitHook
+	schemas            *schema.Loader
+	properties         *properties.Loader
 }
 
 // New creates a new FileSystemService.
@@ -36,6 +47,11 @@ func New(vaultPath string, pf *pathfilter.PathFilter, fh *frontmatter.Handler) *
 		vaultPath:          absPath,
 		pathFilter:         pf,
 		frontmatterHandler: fh,
+		versions:           newVersionCache(),
+		locks:              newPathLocks(),
+		journal:            journal.New(absPath),
+		schemas:            schema.NewLoader(absPath),
+		properties:         properties.NewLoader(absPath),
 	}
 }
 
@@ -70,39 +86,79 @@ func (s *Service) ResolvePath(relativePath string) (string, error) {
 	return absPath, nil
 }
 
+// CheckPath reports an error unless path is inside the vault and allowed
+// by the path filter.
+func (s *Service) CheckPath(path string) error {
+	if _, err := s.ResolvePath(path); err != nil {
+		return err
+	}
+	if !s.pathFilter.IsAllowed(strings.TrimPrefix(strings.TrimSpace(path), "/")) {
+		return fmt.Errorf("access denied: %s", path)
+	}
+	return nil
+}
+
 // ReadNote reads a note from the vault.
 func (s *Service) ReadNote(path string) (types.ParsedNote, error) {
-	fullPath, err := s.ResolvePath(path)
+	content, _, err := s.readFile(path)
 	if err != nil {
 		return types.ParsedNote{}, err
 	}
+	return s.frontmatterHandler.Parse(string(content)), nil
+}
+
+// readFile returns the raw content of a note and its file info.
+func (s *Service) readFile(path string) ([]byte, fs.FileInfo, error) {
+	fullPath, err := s.ResolvePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	if !s.pathFilter.IsAllowed(path) {
-		return types.ParsedNote{}, fmt.Errorf("access denied: %s", path)
+		return nil, nil, fmt.Errorf("access denied: %s", path)
 	}
 
 	// Check if the path is a directory first
 	isDir, _ := s.IsDirectory(path)
 	if isDir {
-		return types.ParsedNote{}, fmt.Errorf("cannot read directory as file: %s. Use list_directory tool instead", path)
+		return nil, nil, fmt.Errorf("cannot read directory as file: %s. Use list_directory tool instead", path)
 	}
 
-	content, err := os.ReadFile(fullPath)
+	f, err := os.Open(fullPath)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
-			return types.ParsedNote{}, fmt.Errorf("file not found: %s", path)
+			return nil, nil, fmt.Errorf("file not found: %s", path)
 		}
 		if errors.Is(err, fs.ErrPermission) {
-			return types.ParsedNote{}, fmt.Errorf("permission denied: %s", path)
+			return nil, nil, fmt.Errorf("permission denied: %s", path)
 		}
-		return types.ParsedNote{}, fmt.Errorf("failed to read file: %s - %w", path, err)
+		return nil, nil, fmt.Errorf("failed to read file: %s - %w", path, err)
 	}
+	defer f.Close()
 
-	return s.frontmatterHandler.Parse(string(content)), nil
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %s - %w", path, err)
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %s - %w", path, err)
+	}
+	return content, info, nil
 }
 
 // WriteNote writes a note to the vault.
 func (s *Service) WriteNote(params types.NoteWriteParams) error {
+	_, err := s.Apply([]string{params.Path}, false, func(tx *Tx) error {
+		tx.SetCause("write", params)
+		return tx.WriteNote(params)
+	})
+	return err
+}
+
+// composeNote returns the content WriteNote writes for params, given the
+// existing note and whether it exists.
+func (s *Service) composeNote(params types.NoteWriteParams, existingNote types.ParsedNote, exists bool) (string, error) {
 	path := params.Path
 	content := params.Content
 	fm := params.Frontmatter
@@ -111,86 +167,139 @@ func (s *Service) WriteNote(params types.NoteWriteParams) error {
 		mode = "overwrite"
 	}
 
-	fullPath, err := s.ResolvePath(path)
-	if err != nil {
-		return err
-	}
-
-	if !s.pathFilter.IsAllowed(path) {
-		return fmt.Errorf("access denied: %s", path)
-	}
-
 	// Validate frontmatter if provided
 	if fm != nil {
 		validation := s.frontmatterHandler.Validate(fm)
 		if !validation.IsValid {
-			return fmt.Errorf("invalid frontmatter: %s", strings.Join(validation.Errors, ", "))
+			return "", fmt.Errorf("invalid frontmatter: %s", strings.Join(validation.Errors, ", "))
 		}
 	}
 
-	var finalContent string
+	if params.Heading != "" && mode == "overwrite" {
+		return "", fmt.Errorf("heading requires append or prepend mode")
+	}
+
+	switch mode {
+	case "overwrite":
+		if fm != nil {
+			return s.frontmatterHandler.Update(existingNote.OriginalContent, fm, content)
+		}
+		return content, nil
+	case "append", "prepend":
+		if !exists && !params.CreateIfMissing {
+			return "", fmt.Errorf("file not found: %s (set createIfMissing to create it)", path)
+		}
+
+		newContent, err := insertContent(existingNote.Content, params)
+		if err != nil {
+			return "", err
+		}
 
-	if mode == "overwrite" {
 		if fm != nil {
-			finalContent, err = s.frontmatterHandler.Stringify(fm, content)
-			if err != nil {
-				return err
-			}
-		} else {
-			finalContent = content
-		}
-	} else {
-		// For append/prepend, we need to read existing content
-		existingNote, readErr := s.ReadNote(path)
-		if readErr != nil {
-			// File doesn't exist, treat as overwrite
-			if fm != nil {
-				finalContent, err = s.frontmatterHandler.Stringify(fm, content)
-				if err != nil {
-					return err
-				}
-			} else {
-				finalContent = content
-			}
-		} else {
 			// Merge frontmatter if provided
-			mergedFrontmatter := existingNote.Frontmatter
-			if fm != nil {
-				if mergedFrontmatter == nil {
-					mergedFrontmatter = make(map[string]any)
-				}
-				maps.Copy(mergedFrontmatter, fm)
-			}
+			mergedFrontmatter := make(map[string]any)
+			maps.Copy(mergedFrontmatter, existingNote.Frontmatter)
+			maps.Copy(mergedFrontmatter, fm)
+			return s.frontmatterHandler.Update(existingNote.OriginalContent, mergedFrontmatter, newContent)
+		}
 
-			var newContent string
-			switch mode {
-			case "append":
-				newContent = existingNote.Content + content
-			case "prepend":
-				newContent = content + existingNote.Content
-			}
+		// Keep the existing frontmatter byte for byte
+		prefix := existingNote.OriginalContent[:len(existingNote.OriginalContent)-len(existingNote.Content)]
+		return prefix + newContent, nil
+	default:
+		return "", fmt.Errorf("invalid write mode: %s (use overwrite, append or prepend)", mode)
+	}
+}
 
-			finalContent, err = s.frontmatterHandler.Stringify(mergedFrontmatter, newContent)
-			if err != nil {
-				return err
-			}
+// insertContent adds params.Content to the body of a note according to
+// params.Mode. Without a heading, the content goes at the end (append) or
+// the start (prepend) of the body, which is right after the frontmatter.
+// With a heading, it goes at the end or the start of that heading's
+// section; when params.CreateIfMissing is set, the missing headings of the
+// path are added under the deepest one that exists.
+func insertContent(body string, params types.NoteWriteParams) (string, error) {
+	separator := params.Separator
+	if separator == "" {
+		separator = "\n"
+	}
+
+	if params.Heading == "" {
+		if params.Mode == "append" {
+			return joinWithSeparator(body, params.Content, separator), nil
 		}
+		return joinWithSeparator(params.Content, body, separator), nil
 	}
 
-	// Create directories if they don't exist
-	dir := filepath.Dir(fullPath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	headingPath := markdown.SplitHeadingPath(params.Heading)
+	if len(headingPath) == 0 {
+		return "", fmt.Errorf("heading cannot be empty")
 	}
 
-	if err := os.WriteFile(fullPath, []byte(finalContent), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %s - %w", path, err)
+	if _, ok := markdown.FindHeading(body, headingPath); !ok {
+		if !params.CreateIfMissing {
+			return "", fmt.Errorf("heading not found: %s", params.Heading)
+		}
+		return createHeadings(body, headingPath, params.Content)
 	}
 
-	return nil
+	op := markdown.SectionAppend
+	if params.Mode == "prepend" {
+		op = markdown.SectionPrepend
+	}
+	updated, _, err := markdown.EditSection(body, headingPath, markdown.SectionEdit{Op: op, Text: params.Content, Separator: params.Separator})
+	return updated, err
+}
+
+// createHeadings adds the headings of path that body lacks, each nested in
+// the previous one, below the deepest heading of path that body has, and
+// puts content in the last of them. With none of them in body, they go at
+// the end of the note starting at level one.
+func createHeadings(body string, path []string, content string) (string, error) {
+	for depth := len(path) - 1; depth > 0; depth-- {
+		parent, ok := markdown.FindHeading(body, path[:depth])
+		if !ok {
+			continue
+		}
+		updated, _, err := markdown.EditSection(body, path[:depth], markdown.SectionEdit{
+			Op:      markdown.SectionInsert,
+			Heading: path[depth],
+			Text:    nestedHeadings(path[depth+1:], parent.Level+2, content),
+		})
+		return updated, err
+	}
+
+	section := nestedHeadings(path, 1, content)
+	if strings.TrimSpace(body) == "" {
+		return section, nil
+	}
+	if strings.HasSuffix(body, "\n") && !strings.HasSuffix(section, "\n") {
+		section += "\n"
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + section, nil
+}
+
+// nestedHeadings returns a heading for each component of path, starting at
+// level and each one level below the previous, followed by content.
+func nestedHeadings(path []string, level int, content string) string {
+	var b strings.Builder
+	for i, name := range path {
+		b.WriteString(strings.Repeat("#", min(level+i, 6)) + " " + name + "\n\n")
+	}
+	return b.String() + content
 }
 
-// PatchNote patches a note by replacing a specific string.
+// joinWithSeparator joins two pieces of content, inserting separator
+// between them unless either side is empty or already provides it.
+func joinWithSeparator(first, second, separator string) string {
+	if first == "" || second == "" ||
+		strings.HasSuffix(first, separator) || strings.HasPrefix(second, separator) {
+		return first + second
+	}
+	return first + separator + second
+}
+
+// PatchNote patches a note by replacing a specific string. The change is
+// journaled like any other.
 func (s *Service) PatchNote(params types.PatchNoteParams) types.PatchNoteResult {
 	path := params.Path
 	oldString := params.OldString
@@ -231,68 +340,44 @@ func (s *Service) PatchNote(params types.PatchNoteParams) types.PatchNoteResult
 		}
 	}
 
-	// Read the existing note
-	note, err := s.ReadNote(path)
-	if err != nil {
-		return types.PatchNoteResult{
-			Success: false,
-			Path:    path,
-			Message: fmt.Sprintf("Failed to patch note: %v", err),
-		}
-	}
-
-	// Get the full content with frontmatter
-	fullContent := note.OriginalContent
-
-	// Count occurrences of oldString
-	occurrences := strings.Count(fullContent, oldString)
-
-	if occurrences == 0 {
-		truncated := oldString
-		if len(truncated) > 50 {
-			truncated = truncated[:50] + "..."
-		}
-		return types.PatchNoteResult{
-			Success:    false,
-			Path:       path,
-			Message:    fmt.Sprintf("String not found in note: \"%s\"", truncated),
-			MatchCount: 0,
-		}
-	}
-
-	// If not replaceAll and multiple occurrences exist, fail
-	if !replaceAll && occurrences > 1 {
+	// The update fails without writing, setting mismatch, unless the
+	// string occurs once or replaceAll is set.
+	occurrences := 0
+	var mismatch string
+	_, err := s.Apply([]string{path}, false, func(tx *Tx) error {
+		tx.SetCause("patch", params)
+		return tx.UpdateNote(path, "", func(content string) (string, error) {
+			occurrences = strings.Count(content, oldString)
+			switch {
+			case occurrences == 0:
+				truncated := oldString
+				if len(truncated) > 50 {
+					truncated = truncated[:50] + "..."
+				}
+				mismatch = fmt.Sprintf("String not found in note: \"%s\"", truncated)
+				return "", errors.New(mismatch)
+			case !replaceAll && occurrences > 1:
+				mismatch = fmt.Sprintf("Found %d occurrences of the string. Use replaceAll=true to replace all occurrences, or provide a more specific string to match exactly one occurrence.", occurrences)
+				return "", errors.New(mismatch)
+			case replaceAll:
+				return strings.ReplaceAll(content, oldString, newString), nil
+			}
+			return strings.Replace(content, oldString, newString, 1), nil
+		})
+	})
+	if mismatch != "" {
 		return types.PatchNoteResult{
 			Success:    false,
 			Path:       path,
-			Message:    fmt.Sprintf("Found %d occurrences of the string. Use replaceAll=true to replace all occurrences, or provide a more specific string to match exactly one occurrence.", occurrences),
+			Message:    mismatch,
 			MatchCount: occurrences,
 		}
 	}
-
-	// Perform the replacement
-	var updatedContent string
-	if replaceAll {
-		updatedContent = strings.ReplaceAll(fullContent, oldString, newString)
-	} else {
-		updatedContent = strings.Replace(fullContent, oldString, newString, 1)
-	}
-
-	// Write the updated content
-	fullPath, err := s.ResolvePath(path)
 	if err != nil {
 		return types.PatchNoteResult{
 			Success: false,
 			Path:    path,
-			Message: fmt.Sprintf("Failed to resolve path: %v", err),
-		}
-	}
-
-	if err := os.WriteFile(fullPath, []byte(updatedContent), 0o644); err != nil {
-		return types.PatchNoteResult{
-			Success: false,
-			Path:    path,
-			Message: fmt.Sprintf("Failed to write file: %v", err),
+			Message: fmt.Sprintf("Failed to patch note: %v", err),
 		}
 	}
 
@@ -314,6 +399,25 @@ func (s *Service) PatchNote(params types.PatchNoteParams) types.PatchNoteResult
 	}
 }
 
+// UpdateNote reads the raw content of an existing note, passes it to fn and
+// writes back the content fn returns. The file is left untouched when fn
+// returns an error or the content is unchanged. It reports whether the note
+// was written.
+func (s *Service) UpdateNote(path string, fn func(content string) (string, error)) (bool, error) {
+	return s.UpdateNoteIfMatch(path, "", fn)
+}
+
+// UpdateNoteIfMatch is like UpdateNote but first checks that the note is at
+// the given version, returning a *ConflictError if it is not. The note is
+// locked from the check until the write completes.
+func (s *Service) UpdateNoteIfMatch(path, version string, fn func(content string) (string, error)) (bool, error) {
+	changes, err := s.Apply([]string{path}, false, func(tx *Tx) error {
+		tx.SetCause("update", map[string]string{"path": path})
+		return tx.UpdateNote(path, version, fn)
+	})
+	return len(changes) > 0, err
+}
+
 // ListDirectory lists files and directories in the vault.
 func (s *Service) ListDirectory(path string) (types.DirectoryListing, error) {
 	// Normalize path: treat '.' as root directory
@@ -415,8 +519,7 @@ func (s *Service) DeleteNote(params types.DeleteNoteParams) types.DeleteResult {
 		}
 	}
 
-	fullPath, err := s.ResolvePath(path)
-	if err != nil {
+	if _, err := s.ResolvePath(path); err != nil {
 		return types.DeleteResult{
 			Success: false,
 			Path:    path,
@@ -442,22 +545,19 @@ func (s *Service) DeleteNote(params types.DeleteNoteParams) types.DeleteResult {
 		}
 	}
 
-	// Perform the deletion
-	if err := os.Remove(fullPath); err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return types.DeleteResult{
-				Success: false,
-				Path:    path,
-				Message: fmt.Sprintf("File not found: %s", path),
-			}
-		}
-		if errors.Is(err, fs.ErrPermission) {
-			return types.DeleteResult{
-				Success: false,
-				Path:    path,
-				Message: fmt.Sprintf("Permission denied: %s", path),
-			}
+	if !s.Exists(path) {
+		return types.DeleteResult{
+			Success: false,
+			Path:    path,
+			Message: fmt.Sprintf("File not found: %s", path),
 		}
+	}
+
+	changes, err := s.Apply([]string{path}, params.DryRun, func(tx *Tx) error {
+		tx.SetCause("delete", params)
+		return tx.DeleteNote(params)
+	})
+	if err != nil {
 		return types.DeleteResult{
 			Success: false,
 			Path:    path,
@@ -465,10 +565,28 @@ func (s *Service) DeleteNote(params types.DeleteNoteParams) types.DeleteResult {
 		}
 	}
 
+	if params.DryRun {
+		return types.DeleteResult{
+			Success: true,
+			Path:    path,
+			Message: fmt.Sprintf("Dry run: %s would be deleted", path),
+			Changes: changes,
+		}
+	}
+	var trash string
+	if len(changes) == 1 {
+		trash = changes[0].Trash
+	}
+	message := fmt.Sprintf("Successfully deleted note: %s. It was deleted permanently.", path)
+	if trash != "" {
+		message = fmt.Sprintf("Successfully deleted note: %s. It was moved to the trash; restore it with id %q.", path, trash)
+	}
 	return types.DeleteResult{
 		Success: true,
 		Path:    path,
-		Message: fmt.Sprintf("Successfully deleted note: %s. This action cannot be undone.", path),
+		Message: message,
+		Trash:   trash,
+		Changes: changes,
 	}
 }
 
@@ -476,7 +594,6 @@ func (s *Service) DeleteNote(params types.DeleteNoteParams) types.DeleteResult {
 func (s *Service) MoveNote(params types.MoveNoteParams) types.MoveResult {
 	oldPath := params.OldPath
 	newPath := params.NewPath
-	overwrite := params.Overwrite
 
 	if !s.pathFilter.IsAllowed(oldPath) {
 		return types.MoveResult{
@@ -496,8 +613,7 @@ func (s *Service) MoveNote(params types.MoveNoteParams) types.MoveResult {
 		}
 	}
 
-	oldFullPath, err := s.ResolvePath(oldPath)
-	if err != nil {
+	if _, err := s.ResolvePath(oldPath); err != nil {
 		return types.MoveResult{
 			Success: false,
 			OldPath: oldPath,
@@ -506,8 +622,7 @@ func (s *Service) MoveNote(params types.MoveNoteParams) types.MoveResult {
 		}
 	}
 
-	newFullPath, err := s.ResolvePath(newPath)
-	if err != nil {
+	if _, err := s.ResolvePath(newPath); err != nil {
 		return types.MoveResult{
 			Success: false,
 			OldPath: oldPath,
@@ -516,74 +631,58 @@ func (s *Service) MoveNote(params types.MoveNoteParams) types.MoveResult {
 		}
 	}
 
-	// Read source content
-	content, err := os.ReadFile(oldFullPath)
+	var staged *Tx
+	changes, err := s.Apply([]string{oldPath, newPath}, params.DryRun, func(tx *Tx) error {
+		staged = tx
+		tx.SetCause("rename", params)
+		return tx.MoveNote(params)
+	})
 	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return types.MoveResult{
-				Success: false,
-				OldPath: oldPath,
-				NewPath: newPath,
-				Message: fmt.Sprintf("Source file not found: %s", oldPath),
-			}
-		}
 		return types.MoveResult{
 			Success: false,
 			OldPath: oldPath,
 			NewPath: newPath,
-			Message: fmt.Sprintf("Failed to read source file: %v", err),
+			Message: fmt.Sprintf("Failed to move note: %v", err),
 		}
 	}
 
-	// Create directories if needed
-	dir := filepath.Dir(newFullPath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return types.MoveResult{
-			Success: false,
-			OldPath: oldPath,
-			NewPath: newPath,
-			Message: fmt.Sprintf("Failed to create directory: %v", err),
-		}
+	message := fmt.Sprintf("Successfully moved note from %s to %s", oldPath, newPath)
+	if params.DryRun {
+		message = fmt.Sprintf("Dry run: %s would be moved to %s", oldPath, newPath)
 	}
-
-	// Check if target exists
-	if !overwrite {
-		if _, err := os.Stat(newFullPath); err == nil {
-			return types.MoveResult{
-				Success: false,
-				OldPath: oldPath,
-				NewPath: newPath,
-				Message: fmt.Sprintf("Target file already exists: %s. Use overwrite=true to replace it.", newPath),
-			}
-		}
+	result := types.MoveResult{
+		Success: true,
+		OldPath: oldPath,
+		NewPath: newPath,
+		Message: message,
+		Changes: changes,
 	}
-
-	// Write to new location
-	if err := os.WriteFile(newFullPath, content, 0o644); err != nil {
-		return types.MoveResult{
-			Success: false,
-			OldPath: oldPath,
-			NewPath: newPath,
-			Message: fmt.Sprintf("Failed to write to new location: %v", err),
-		}
+	if !params.DryRun {
+		result.Version = staged.Version(newPath)
 	}
+	return result
+}
 
-	// Delete the source file
-	if err := os.Remove(oldFullPath); err != nil {
-		return types.MoveResult{
-			Success: false,
-			OldPath: oldPath,
-			NewPath: newPath,
-			Message: fmt.Sprintf("Failed to delete source file: %v", err),
-		}
+// EnsureDataDir creates the vault's .obsidian-mcp folder, which holds the
+// journal, the snapshots and the schema file, with a .gitignore that keeps
+// everything but the schema file, such as the full note contents in the
+// journal, out of version control.
+func (s *Service) EnsureDataDir() error {
+	dir := filepath.Join(s.vaultPath, journal.Dir)
+	gitignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(gitignore); err == nil {
+		return nil
 	}
-
-	return types.MoveResult{
-		Success: true,
-		OldPath: oldPath,
-		NewPath: newPath,
-		Message: fmt.Sprintf("Successfully moved note from %s to %s", oldPath, newPath),
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
 	}
+	return os.WriteFile(gitignore, []byte("*\n!"+filepath.Base(filepath.FromSlash(schema.File))+"\n"), 0o644)
+}
+
+// Frontmatter returns the frontmatter handler the service parses and
+// writes notes with.
+func (s *Service) Frontmatter() *frontmatter.Handler {
+	return s.frontmatterHandler
 }
 
 // GetVaultPath returns the vault path.
@@ -1,16 +1,27 @@
 package filesystem
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/taigrr/obsidian-mcp/internal/journal"
+	"github.com/taigrr/obsidian-mcp/internal/schema"
 	"github.com/taigrr/obsidian-mcp/internal/types"
 )
 
 func setupTestVault(t *testing.T) (string, *Service) {
 	t.Helper()
+	// Deleted notes go to the system trash by default; keep it out of the
+	// user's home.
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
 	tmpDir, err := os.MkdirTemp("", "mcp-obsidian-test-*")
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
@@ -56,6 +67,20 @@ func TestService_PatchNote(t *testing.T) {
 		}
 	})
 
+	t.Run("is journaled", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte("old"), 0o644)
+		if result := svc.PatchNote(types.PatchNoteParams{Path: "note.md", OldString: "old", NewString: "new"}); !result.Success {
+			t.Fatalf("PatchNote() = %+v", result)
+		}
+		entries, _ := svc.History()
+		if len(entries) != 1 || entries[0].Tool != "patch" || *entries[0].Notes[0].Before != "old" {
+			t.Errorf("History() = %+v, want the patch", entries)
+		}
+	})
+
 	t.Run("multiple occurrences requires replaceAll", func(t *testing.T) {
 		tmpDir, svc := setupTestVault(t)
 		defer cleanupTestVault(t, tmpDir)
@@ -227,6 +252,31 @@ func TestService_DeleteNote(t *testing.T) {
 		if !strings.Contains(result.Message, "Successfully deleted") {
 			t.Errorf("Message should mention successful deletion: %s", result.Message)
 		}
+		if result.Trash == "" || !strings.Contains(result.Message, result.Trash) {
+			t.Errorf("Message should name the trash item %q: %s", result.Trash, result.Message)
+		}
+	})
+
+	t.Run("without a trash", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.MkdirAll(filepath.Join(tmpDir, ".obsidian"), 0o755)
+		os.WriteFile(filepath.Join(tmpDir, ".obsidian", "app.json"), []byte(`{"trashOption":"none"}`), 0o644)
+		testPath := "test-note.md"
+		os.WriteFile(filepath.Join(tmpDir, testPath), []byte("# Test Note\n"), 0o644)
+
+		result := svc.DeleteNote(types.DeleteNoteParams{
+			Path:        testPath,
+			ConfirmPath: testPath,
+		})
+
+		if !result.Success || result.Trash != "" {
+			t.Errorf("DeleteNote() = %+v, want a permanent deletion", result)
+		}
+		if !strings.Contains(result.Message, "deleted permanently") {
+			t.Errorf("Message should say the note was deleted permanently: %s", result.Message)
+		}
 	})
 
 	t.Run("reject with incorrect confirmation", func(t *testing.T) {
@@ -378,6 +428,155 @@ func TestService_WriteNote(t *testing.T) {
 			t.Errorf("Content should contain both original and appended content: %s", note.Content)
 		}
 	})
+
+	t.Run("append and prepend add separator", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		original := "---\n# keep this comment\ntitle: Journal\n---\nfirst"
+		os.WriteFile(filepath.Join(tmpDir, "journal.md"), []byte(original), 0o644)
+
+		writes := []types.NoteWriteParams{
+			{Path: "journal.md", Content: "second", Mode: "append"},
+			{Path: "journal.md", Content: "zeroth", Mode: "prepend"},
+			{Path: "journal.md", Content: "third", Mode: "append", Separator: "\n---\n"},
+		}
+		for _, params := range writes {
+			if err := svc.WriteNote(params); err != nil {
+				t.Fatalf("WriteNote(%s %q) error = %v", params.Mode, params.Content, err)
+			}
+		}
+
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "journal.md"))
+		want := "---\n# keep this comment\ntitle: Journal\n---\nzeroth\nfirst\nsecond\n---\nthird"
+		if string(data) != want {
+			t.Errorf("content = %q, want %q", data, want)
+		}
+	})
+
+	t.Run("frontmatter updates keep untouched keys", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		original := "---\ntitle: \"Journal\" # shown in lists\ntags: [daily]\n---\nfirst\n"
+		os.WriteFile(filepath.Join(tmpDir, "journal.md"), []byte(original), 0o644)
+
+		writes := []types.NoteWriteParams{
+			{Path: "journal.md", Content: "second\n", Mode: "append", Frontmatter: map[string]any{"mood": "good"}},
+			{Path: "journal.md", Content: "rewritten\n", Frontmatter: map[string]any{"title": "Journal", "tags": []any{"daily", "log"}}},
+		}
+		wants := []string{
+			"---\ntitle: \"Journal\" # shown in lists\ntags: [daily]\nmood: good\n---\nfirst\nsecond\n",
+			"---\ntitle: \"Journal\" # shown in lists\ntags: [daily, log]\n---\nrewritten\n",
+		}
+		for i, params := range writes {
+			if err := svc.WriteNote(params); err != nil {
+				t.Fatalf("WriteNote(%s) error = %v", params.Mode, err)
+			}
+			data, _ := os.ReadFile(filepath.Join(tmpDir, "journal.md"))
+			if string(data) != wants[i] {
+				t.Errorf("content = %q, want %q", data, wants[i])
+			}
+		}
+	})
+
+	t.Run("append requires createIfMissing for new notes", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		err := svc.WriteNote(types.NoteWriteParams{Path: "new.md", Content: "entry", Mode: "append"})
+		if err == nil || !strings.Contains(err.Error(), "createIfMissing") {
+			t.Fatalf("WriteNote(append) error = %v, want createIfMissing hint", err)
+		}
+		if svc.Exists("new.md") {
+			t.Error("new.md should not have been created")
+		}
+
+		err = svc.WriteNote(types.NoteWriteParams{Path: "logs/new.md", Content: "entry", Mode: "append", CreateIfMissing: true})
+		if err != nil {
+			t.Fatalf("WriteNote(append, createIfMissing) error = %v", err)
+		}
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "logs", "new.md"))
+		if string(data) != "entry" {
+			t.Errorf("content = %q, want %q", data, "entry")
+		}
+	})
+
+	t.Run("append under heading", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "day.md"), []byte("# Day\n\n## Log\n\n- 09:00 start\n\n## Notes\n\nNothing.\n"), 0o644)
+
+		if err := svc.WriteNote(types.NoteWriteParams{Path: "day.md", Content: "- 10:00 review", Mode: "append", Heading: "Log"}); err != nil {
+			t.Fatalf("WriteNote(append, heading) error = %v", err)
+		}
+		if err := svc.WriteNote(types.NoteWriteParams{Path: "day.md", Content: "- milk", Mode: "append", Heading: "Shopping"}); err == nil {
+			t.Error("WriteNote() to a missing heading error = nil, want error")
+		}
+		if err := svc.WriteNote(types.NoteWriteParams{Path: "day.md", Content: "- milk\n", Mode: "append", Heading: "Shopping", CreateIfMissing: true}); err != nil {
+			t.Fatalf("WriteNote(append, new heading) error = %v", err)
+		}
+
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "day.md"))
+		want := "# Day\n\n## Log\n\n- 09:00 start\n- 10:00 review\n\n## Notes\n\nNothing.\n\n# Shopping\n\n- milk\n"
+		if string(data) != want {
+			t.Errorf("content = %q, want %q", data, want)
+		}
+	})
+
+	t.Run("append under a missing nested heading", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "day.md"), []byte("# Day\n\nIntro.\n"), 0o644)
+		os.WriteFile(filepath.Join(tmpDir, "week.md"), []byte("# Week\n\n## Journal\n\nMonday.\n\n## Notes\n\nNothing.\n"), 0o644)
+
+		for _, entry := range []string{"- 09:00 start", "- 10:00 review"} {
+			for _, path := range []string{"day.md", "week.md"} {
+				if err := svc.WriteNote(types.NoteWriteParams{Path: path, Content: entry, Mode: "append", Heading: "Journal > Log", CreateIfMissing: true}); err != nil {
+					t.Fatalf("WriteNote(%s, append, nested heading) error = %v", path, err)
+				}
+			}
+		}
+
+		tests := map[string]string{
+			"day.md":  "# Day\n\nIntro.\n\n# Journal\n\n## Log\n\n- 09:00 start\n- 10:00 review\n",
+			"week.md": "# Week\n\n## Journal\n\nMonday.\n\n### Log\n\n- 09:00 start\n- 10:00 review\n\n## Notes\n\nNothing.\n",
+		}
+		for path, want := range tests {
+			if data, _ := os.ReadFile(filepath.Join(tmpDir, path)); string(data) != want {
+				t.Errorf("%s content = %q, want %q", path, data, want)
+			}
+		}
+	})
+
+	t.Run("append under heading with separator", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "day.md"), []byte("# Day\n\n## Tags\n\nwork\n\n## Notes\n"), 0o644)
+
+		if err := svc.WriteNote(types.NoteWriteParams{Path: "day.md", Content: "home", Mode: "append", Heading: "Tags", Separator: ", "}); err != nil {
+			t.Fatalf("WriteNote(append, heading, separator) error = %v", err)
+		}
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "day.md"))
+		if want := "# Day\n\n## Tags\n\nwork, home\n\n## Notes\n"; string(data) != want {
+			t.Errorf("content = %q, want %q", data, want)
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		if err := svc.WriteNote(types.NoteWriteParams{Path: "x.md", Content: "x", Mode: "insert"}); err == nil {
+			t.Error("WriteNote(insert) error = nil, want error")
+		}
+		if err := svc.WriteNote(types.NoteWriteParams{Path: "x.md", Content: "x", Heading: "Log"}); err == nil {
+			t.Error("WriteNote(overwrite, heading) error = nil, want error")
+		}
+	})
 }
 
 func TestService_PathTraversal(t *testing.T) {
@@ -467,6 +666,25 @@ func TestService_MoveNote(t *testing.T) {
 		}
 	})
 
+	t.Run("renames the file", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "original.md"), []byte("# Test Note"), 0o600)
+		before, _ := os.Stat(filepath.Join(tmpDir, "original.md"))
+
+		if result := svc.MoveNote(types.MoveNoteParams{OldPath: "original.md", NewPath: "dir/moved.md"}); !result.Success {
+			t.Fatalf("MoveNote() = %+v", result)
+		}
+		after, err := os.Stat(filepath.Join(tmpDir, "dir", "moved.md"))
+		if err != nil {
+			t.Fatalf("Stat(dir/moved.md) error = %v", err)
+		}
+		if !os.SameFile(before, after) || after.Mode().Perm() != 0o600 {
+			t.Errorf("dir/moved.md is not the renamed original (mode %v)", after.Mode())
+		}
+	})
+
 	t.Run("fail without overwrite when target exists", func(t *testing.T) {
 		tmpDir, svc := setupTestVault(t)
 		defer cleanupTestVault(t, tmpDir)
@@ -489,6 +707,382 @@ func TestService_MoveNote(t *testing.T) {
 	})
 }
 
+func TestService_UpdateNote(t *testing.T) {
+	t.Run("writes changed content", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte("---\ntags: [a]\n---\nbody"), 0o644)
+
+		written, err := svc.UpdateNote("note.md", func(content string) (string, error) {
+			return strings.ReplaceAll(content, "body", "new body"), nil
+		})
+		if err != nil {
+			t.Fatalf("UpdateNote() error = %v", err)
+		}
+		if !written {
+			t.Error("UpdateNote() written = false, want true")
+		}
+
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "note.md"))
+		if want := "---\ntags: [a]\n---\nnew body"; string(data) != want {
+			t.Errorf("content = %q, want %q", data, want)
+		}
+	})
+
+	t.Run("skips unchanged content", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte("body"), 0o644)
+
+		written, err := svc.UpdateNote("note.md", func(content string) (string, error) {
+			return content, nil
+		})
+		if err != nil || written {
+			t.Errorf("UpdateNote() = %v, %v, want false, nil", written, err)
+		}
+	})
+
+	t.Run("missing note", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		_, err := svc.UpdateNote("missing.md", func(content string) (string, error) {
+			return content, nil
+		})
+		if err == nil {
+			t.Error("UpdateNote() error = nil, want error")
+		}
+	})
+}
+
+func TestService_CheckVersion(t *testing.T) {
+	t.Run("matches the version read", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte("body\n"), 0o644)
+
+		_, version, err := svc.ReadNoteVersion("note.md")
+		if err != nil {
+			t.Fatalf("ReadNoteVersion() error = %v", err)
+		}
+		if err := svc.CheckVersion("note.md", version); err != nil {
+			t.Errorf("CheckVersion() error = %v, want nil", err)
+		}
+		if err := svc.CheckVersion("note.md", ""); err != nil {
+			t.Errorf("CheckVersion(\"\") error = %v, want nil", err)
+		}
+	})
+
+	t.Run("touched but unchanged", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		fullPath := filepath.Join(tmpDir, "note.md")
+		os.WriteFile(fullPath, []byte("body\n"), 0o644)
+		version, _ := svc.Version("note.md")
+
+		later := time.Now().Add(time.Hour)
+		os.Chtimes(fullPath, later, later)
+		if err := svc.CheckVersion("note.md", version); err != nil {
+			t.Errorf("CheckVersion() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("conflict includes diff", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		fullPath := filepath.Join(tmpDir, "note.md")
+		os.WriteFile(fullPath, []byte("# Note\nold line\n"), 0o644)
+		version, _ := svc.Version("note.md")
+		os.WriteFile(fullPath, []byte("# Note\nnew line\n"), 0o644)
+
+		err := svc.CheckVersion("note.md", version)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("CheckVersion() error = %v, want *ConflictError", err)
+		}
+		if conflict.Actual == "" || conflict.Actual == version {
+			t.Errorf("ConflictError.Actual = %q", conflict.Actual)
+		}
+		if !strings.Contains(conflict.Diff, "-old line\n+new line\n") {
+			t.Errorf("ConflictError.Diff = %q", conflict.Diff)
+		}
+
+		// The write is refused and the note left as is.
+		err = svc.WriteNote(types.NoteWriteParams{Path: "note.md", Content: "mine", IfMatch: version})
+		if !errors.As(err, &conflict) {
+			t.Errorf("WriteNote() error = %v, want *ConflictError", err)
+		}
+		data, _ := os.ReadFile(fullPath)
+		if string(data) != "# Note\nnew line\n" {
+			t.Errorf("content = %q, want it unchanged", data)
+		}
+	})
+
+	t.Run("deleted note", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte("body\n"), 0o644)
+		version, _ := svc.Version("note.md")
+		os.Remove(filepath.Join(tmpDir, "note.md"))
+
+		result := svc.MoveNote(types.MoveNoteParams{OldPath: "note.md", NewPath: "other.md", IfMatch: version})
+		if result.Success || !strings.Contains(result.Message, "was deleted since version") {
+			t.Errorf("MoveNote() = %+v, want a conflict", result)
+		}
+	})
+}
+
+func TestService_ConcurrentWrites(t *testing.T) {
+	t.Run("updates are serialized", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte("# Log\n"), 0o644)
+
+		const writers = 50
+		var wg sync.WaitGroup
+		for i := range writers {
+			wg.Go(func() {
+				_, err := svc.UpdateNote("note.md", func(content string) (string, error) {
+					return content + fmt.Sprintf("- entry %d\n", i), nil
+				})
+				if err != nil {
+					t.Errorf("UpdateNote() error = %v", err)
+				}
+			})
+			wg.Go(func() {
+				err := svc.WriteNote(types.NoteWriteParams{Path: "note.md", Content: fmt.Sprintf("- append %d\n", i), Mode: "append", Separator: "\n"})
+				if err != nil {
+					t.Errorf("WriteNote(append) error = %v", err)
+				}
+			})
+		}
+		wg.Wait()
+
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "note.md"))
+		for i := range writers {
+			for _, want := range []string{fmt.Sprintf("- entry %d\n", i), fmt.Sprintf("- append %d\n", i)} {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("content is missing %q", want)
+				}
+			}
+		}
+	})
+
+	t.Run("version checks are atomic", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte("v0\n"), 0o644)
+		version, _ := svc.Version("note.md")
+
+		// Only one of several writers holding the same version may win.
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			wins int
+		)
+		for i := range 20 {
+			wg.Go(func() {
+				_, err := svc.UpdateNoteIfMatch("note.md", version, func(string) (string, error) {
+					return fmt.Sprintf("writer %d\n", i), nil
+				})
+				if err == nil {
+					mu.Lock()
+					wins++
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+
+		if wins != 1 {
+			t.Errorf("%d writers succeeded, want 1", wins)
+		}
+	})
+}
+
+func TestService_WritePreservesMode(t *testing.T) {
+	tmpDir, svc := setupTestVault(t)
+	defer cleanupTestVault(t, tmpDir)
+
+	fullPath := filepath.Join(tmpDir, "private.md")
+	os.WriteFile(fullPath, []byte("secret\n"), 0o600)
+
+	if err := svc.WriteNote(types.NoteWriteParams{Path: "private.md", Content: "still secret\n"}); err != nil {
+		t.Fatalf("WriteNote() error = %v", err)
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(tmpDir)
+	entries = slices.DeleteFunc(entries, func(e os.DirEntry) bool { return e.Name() == journal.Dir })
+	if len(entries) != 1 {
+		t.Errorf("vault has %d entries, want only the note (temporary files left behind?)", len(entries))
+	}
+}
+
+func TestTx(t *testing.T) {
+	t.Run("commits staged changes", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("# A\n"), 0o644)
+
+		tx := svc.Begin()
+		if err := tx.WriteNote(types.NoteWriteParams{Path: "a.md", Content: "more", Mode: "append"}); err != nil {
+			t.Fatalf("WriteNote() error = %v", err)
+		}
+		if err := tx.MoveNote(types.MoveNoteParams{OldPath: "a.md", NewPath: "dir/b.md"}); err != nil {
+			t.Fatalf("MoveNote() error = %v", err)
+		}
+		if err := tx.UpdateNote("dir/b.md", "", func(content string) (string, error) {
+			return strings.ToUpper(content), nil
+		}); err != nil {
+			t.Fatalf("UpdateNote() error = %v", err)
+		}
+
+		// Nothing is written before Commit.
+		if _, err := os.Stat(filepath.Join(tmpDir, "dir")); err == nil {
+			t.Fatal("dir was created before Commit")
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "dir/b.md"))
+		if string(data) != "# A\nMORE" {
+			t.Errorf("dir/b.md = %q, want %q", data, "# A\nMORE")
+		}
+		if svc.Exists("a.md") {
+			t.Error("a.md still exists")
+		}
+	})
+
+	t.Run("versions are those of the committed content", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("a"), 0o644)
+		os.WriteFile(filepath.Join(tmpDir, "b.md"), []byte("b"), 0o644)
+		before, _ := svc.Version("b.md")
+
+		tx := svc.Begin()
+		tx.WriteNote(types.NoteWriteParams{Path: "a.md", Content: "mine"})
+		tx.UpdateNote("b.md", "", func(content string) (string, error) { return content, nil })
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+		committed, _ := svc.Version("a.md")
+		os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("theirs"), 0o644)
+
+		if got := tx.Version("a.md"); got != committed {
+			t.Errorf("Version(a.md) = %q, want %q from the commit, not the later write", got, committed)
+		}
+		if got := tx.Version("b.md"); got != before {
+			t.Errorf("Version(b.md) = %q, want the unchanged %q", got, before)
+		}
+		if err := svc.CheckVersion("a.md", tx.Version("a.md")); err == nil {
+			t.Error("CheckVersion() with the committed version after another write = nil, want a conflict")
+		}
+	})
+
+	t.Run("staged operations are validated", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("a"), 0o644)
+		os.WriteFile(filepath.Join(tmpDir, "b.md"), []byte("b"), 0o644)
+
+		tx := svc.Begin()
+		if err := tx.DeleteNote(types.DeleteNoteParams{Path: "a.md", ConfirmPath: "a.md"}); err != nil {
+			t.Fatalf("DeleteNote() error = %v", err)
+		}
+		if err := tx.MoveNote(types.MoveNoteParams{OldPath: "a.md", NewPath: "c.md"}); err == nil {
+			t.Error("MoveNote() of a deleted note error = nil, want error")
+		}
+		if err := tx.MoveNote(types.MoveNoteParams{OldPath: "b.md", NewPath: "a.md"}); err != nil {
+			t.Errorf("MoveNote() onto a deleted note error = %v", err)
+		}
+		if err := tx.WriteNote(types.NoteWriteParams{Path: "b.md", Content: "x", Mode: "append"}); err == nil {
+			t.Error("WriteNote(append) to a moved note error = nil, want error")
+		}
+	})
+
+	t.Run("conflicting change on disk", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("a"), 0o644)
+
+		tx := svc.Begin()
+		tx.WriteNote(types.NoteWriteParams{Path: "new.md", Content: "new"})
+		tx.WriteNote(types.NoteWriteParams{Path: "a.md", Content: "mine"})
+		os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("theirs"), 0o644)
+
+		if err := tx.Commit(); err == nil || !strings.Contains(err.Error(), "conflict") {
+			t.Errorf("Commit() error = %v, want a conflict", err)
+		}
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "a.md"))
+		if string(data) != "theirs" || svc.Exists("new.md") {
+			t.Errorf("Commit() wrote despite the conflict: a.md = %q", data)
+		}
+	})
+}
+
+func TestService_ApplyDryRun(t *testing.T) {
+	tmpDir, svc := setupTestVault(t)
+	defer cleanupTestVault(t, tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("one\n"), 0o644)
+
+	changes, err := svc.Apply([]string{"a.md", "b.md", "c.md"}, true, func(tx *Tx) error {
+		if err := tx.UpdateNote("a.md", "", func(content string) (string, error) {
+			return content + "two\n", nil
+		}); err != nil {
+			return err
+		}
+		// Moves are chained: the plan shows a single rename from a.md.
+		if err := tx.MoveNote(types.MoveNoteParams{OldPath: "a.md", NewPath: "b.md"}); err != nil {
+			return err
+		}
+		if err := tx.MoveNote(types.MoveNoteParams{OldPath: "b.md", NewPath: "c.md"}); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	want := []types.NoteChange{{
+		Path:   "c.md",
+		Action: "rename",
+		From:   "a.md",
+		Diff:   "--- a/a.md\n+++ b/c.md\n@@ -1 +1,2 @@\n one\n+two\n",
+	}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Apply(dryRun) = %+v, want %+v", changes, want)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, "a.md"))
+	if string(data) != "one\n" || svc.Exists("c.md") {
+		t.Error("Apply(dryRun) changed the vault")
+	}
+}
+
 func TestService_ListDirectory(t *testing.T) {
 	tmpDir, svc := setupTestVault(t)
 	defer cleanupTestVault(t, tmpDir)
@@ -521,3 +1115,137 @@ func TestService_ListDirectory(t *testing.T) {
 		t.Errorf("Files should contain note1.md and note2.md: %v", listing.Files)
 	}
 }
+
+func TestService_Undo(t *testing.T) {
+	tmpDir, svc := setupTestVault(t)
+	defer cleanupTestVault(t, tmpDir)
+
+	read := func(path string) string {
+		data, err := os.ReadFile(filepath.Join(tmpDir, path))
+		if err != nil {
+			return "<missing>"
+		}
+		return string(data)
+	}
+
+	svc.WriteNote(types.NoteWriteParams{Path: "a.md", Content: "one\n"})
+	svc.UpdateNote("a.md", func(string) (string, error) { return "two\n", nil })
+	svc.MoveNote(types.MoveNoteParams{OldPath: "a.md", NewPath: "b.md"})
+
+	entries, err := svc.History()
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("History() = %+v, %v, want 3 entries", entries, err)
+	}
+	if entries[2].Tool != "rename" || entries[2].Changes[0].Action != "rename" {
+		t.Errorf("History()[2] = %+v", entries[2])
+	}
+	if got := read(".obsidian-mcp/.gitignore"); got != "*\n!schemas.yaml\n" {
+		t.Errorf(".obsidian-mcp/.gitignore = %q, want everything but the schema file ignored", got)
+	}
+
+	t.Run("conflicts with a later change", func(t *testing.T) {
+		if _, _, err := svc.Undo([]int64{2}, 0, false); err == nil {
+			t.Fatal("Undo(2) succeeded while the note was renamed afterwards")
+		} else if conflict, ok := err.(*UndoConflictError); !ok || conflict.ID != 2 || conflict.Path != "a.md" {
+			t.Errorf("Undo(2) error = %v, want *UndoConflictError for a.md", err)
+		}
+		if read("b.md") != "two\n" {
+			t.Errorf("failed Undo() changed b.md")
+		}
+	})
+
+	t.Run("reverts the last operations", func(t *testing.T) {
+		undone, changes, err := svc.Undo(nil, 2, false)
+		if err != nil {
+			t.Fatalf("Undo() error = %v", err)
+		}
+		if !reflect.DeepEqual(undone, []int64{3, 2}) {
+			t.Errorf("Undo() = %v, want [3 2]", undone)
+		}
+		want := []types.NoteChange{{Path: "a.md", Action: "rename", From: "b.md"}}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("Undo() changes = %+v, want %+v", changes, want)
+		}
+		if read("a.md") != "one\n" || read("b.md") != "<missing>" {
+			t.Errorf("after Undo() a.md = %q, b.md = %q", read("a.md"), read("b.md"))
+		}
+	})
+
+	t.Run("skips undone operations", func(t *testing.T) {
+		undone, _, err := svc.Undo(nil, 1, false)
+		if err != nil || !reflect.DeepEqual(undone, []int64{1}) {
+			t.Fatalf("Undo() = %v, %v, want [1]", undone, err)
+		}
+		if svc.Exists("a.md") {
+			t.Error("a.md still exists after undoing its creation")
+		}
+		if _, _, err := svc.Undo([]int64{1}, 0, false); err == nil {
+			t.Error("Undo(1) twice succeeded")
+		}
+	})
+
+	t.Run("undoing an undo redoes", func(t *testing.T) {
+		if _, _, err := svc.Undo(nil, 1, false); err == nil {
+			t.Error("Undo() succeeded with only undos left")
+		}
+		if _, _, err := svc.Undo([]int64{4}, 0, false); err == nil {
+			t.Error("Undo(4) succeeded before the later undo 5 was undone")
+		}
+		for _, id := range []int64{5, 4} {
+			if _, _, err := svc.Undo([]int64{id}, 0, false); err != nil {
+				t.Fatalf("Undo(%d) error = %v", id, err)
+			}
+		}
+		if read("b.md") != "two\n" || read("a.md") != "<missing>" {
+			t.Errorf("after redo a.md = %q, b.md = %q", read("a.md"), read("b.md"))
+		}
+	})
+}
+
+func TestService_SchemaValidation(t *testing.T) {
+	tmpDir, svc := setupTestVault(t)
+	defer cleanupTestVault(t, tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "legacy.md"), []byte("# Legacy\n"), 0o644)
+	if err := svc.WriteNote(types.NoteWriteParams{Path: "projects/old.md", Content: "# Old\n"}); err != nil {
+		t.Fatalf("WriteNote() error = %v", err)
+	}
+	os.MkdirAll(filepath.Join(tmpDir, ".obsidian-mcp"), 0o755)
+	os.WriteFile(filepath.Join(tmpDir, ".obsidian-mcp", "schemas.yaml"), []byte(
+		"project:\n  folder: projects\n  fields:\n    status: {type: string, required: true, enum: [active, paused, done]}\n"), 0o644)
+
+	err := svc.WriteNote(types.NoteWriteParams{Path: "projects/alpha.md", Content: "# Alpha\n", Frontmatter: map[string]any{"status": "started"}})
+	var schemaErr *schema.Error
+	if !errors.As(err, &schemaErr) || schemaErr.Path != "projects/alpha.md" || !strings.Contains(err.Error(), "status must be one of active, paused, done") {
+		t.Fatalf("WriteNote() error = %v, want a schema error", err)
+	}
+	if svc.Exists("projects/alpha.md") {
+		t.Error("WriteNote() wrote a note that does not match its schema")
+	}
+
+	if err := svc.WriteNote(types.NoteWriteParams{Path: "projects/alpha.md", Content: "# Alpha\n", Frontmatter: map[string]any{"status": "active"}}); err != nil {
+		t.Fatalf("WriteNote() error = %v", err)
+	}
+
+	// Edits that leave the frontmatter alone are not checked...
+	os.WriteFile(filepath.Join(tmpDir, "projects", "beta.md"), []byte("# Beta\n"), 0o644)
+	if _, err := svc.UpdateNote("projects/beta.md", func(content string) (string, error) { return content + "More\n", nil }); err != nil {
+		t.Errorf("UpdateNote() of a note that predates the schema error = %v", err)
+	}
+
+	// ...but moving a note under a schema is.
+	if result := svc.MoveNote(types.MoveNoteParams{OldPath: "legacy.md", NewPath: "projects/legacy.md"}); result.Success {
+		t.Error("MoveNote() into projects succeeded without a status")
+	}
+
+	// Undos are not checked.
+	if err := svc.WriteNote(types.NoteWriteParams{Path: "projects/old.md", Content: "# Old\n", Frontmatter: map[string]any{"status": "done"}}); err != nil {
+		t.Fatalf("WriteNote() error = %v", err)
+	}
+	if _, _, err := svc.Undo(nil, 1, false); err != nil {
+		t.Errorf("Undo() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "projects", "old.md")); string(data) != "# Old\n" {
+		t.Errorf("projects/old.md = %q after Undo()", data)
+	}
+}
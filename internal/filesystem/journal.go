@@ -0,0 +1,226 @@
+package filesystem
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/taigrr/obsidian-mcp/internal/diff"
+	"github.com/taigrr/obsidian-mcp/internal/journal"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// UndoConflictError is returned when a note touched by an operation being
+// undone was changed again afterwards.
+type UndoConflictError struct {
+	ID   int64
+	Path string
+	Diff string // unified diff from the operation's result to the note now
+}
+
+func (e *UndoConflictError) Error() string {
+	msg := fmt.Sprintf("cannot undo operation %d: %s was changed by a later operation", e.ID, e.Path)
+	if e.Diff == "" {
+		return msg + "; undo that operation first"
+	}
+	return msg + "; undo that operation first. Changes since then:\n" + e.Diff
+}
+
+// CommitHook is called after changes are written, with the tool call that
+// caused them.
+type CommitHook func(tool string, changes []types.NoteChange)
+
+// OnCommit registers a hook to run after every change to the vault, while
+// the changed notes are still locked. It must be called before the Service
+// is used.
+func (s *Service) OnCommit(hook CommitHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// SetCause records the tool call behind the transaction's changes, for the
+// journal.
+func (t *Tx) SetCause(tool string, args any) {
+	t.tool, t.args = tool, args
+}
+
+// record appends the committed changes to the journal and runs the commit
+// hooks.
+func (t *Tx) record(pending []*txNote) {
+	if len(pending) == 0 {
+		return
+	}
+
+	entry := journal.Entry{Tool: t.tool, Undoes: t.undoes, Changes: t.Changes(false)}
+	if t.args != nil {
+		if data, err := json.Marshal(t.args); err == nil {
+			entry.Args = data
+		}
+	}
+	for _, n := range pending {
+		note := journal.Note{Path: n.path}
+		if n.existed {
+			before := string(n.original)
+			note.Before = &before
+		}
+		if n.exists {
+			after := n.content
+			note.After = &after
+		}
+		entry.Notes = append(entry.Notes, note)
+	}
+	t.s.record(entry)
+}
+
+// record journals an entry and runs the commit hooks. A journal failure
+// does not fail the change, as it is already on disk; it is logged.
+func (s *Service) record(entry journal.Entry) {
+	err := s.EnsureDataDir()
+	if err == nil {
+		_, err = s.journal.Append(entry)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "obsidian-mcp: journal failed: %v\n", err)
+	}
+	for _, hook := range s.hooks {
+		hook(entry.Tool, entry.Changes)
+	}
+}
+
+// History returns the journal, oldest entry first.
+func (s *Service) History() ([]journal.Entry, error) {
+	return s.journal.Entries()
+}
+
+// Undo reverts the operations with the given IDs in one transaction, or
+// the last count operations that were not undone yet if ids is empty. Undo
+// operations are journaled too, so undoing one redoes what it reverted.
+// It fails with an *UndoConflictError, without changing anything, if a
+// note was changed after an operation being reverted. It returns the IDs
+// of the reverted operations, newest first, and the changes.
+func (s *Service) Undo(ids []int64, count int, dryRun bool) ([]int64, []types.NoteChange, error) {
+	entries, err := s.journal.Entries()
+	if err != nil {
+		return nil, nil, err
+	}
+	targets, err := undoTargets(entries, ids, count)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var paths []string
+	reverted := make([]int64, 0, len(targets))
+	for _, entry := range targets {
+		reverted = append(reverted, entry.ID)
+		for _, note := range entry.Notes {
+			paths = append(paths, note.Path)
+		}
+	}
+
+	changes, err := s.Apply(paths, dryRun, func(tx *Tx) error {
+		tx.SetCause("undo", map[string]any{"ids": reverted})
+		tx.undoes = reverted
+		for _, entry := range targets {
+			if err := tx.revert(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return reverted, changes, nil
+}
+
+// undoTargets picks the entries to revert, newest first.
+func undoTargets(entries []journal.Entry, ids []int64, count int) ([]journal.Entry, error) {
+	undone := journal.UndoneBy(entries)
+
+	var targets []journal.Entry
+	if len(ids) > 0 {
+		for _, id := range ids {
+			entry, ok := journal.Find(entries, id)
+			if !ok {
+				return nil, fmt.Errorf("operation not found: %d", id)
+			}
+			if by, ok := undone[id]; ok {
+				return nil, fmt.Errorf("operation %d was already undone by operation %d", id, by)
+			}
+			targets = append(targets, entry)
+		}
+		slices.SortFunc(targets, func(a, b journal.Entry) int {
+			return cmp.Compare(b.ID, a.ID)
+		})
+		return slices.CompactFunc(targets, func(a, b journal.Entry) bool {
+			return a.ID == b.ID
+		}), nil
+	}
+
+	count = max(count, 1)
+	for i := len(entries) - 1; i >= 0 && len(targets) < count; i-- {
+		entry := entries[i]
+		if _, ok := undone[entry.ID]; ok || len(entry.Undoes) > 0 {
+			continue
+		}
+		targets = append(targets, entry)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+	return targets, nil
+}
+
+// revert stages putting every note an entry changed back to its content
+// before the entry. Notes created by the entry go to the trash, except for
+// the destinations of renames, which are moved back.
+func (t *Tx) revert(entry journal.Entry) error {
+	renamed := make(map[string]string)
+	for _, change := range entry.Changes {
+		if change.Action == "rename" {
+			renamed[change.Path] = change.From
+		}
+	}
+
+	for i := len(entry.Notes) - 1; i >= 0; i-- {
+		note := entry.Notes[i]
+		n, err := t.note(note.Path)
+		if err != nil {
+			return err
+		}
+		if n.exists != (note.After != nil) || (note.After != nil && n.content != *note.After) {
+			var after string
+			if note.After != nil {
+				after = *note.After
+			}
+			return &UndoConflictError{
+				ID:   entry.ID,
+				Path: note.Path,
+				Diff: diff.Unified(fmt.Sprintf("%s@%d", note.Path, entry.ID), note.Path, after, n.content, diff.DefaultContext),
+			}
+		}
+
+		if note.Before != nil {
+			n.content, n.exists, n.deleted = *note.Before, true, false
+			continue
+		}
+		_, moved := renamed[note.Path]
+		n.content, n.exists, n.movedFrom, n.deleted = "", false, nil, !moved
+	}
+
+	for path, from := range renamed {
+		dst, err := t.note(path)
+		if err != nil {
+			return err
+		}
+		src, err := t.note(from)
+		if err != nil {
+			return err
+		}
+		if src.exists && !dst.exists {
+			src.movedFrom = dst
+		}
+	}
+	return nil
+}
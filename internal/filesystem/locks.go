@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"slices"
+	"sync"
+)
+
+// pathLocks serializes operations on the same vault path. Locks are created
+// on demand and dropped once no operation holds or waits for them.
+type pathLocks struct {
+	mu    sync.Mutex
+	locks map[string]*pathLock
+}
+
+type pathLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newPathLocks() *pathLocks {
+	return &pathLocks{locks: make(map[string]*pathLock)}
+}
+
+// lock acquires the locks for the given full paths and returns a function
+// that releases them. Paths are locked in sorted order so that operations
+// on several paths, like a move, cannot deadlock each other.
+func (l *pathLocks) lock(paths ...string) (unlock func()) {
+	paths = slices.Clone(paths)
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	held := make([]*pathLock, 0, len(paths))
+	for _, p := range paths {
+		l.mu.Lock()
+		pl, ok := l.locks[p]
+		if !ok {
+			pl = &pathLock{}
+			l.locks[p] = pl
+		}
+		pl.refs++
+		l.mu.Unlock()
+
+		pl.mu.Lock()
+		held = append(held, pl)
+	}
+
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].mu.Unlock()
+
+			l.mu.Lock()
+			held[i].refs--
+			if held[i].refs == 0 {
+				delete(l.locks, paths[i])
+			}
+			l.mu.Unlock()
+		}
+	}
+}
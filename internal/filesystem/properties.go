@@ -0,0 +1,30 @@
+package filesystem
+
+import (
+	"github.com/taigrr/obsidian-mcp/internal/properties"
+)
+
+// PropertyTypes returns the property types Obsidian stores for the vault.
+func (s *Service) PropertyTypes() (properties.Types, error) {
+	return s.properties.Load()
+}
+
+// coerceProperties converts the frontmatter values of content that differ
+// from before to the types of their properties, so that a date written as
+// "2024-01-05" is stored as a date and "3" as a number.
+func (t *Tx) coerceProperties(before map[string]any, content string) (string, error) {
+	parsed := t.s.frontmatterHandler.Parse(content)
+	if len(parsed.Frontmatter) == 0 {
+		return content, nil
+	}
+	types, err := t.s.PropertyTypes()
+	if err != nil || len(types) == 0 {
+		return content, err
+	}
+
+	coerced, changed, err := types.Coerce(before, parsed.Frontmatter)
+	if err != nil || !changed {
+		return content, err
+	}
+	return t.s.frontmatterHandler.Update(content, coerced, parsed.Content)
+}
@@ -0,0 +1,60 @@
+package filesystem
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+
+	"github.com/taigrr/obsidian-mcp/internal/schema"
+)
+
+// Schemas returns the frontmatter schemas defined by the vault.
+func (s *Service) Schemas() (*schema.Schemas, error) {
+	return s.schemas.Load()
+}
+
+// validate checks the notes staged in the transaction against the vault's
+// schemas. A note is only checked if it is new, or if its frontmatter or
+// the schemas that apply to it change, so notes that predate a schema can
+// still be edited. Undos are not checked; restores from git or a snapshot
+// are, like any other write.
+func (t *Tx) validate() error {
+	if t.undoes != nil {
+		return nil
+	}
+
+	var schemas *schema.Schemas
+	var errs []error
+	for _, fullPath := range t.order {
+		n := t.notes[fullPath]
+		if !n.exists || (!n.changed() && n.movedFrom == nil) {
+			continue
+		}
+		if schemas == nil {
+			var err error
+			if schemas, err = t.s.Schemas(); err != nil {
+				return err
+			}
+			if schemas.Len() == 0 {
+				return nil
+			}
+		}
+
+		fm := t.s.frontmatterHandler.Parse(n.content).Frontmatter
+		before, beforePath, existed := n.original, n.path, n.existed
+		if n.movedFrom != nil {
+			before, beforePath, existed = n.movedFrom.original, n.movedFrom.path, true
+		}
+		if existed {
+			previous := t.s.frontmatterHandler.Parse(string(before)).Frontmatter
+			if reflect.DeepEqual(fm, previous) && slices.Equal(schemas.For(n.path, fm), schemas.For(beforePath, previous)) {
+				continue
+			}
+		}
+
+		if violations := schemas.Validate(n.path, fm); len(violations) > 0 {
+			errs = append(errs, &schema.Error{Path: n.path, Violations: violations})
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,148 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/taigrr/obsidian-mcp/internal/journal"
+	"github.com/taigrr/obsidian-mcp/internal/trash"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// trashBin returns the bin deleted notes go to under the vault's "Deleted
+// files" setting, or nil if they are deleted permanently. Where there is
+// no XDG system trash, the vault's .trash folder stands in for it.
+func (s *Service) trashBin() (*trash.Bin, error) {
+	switch trash.ReadOption(s.vaultPath) {
+	case trash.None:
+		return nil, nil
+	case trash.Local:
+		return trash.NewLocal(s.vaultPath), nil
+	default:
+		if !trash.HasSystem() {
+			return trash.NewLocal(s.vaultPath), nil
+		}
+		return trash.NewSystem(s.vaultPath)
+	}
+}
+
+// bins returns every bin notes of the vault may have been trashed to,
+// whatever the current setting.
+func (s *Service) bins() []*trash.Bin {
+	bins := []*trash.Bin{trash.NewLocal(s.vaultPath)}
+	if system, err := trash.NewSystem(s.vaultPath); err == nil {
+		bins = append(bins, system)
+	}
+	return bins
+}
+
+// bin returns the bin an item ID belongs to.
+func (s *Service) bin(id string) (*trash.Bin, error) {
+	for _, b := range s.bins() {
+		if _, err := b.Get(id); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("trash item not found: %s", id)
+}
+
+// TrashOption returns the vault's "Deleted files" setting.
+func (s *Service) TrashOption() trash.Option {
+	return trash.ReadOption(s.vaultPath)
+}
+
+// ListTrash returns the notes deleted from the vault that are still in the
+// local or system trash, newest first.
+func (s *Service) ListTrash() ([]trash.Item, error) {
+	items := []trash.Item{}
+	for _, b := range s.bins() {
+		binItems, err := b.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s trash: %w", b.Location(), err)
+		}
+		items = append(items, binItems...)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// RestoreNote moves a trashed note back into the vault, to its original
+// path or to path if given. It returns the path the note was restored to
+// and its version token.
+func (s *Service) RestoreNote(id, path string) (string, string, error) {
+	b, err := s.bin(id)
+	if err != nil {
+		return "", "", err
+	}
+	item, err := b.Get(id)
+	if err != nil {
+		return "", "", err
+	}
+	if path == "" {
+		path = item.Path
+	}
+
+	fullPath, err := s.ResolvePath(path)
+	if err != nil {
+		return "", "", err
+	}
+	if !s.pathFilter.IsAllowed(path) {
+		return "", "", fmt.Errorf("access denied: %s", path)
+	}
+
+	defer s.locks.lock(fullPath)()
+
+	if s.Exists(path) {
+		return "", "", fmt.Errorf("cannot restore to %s: a note already exists there; pass a different path", path)
+	}
+	if err := b.Restore(id, fullPath); err != nil {
+		return "", "", fmt.Errorf("failed to restore %s: %w", path, err)
+	}
+
+	var version string
+	if data, info, err := readWithInfo(fullPath); err == nil {
+		version = noteVersion(info, data)
+		content := string(data)
+		args, _ := json.Marshal(map[string]string{"id": id, "path": path})
+		s.record(journal.Entry{
+			Tool:    "restore",
+			Args:    args,
+			Changes: []types.NoteChange{{Path: path, Action: "create"}},
+			Notes:   []journal.Note{{Path: path, After: &content}},
+		})
+	}
+	return path, version, nil
+}
+
+// EmptyTrash permanently deletes the given trash items, or every item from
+// the vault if ids is empty. It returns the number of items deleted.
+func (s *Service) EmptyTrash(ids []string) (int, error) {
+	if len(ids) == 0 {
+		items, err := s.ListTrash()
+		if err != nil {
+			return 0, err
+		}
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	removed := 0
+	var errs []error
+	for _, id := range ids {
+		b, err := s.bin(id)
+		if err == nil {
+			err = b.Remove(id)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,483 @@
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/taigrr/obsidian-mcp/internal/diff"
+	"github.com/taigrr/obsidian-mcp/internal/trash"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// Tx stages changes to several notes in memory and applies them to the
+// vault together. Reads through a Tx see the changes staged so far, so
+// every operation is validated against the state the earlier ones leave
+// behind before anything is written.
+type Tx struct {
+	s     *Service
+	notes map[string]*txNote
+	order []string
+	// trashed holds the trash items of the notes deleted by the commit.
+	trashed map[*txNote]trash.Item
+	// tool and args describe the tool call that caused the changes, and
+	// undoes the operations they revert, for the journal.
+	tool   string
+	args   any
+	undoes []int64
+}
+
+// txNote is the staged state of a single path.
+type txNote struct {
+	path     string
+	fullPath string
+	original []byte
+	existed  bool
+	perm     fs.FileMode
+	content  string
+	exists   bool
+	// version is the version token of the note as staged from disk, and
+	// after a commit as written; empty while it does not exist.
+	version string
+	// movedFrom is the note whose content was moved here, if any.
+	movedFrom *txNote
+	// deleted is set when the note is deleted rather than moved or
+	// overwritten; deleted notes go to the trash.
+	deleted bool
+}
+
+// Begin starts a transaction.
+func (s *Service) Begin() *Tx {
+	return &Tx{s: s, notes: make(map[string]*txNote)}
+}
+
+// Apply runs fn in a new transaction while holding the locks for paths,
+// and commits the changes it stages unless dryRun is set. fn must only
+// touch the given paths. It returns the changes, with content diffs when
+// dryRun is set.
+//
+// Every mutating Service method goes through Apply, so a dry run computes
+// exactly what the real operation would write.
+func (s *Service) Apply(paths []string, dryRun bool, fn func(tx *Tx) error) ([]types.NoteChange, error) {
+	fullPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		fullPath, err := s.ResolvePath(path)
+		if err != nil {
+			return nil, err
+		}
+		fullPaths = append(fullPaths, fullPath)
+	}
+	defer s.locks.lock(fullPaths...)()
+
+	tx := s.Begin()
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		if err := tx.validate(); err != nil {
+			return nil, err
+		}
+		return tx.Changes(true), nil
+	}
+	err := tx.commit()
+	return tx.Changes(false), err
+}
+
+// note returns the staged state of a path, loading it from disk on first
+// use.
+func (t *Tx) note(path string) (*txNote, error) {
+	fullPath, err := t.s.ResolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if !t.s.pathFilter.IsAllowed(path) {
+		return nil, fmt.Errorf("access denied: %s", path)
+	}
+	if n, ok := t.notes[fullPath]; ok {
+		return n, nil
+	}
+	if isDir, _ := t.s.IsDirectory(path); isDir {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	n := &txNote{path: path, fullPath: fullPath}
+	data, info, err := readWithInfo(fullPath)
+	switch {
+	case err == nil:
+		n.original, n.existed, n.perm = data, true, info.Mode().Perm()
+		n.content, n.exists = string(data), true
+		n.version = noteVersion(info, data)
+	case errors.Is(err, fs.ErrPermission):
+		return nil, fmt.Errorf("permission denied: %s", path)
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("failed to read file: %s - %w", path, err)
+	}
+
+	t.notes[fullPath] = n
+	t.order = append(t.order, fullPath)
+	return n, nil
+}
+
+// readWithInfo returns the content and file info of a file.
+func readWithInfo(fullPath string) ([]byte, fs.FileInfo, error) {
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(f)
+	return data, info, err
+}
+
+// WriteNote stages a write with the same semantics as Service.WriteNote.
+func (t *Tx) WriteNote(params types.NoteWriteParams) error {
+	n, err := t.note(params.Path)
+	if err != nil {
+		return err
+	}
+	// Version checks happen after a note is staged, so Commit catches any
+	// change made after the check.
+	if err := t.s.CheckVersion(params.Path, params.IfMatch); err != nil {
+		return err
+	}
+
+	var existing types.ParsedNote
+	if n.exists {
+		existing = t.s.frontmatterHandler.Parse(n.content)
+	}
+	content, err := t.s.composeNote(params, existing, n.exists)
+	if err != nil {
+		return err
+	}
+	content, err = t.coerceProperties(existing.Frontmatter, content)
+	if err != nil {
+		return err
+	}
+	n.content, n.exists, n.deleted = content, true, false
+	return nil
+}
+
+// UpdateNote stages a read-modify-write of an existing note, like
+// Service.UpdateNoteIfMatch.
+func (t *Tx) UpdateNote(path, version string, fn func(content string) (string, error)) error {
+	n, err := t.note(path)
+	if err != nil {
+		return err
+	}
+	if err := t.s.CheckVersion(path, version); err != nil {
+		return err
+	}
+	if !n.exists {
+		return fmt.Errorf("file not found: %s", path)
+	}
+
+	updated, err := fn(n.content)
+	if err != nil {
+		return err
+	}
+	updated, err = t.coerceProperties(t.s.frontmatterHandler.Parse(n.content).Frontmatter, updated)
+	if err != nil {
+		return err
+	}
+	n.content = updated
+	return nil
+}
+
+// MoveNote stages moving a note to a new path.
+func (t *Tx) MoveNote(params types.MoveNoteParams) error {
+	src, err := t.note(params.OldPath)
+	if err != nil {
+		return err
+	}
+	dst, err := t.note(params.NewPath)
+	if err != nil {
+		return err
+	}
+	if err := t.s.CheckVersion(params.OldPath, params.IfMatch); err != nil {
+		return err
+	}
+	if !src.exists {
+		return fmt.Errorf("source file not found: %s", params.OldPath)
+	}
+	if src == dst {
+		return nil
+	}
+	if dst.exists && !params.Overwrite {
+		return fmt.Errorf("target file already exists: %s. Use overwrite=true to replace it", params.NewPath)
+	}
+
+	dst.content, dst.exists, dst.perm, dst.deleted = src.content, true, src.perm, false
+	dst.movedFrom = src
+	if src.movedFrom != nil {
+		dst.movedFrom = src.movedFrom
+	}
+	src.content, src.exists, src.movedFrom = "", false, nil
+	return nil
+}
+
+// DeleteNote stages deleting a note.
+func (t *Tx) DeleteNote(params types.DeleteNoteParams) error {
+	if params.Path != params.ConfirmPath {
+		return fmt.Errorf("deletion cancelled: confirmation path does not match")
+	}
+	n, err := t.note(params.Path)
+	if err != nil {
+		return err
+	}
+	if err := t.s.CheckVersion(params.Path, params.IfMatch); err != nil {
+		return err
+	}
+	if !n.exists {
+		return fmt.Errorf("file not found: %s", params.Path)
+	}
+
+	n.content, n.exists, n.movedFrom, n.deleted = "", false, nil, true
+	return nil
+}
+
+// changed reports whether the staged state differs from the disk.
+func (n *txNote) changed() bool {
+	return n.exists != n.existed || n.content != string(n.original)
+}
+
+// Changes returns the changes staged so far, in the order the notes were
+// first touched. A note moved elsewhere is reported once, as a rename at
+// its new path. With withDiff set, creations and modifications carry a
+// unified diff with a/ and b/ prefixes that apply_patch accepts.
+func (t *Tx) Changes(withDiff bool) []types.NoteChange {
+	moved := make(map[*txNote]bool)
+	for _, n := range t.notes {
+		if n.exists && n.movedFrom != nil {
+			moved[n.movedFrom] = true
+		}
+	}
+
+	changes := []types.NoteChange{}
+	for _, fullPath := range t.order {
+		n := t.notes[fullPath]
+		if !n.changed() && n.movedFrom == nil {
+			continue
+		}
+
+		change := types.NoteChange{Path: n.path}
+		from, before := "a/"+n.path, string(n.original)
+		switch {
+		case !n.exists && moved[n]:
+			continue
+		case !n.exists:
+			change.Action = "delete"
+		case n.movedFrom != nil:
+			change.Action = "rename"
+			change.From = n.movedFrom.path
+			from, before = "a/"+n.movedFrom.path, string(n.movedFrom.original)
+		case !n.existed:
+			change.Action = "create"
+			from = "/dev/null"
+		default:
+			change.Action = "modify"
+		}
+		if item, ok := t.trashed[n]; ok {
+			change.Trash = item.ID
+		}
+		if withDiff && n.exists {
+			change.Diff = diff.Unified(from, "b/"+n.path, before, n.content, diff.DefaultContext)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Commit applies the staged changes. It fails without writing anything if
+// a note does not match the vault's schemas or one of the notes changed on
+// disk since it was staged. If a write fails, the notes already written
+// are restored. Deleted notes are moved to the trash the vault is
+// configured to use. Committed changes are journaled.
+func (t *Tx) Commit() error {
+	defer t.s.locks.lock(t.order...)()
+	return t.commit()
+}
+
+// commit is Commit for callers that already hold the locks.
+func (t *Tx) commit() error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+
+	var pending []*txNote
+	for _, fullPath := range t.order {
+		n := t.notes[fullPath]
+		data, err := os.ReadFile(n.fullPath)
+		exists := err == nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to read file: %s - %w", n.path, err)
+		}
+		if exists != n.existed || !bytes.Equal(data, n.original) {
+			return fmt.Errorf("conflict: %s changed while the transaction was prepared", n.path)
+		}
+		if n.changed() {
+			pending = append(pending, n)
+		}
+	}
+
+	bin, err := t.trashBin(pending)
+	if err != nil {
+		return err
+	}
+
+	// A note moved without changes is renamed rather than rewritten, and
+	// the removal of its source is left to the rename.
+	renamedAway := make(map[*txNote]bool)
+	for _, n := range pending {
+		if n.renamed() {
+			renamedAway[n.movedFrom] = true
+		}
+	}
+
+	trashed := make(map[*txNote]trash.Item)
+	for i, n := range pending {
+		var err error
+		switch {
+		case renamedAway[n]:
+			continue
+		case n.deleted && n.existed && bin != nil:
+			var item trash.Item
+			if item, err = bin.Put(n.fullPath); err == nil {
+				trashed[n] = item
+			}
+		case n.renamed():
+			err = n.rename(n.movedFrom.fullPath, n.fullPath)
+		default:
+			err = n.apply(n.exists, []byte(n.content))
+		}
+		if err != nil {
+			if i == 0 {
+				return fmt.Errorf("failed to write file: %s - %w", n.path, err)
+			}
+			for _, done := range pending[:i] {
+				item, ok := trashed[done]
+				switch {
+				case ok:
+					bin.Restore(item.ID, done.fullPath)
+				case renamedAway[done]:
+				case done.renamed():
+					done.rename(done.fullPath, done.movedFrom.fullPath)
+					if done.existed {
+						done.apply(true, done.original)
+					}
+				default:
+					done.apply(done.existed, done.original)
+				}
+			}
+			return fmt.Errorf("failed to write file: %s - %w; earlier changes were rolled back", n.path, err)
+		}
+	}
+	t.trashed = trashed
+	for _, n := range pending {
+		if n.updateVersion(); n.version != "" {
+			t.s.versions.remember(n.version, []byte(n.content))
+		}
+	}
+	t.record(pending)
+	return nil
+}
+
+// updateVersion sets the version of a note just written, from the content
+// written and the file's new modification time. It runs while the note is
+// still locked, so the version is that of this write even if another
+// writer changes the note right after.
+func (n *txNote) updateVersion() {
+	n.version = ""
+	if !n.exists {
+		return
+	}
+	if info, err := os.Stat(n.fullPath); err == nil {
+		n.version = noteVersion(info, []byte(n.content))
+	}
+}
+
+// Version returns the version token of a note touched by the transaction:
+// as written once it is committed, or as read from disk if the commit did
+// not change it. It is empty for a note that does not exist or was not
+// touched.
+func (t *Tx) Version(path string) string {
+	fullPath, err := t.s.ResolvePath(path)
+	if err != nil {
+		return ""
+	}
+	if n, ok := t.notes[fullPath]; ok && n.exists {
+		return n.version
+	}
+	return ""
+}
+
+// Content returns the staged content of a note touched by the transaction,
+// with its properties coerced to their types. It is empty for a note that
+// does not exist or was not touched.
+func (t *Tx) Content(path string) string {
+	fullPath, err := t.s.ResolvePath(path)
+	if err != nil {
+		return ""
+	}
+	if n, ok := t.notes[fullPath]; ok && n.exists {
+		return n.content
+	}
+	return ""
+}
+
+// trashBin returns the bin deleted notes go to, or nil if none of the
+// pending changes deletes a note or the vault deletes permanently.
+func (t *Tx) trashBin(pending []*txNote) (*trash.Bin, error) {
+	for _, n := range pending {
+		if n.deleted && n.existed {
+			return t.s.trashBin()
+		}
+	}
+	return nil, nil
+}
+
+// renamed reports whether the note is committed by renaming the file it
+// was moved from: that file is on disk with the note's content, and no
+// other note is staged in its place.
+func (n *txNote) renamed() bool {
+	src := n.movedFrom
+	return n.exists && src != nil && src.existed && !src.exists && n.content == string(src.original)
+}
+
+// rename renames the file at from to to, creating the parent directory
+// of to if needed.
+func (n *txNote) rename(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(from))
+	syncDir(filepath.Dir(to))
+	return nil
+}
+
+// apply writes content to the note's path, or removes it if it should not
+// exist.
+func (n *txNote) apply(exists bool, content []byte) error {
+	if !exists {
+		if err := os.Remove(n.fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		syncDir(filepath.Dir(n.fullPath))
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(n.fullPath), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(n.fullPath, content, n.perm)
+}
@@ -0,0 +1,143 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"github.com/taigrr/obsidian-mcp/internal/diff"
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// versionCacheSize is the number of note versions whose content is kept so
+// that a conflict can show what changed since then.
+const versionCacheSize = 256
+
+// ConflictError is returned when a note no longer matches the version a
+// caller based its change on.
+type ConflictError struct {
+	Path     string
+	Expected string
+	Actual   string // empty if the note no longer exists
+	Diff     string // unified diff from Expected to Actual, if known
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+	if e.Actual == "" {
+		fmt.Fprintf(&b, "conflict: %s was deleted since version %s", e.Path, e.Expected)
+	} else {
+		fmt.Fprintf(&b, "conflict: %s was modified since version %s (current version %s)", e.Path, e.Expected, e.Actual)
+	}
+	if e.Diff != "" {
+		b.WriteString("; changes since then:\n")
+		b.WriteString(e.Diff)
+	} else if e.Actual != "" {
+		b.WriteString("; read the note again before changing it")
+	}
+	return b.String()
+}
+
+// noteVersion returns the version token of a note: its modification time
+// and a hash of its content.
+func noteVersion(info fs.FileInfo, content []byte) string {
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf("%x-%s", info.ModTime().UnixNano(), hex.EncodeToString(sum[:8]))
+}
+
+// sameContent reports whether two version tokens have the same content
+// hash, i.e. the note was at most touched in between.
+func sameContent(a, b string) bool {
+	_, hashA, okA := strings.Cut(a, "-")
+	_, hashB, okB := strings.Cut(b, "-")
+	return okA && okB && hashA == hashB
+}
+
+// versionCache remembers the content of recently seen note versions.
+type versionCache struct {
+	mu       sync.Mutex
+	contents map[string]string
+	order    []string
+}
+
+func newVersionCache() *versionCache {
+	return &versionCache{contents: make(map[string]string)}
+}
+
+// remember records the content of a version, evicting the oldest entry
+// when the cache is full.
+func (c *versionCache) remember(version string, content []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.contents[version]; ok {
+		return
+	}
+	if len(c.order) >= versionCacheSize {
+		delete(c.contents, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.contents[version] = string(content)
+	c.order = append(c.order, version)
+}
+
+func (c *versionCache) lookup(version string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	content, ok := c.contents[version]
+	return content, ok
+}
+
+// ReadNoteVersion reads a note along with its version token.
+func (s *Service) ReadNoteVersion(path string) (types.ParsedNote, string, error) {
+	content, info, err := s.readFile(path)
+	if err != nil {
+		return types.ParsedNote{}, "", err
+	}
+	version := noteVersion(info, content)
+	s.versions.remember(version, content)
+	return s.frontmatterHandler.Parse(string(content)), version, nil
+}
+
+// Version returns the current version token of a note.
+func (s *Service) Version(path string) (string, error) {
+	_, version, err := s.ReadNoteVersion(path)
+	return version, err
+}
+
+// CheckVersion returns a *ConflictError if the note is not at the given
+// version. An empty version always matches, as does a version whose
+// content is unchanged even if the note was touched since.
+func (s *Service) CheckVersion(path, version string) error {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return nil
+	}
+
+	content, info, err := s.readFile(path)
+	if err != nil {
+		if !s.Exists(path) {
+			conflict := &ConflictError{Path: path, Expected: version}
+			if old, ok := s.versions.lookup(version); ok {
+				conflict.Diff = diff.Unified(path, "/dev/null", old, "", diff.DefaultContext)
+			}
+			return conflict
+		}
+		return err
+	}
+
+	current := noteVersion(info, content)
+	if current == version || sameContent(current, version) {
+		return nil
+	}
+	s.versions.remember(current, content)
+
+	conflict := &ConflictError{Path: path, Expected: version, Actual: current}
+	if old, ok := s.versions.lookup(version); ok {
+		conflict.Diff = diff.Unified(path+"@"+version, path+"@"+current, old, string(content), diff.DefaultContext)
+	}
+	return conflict
+}
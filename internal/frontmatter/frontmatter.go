@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/taigrr/obsidian-mcp/internal/markdown"
 	"github.com/taigrr/obsidian-mcp/internal/types"
 	"gopkg.in/yaml.v3"
 )
@@ -19,7 +20,8 @@ func New() *Handler {
 	return &Handler{}
 }
 
-// Parse parses a note's content and extracts frontmatter.
+// Parse parses a note's content and extracts frontmatter and Dataview
+// inline fields.
 func (h *Handler) Parse(content string) types.ParsedNote {
 	result := types.ParsedNote{
 		Frontmatter:     make(map[string]any),
@@ -27,33 +29,17 @@ func (h *Handler) Parse(content string) types.ParsedNote {
 		OriginalContent: content,
 	}
 
-	// Check if content starts with frontmatter delimiter
-	if !strings.HasPrefix(content, "---\n") {
+	yamlContent, contentStart, ok := split(content)
+	if !ok {
+		result.InlineFields = markdown.InlineFieldMap(content)
 		return result
 	}
 
-	// Find the closing delimiter.
-	bodyStart := len("---\n")
-	closingIndex := strings.Index(content[bodyStart:], "\n---\n")
-	var yamlContent string
-	var contentStart int
-	if closingIndex == -1 {
-		// Try finding --- at the very end.
-		if !strings.HasSuffix(content, "\n---") {
-			return result
-		}
-		yamlEnd := len(content) - len("\n---")
-		yamlContent = content[bodyStart:yamlEnd]
-		contentStart = len(content)
-	} else {
-		yamlContent = content[bodyStart : bodyStart+closingIndex]
-		contentStart = bodyStart + closingIndex + len("\n---\n")
-	}
-
 	// Parse YAML
 	var frontmatter map[string]any
 	if err := yaml.Unmarshal([]byte(yamlContent), &frontmatter); err != nil {
 		// If parsing fails, treat as content without frontmatter
+		result.InlineFields = markdown.InlineFieldMap(content)
 		return result
 	}
 
@@ -64,10 +50,34 @@ func (h *Handler) Parse(content string) types.ParsedNote {
 
 	// Content starts after the closing delimiter.
 	result.Content = content[contentStart:]
+	result.InlineFields = markdown.InlineFieldMap(result.Content)
 
 	return result
 }
 
+// split returns the YAML between the frontmatter delimiters of content and
+// the offset at which the body starts. ok is false if content has no
+// frontmatter block.
+func split(content string) (yamlContent string, contentStart int, ok bool) {
+	// Check if content starts with frontmatter delimiter
+	if !strings.HasPrefix(content, "---\n") {
+		return "", 0, false
+	}
+
+	// Find the closing delimiter.
+	bodyStart := len("---\n")
+	closingIndex := strings.Index(content[bodyStart:], "\n---\n")
+	if closingIndex == -1 {
+		// Try finding --- at the very end.
+		if !strings.HasSuffix(content, "\n---") {
+			return "", 0, false
+		}
+		yamlEnd := len(content) - len("\n---")
+		return content[bodyStart:yamlEnd], len(content), true
+	}
+	return content[bodyStart : bodyStart+closingIndex], bodyStart + closingIndex + len("\n---\n"), true
+}
+
 // Stringify converts frontmatter and content back to a note string.
 func (h *Handler) Stringify(frontmatter map[string]any, content string) (string, error) {
 	// If no frontmatter, return content as-is
@@ -168,5 +178,22 @@ func (h *Handler) UpdateFrontmatter(content string, updates map[string]any) (str
 		return "", fmt.Errorf("invalid frontmatter: %s", strings.Join(validation.Errors, ", "))
 	}
 
-	return h.Stringify(updatedFrontmatter, parsed.Content)
+	return h.Update(content, updatedFrontmatter, parsed.Content)
+}
+
+// ApplyOperations applies frontmatter operations to existing content.
+func (h *Handler) ApplyOperations(content string, ops []Operation) (string, error) {
+	parsed := h.Parse(content)
+
+	updatedFrontmatter, err := Apply(parsed.Frontmatter, ops)
+	if err != nil {
+		return "", err
+	}
+
+	validation := h.Validate(updatedFrontmatter)
+	if !validation.IsValid {
+		return "", fmt.Errorf("invalid frontmatter: %s", strings.Join(validation.Errors, ", "))
+	}
+
+	return h.Update(content, updatedFrontmatter, parsed.Content)
 }
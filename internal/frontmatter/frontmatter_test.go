@@ -1,10 +1,16 @@
 package frontmatter
 
 import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
 
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
 func TestHandler_ParseWithFrontmatter(t *testing.T) {
 	handler := New()
 
@@ -95,6 +101,27 @@ title: [invalid
 	}
 }
 
+func TestHandler_ParseInlineFields(t *testing.T) {
+	handler := New()
+
+	content := `---
+status: draft
+---
+
+status:: active
+- [ ] Review [due:: 2024-05-01]`
+
+	result := handler.Parse(content)
+
+	if result.Frontmatter["status"] != "draft" {
+		t.Errorf("Frontmatter[status] = %v, want %q", result.Frontmatter["status"], "draft")
+	}
+	want := map[string]any{"status": "active", "due": "2024-05-01"}
+	if !reflect.DeepEqual(result.InlineFields, want) {
+		t.Errorf("InlineFields = %v, want %v", result.InlineFields, want)
+	}
+}
+
 func TestHandler_StringifyWithFrontmatter(t *testing.T) {
 	handler := New()
 
@@ -306,3 +333,176 @@ Content here.`
 		t.Errorf("Frontmatter[value] = %v, want %v", fm["value"], 42)
 	}
 }
+
+func TestApply(t *testing.T) {
+	base := map[string]any{
+		"title":   "Note",
+		"tags":    []any{"a", "b"},
+		"aliases": "Alias",
+		"status":  nil,
+		"meta":    map[string]any{"author": "me", "rating": 3},
+	}
+
+	tests := []struct {
+		name string
+		ops  []Operation
+		want map[string]any
+	}{
+		{
+			name: "set and unset",
+			ops:  []Operation{{Op: "set", Key: "title", Value: "New"}, {Op: "unset", Key: "status"}},
+			want: map[string]any{"title": "New", "tags": []any{"a", "b"}, "aliases": "Alias", "meta": base["meta"]},
+		},
+		{
+			name: "rename",
+			ops:  []Operation{{Op: "rename", Key: "aliases", NewKey: "alias"}},
+			want: map[string]any{"title": "Note", "tags": []any{"a", "b"}, "alias": "Alias", "status": nil, "meta": base["meta"]},
+		},
+		{
+			name: "append and remove",
+			ops: []Operation{
+				{Op: "append", Key: "tags", Value: []any{"b", "c"}},
+				{Op: "append", Key: "aliases", Value: "Other"},
+				{Op: "remove", Key: "tags", Value: "a"},
+				{Op: "remove", Key: "title", Value: "Note"},
+			},
+			want: map[string]any{"tags": []any{"b", "c"}, "aliases": []any{"Alias", "Other"}, "status": nil, "meta": base["meta"]},
+		},
+		{
+			name: "deep merge",
+			ops:  []Operation{{Op: "merge", Value: map[string]any{"meta": map[string]any{"rating": 4.0}, "tags": []any{"x"}}}},
+			want: map[string]any{"title": "Note", "tags": []any{"x"}, "aliases": "Alias", "status": nil, "meta": map[string]any{"author": "me", "rating": 4.0}},
+		},
+		{
+			name: "merge patch",
+			ops:  []Operation{{Op: "merge_patch", Value: map[string]any{"title": nil, "meta": map[string]any{"author": nil, "source": "web"}}}},
+			want: map[string]any{"tags": []any{"a", "b"}, "aliases": "Alias", "status": nil, "meta": map[string]any{"rating": 3, "source": "web"}},
+		},
+		{
+			name: "json patch",
+			ops: []Operation{{Op: "json_patch", Value: []any{
+				map[string]any{"op": "test", "path": "/meta/rating", "value": 3.0},
+				map[string]any{"op": "add", "path": "/tags/-", "value": "c"},
+				map[string]any{"op": "add", "path": "/tags/0", "value": "z"},
+				map[string]any{"op": "remove", "path": "/status"},
+				map[string]any{"op": "replace", "path": "/title", "value": "Patched"},
+				map[string]any{"op": "move", "from": "/meta/author", "path": "/author"},
+				map[string]any{"op": "copy", "from": "/aliases", "path": "/a~1b"},
+			}}},
+			want: map[string]any{"title": "Patched", "tags": []any{"z", "a", "b", "c"}, "aliases": "Alias", "a/b": "Alias", "author": "me", "meta": map[string]any{"rating": 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(base, tt.ops)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if tags := base["tags"].([]any); len(tags) != 2 || base["title"] != "Note" {
+		t.Errorf("Apply() modified its input: %v", base)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	base := map[string]any{"title": "Note", "alias": "A", "tags": []any{"a"}}
+
+	tests := []struct {
+		name string
+		op   Operation
+		want string
+	}{
+		{"unknown op", Operation{Op: "frobnicate"}, "unknown operation"},
+		{"missing key", Operation{Op: "set"}, "key cannot be empty"},
+		{"rename missing key", Operation{Op: "rename", Key: "missing", NewKey: "x"}, "key not found"},
+		{"rename onto existing", Operation{Op: "rename", Key: "alias", NewKey: "title"}, "key already exists"},
+		{"merge non-object", Operation{Op: "merge", Value: "x"}, "must be an object"},
+		{"patch not a list", Operation{Op: "json_patch", Value: "x"}, "list of JSON Patch operations"},
+		{"failed test", Operation{Op: "json_patch", Value: []any{map[string]any{"op": "test", "path": "/title", "value": "Other"}}}, "test failed"},
+		{"missing path", Operation{Op: "json_patch", Value: []any{map[string]any{"op": "replace", "path": "/status", "value": "x"}}}, "path not found"},
+		{"index out of range", Operation{Op: "json_patch", Value: []any{map[string]any{"op": "remove", "path": "/tags/1"}}}, "out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply(base, []Operation{tt.op})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Apply() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_ApplyOperations(t *testing.T) {
+	handler := New()
+
+	content := "---\ntitle: Note\ntags: [a, b]\nstatus: null\n---\n# Content\n"
+	result, err := handler.ApplyOperations(content, []Operation{
+		{Op: "unset", Key: "status"},
+		{Op: "remove", Key: "tags", Value: []any{"a", "b"}},
+	})
+	if err != nil {
+		t.Fatalf("ApplyOperations() error = %v", err)
+	}
+	if want := "---\ntitle: Note\n---\n# Content\n"; result != want {
+		t.Errorf("ApplyOperations() = %q, want %q", result, want)
+	}
+}
+
+func TestHandler_UpdateGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		ops   []Operation
+	}{
+		{name: "obsidian-unchanged", input: "obsidian.md", ops: []Operation{{Op: "set", Key: "title", Value: "Weekly review"}}},
+		{name: "obsidian-set", input: "obsidian.md", ops: []Operation{{Op: "set", Key: "status", Value: "done"}, {Op: "set", Key: "title", Value: "Review: week 10"}}},
+		{name: "obsidian-unset", input: "obsidian.md", ops: []Operation{{Op: "unset", Key: "created"}, {Op: "unset", Key: "cssclasses"}}},
+		{name: "obsidian-lists", input: "obsidian.md", ops: []Operation{{Op: "append", Key: "aliases", Value: "retro"}, {Op: "append", Key: "tags", Value: "weekly"}}},
+		{name: "obsidian-add", input: "obsidian.md", ops: []Operation{{Op: "set", Key: "reviewed", Value: true}, {Op: "rename", Key: "status", NewKey: "state"}}},
+		{name: "nested-merge", input: "nested.md", ops: []Operation{{Op: "merge", Value: map[string]any{"meta": map[string]any{"rating": 4, "team": "core"}}}}},
+		{name: "nested-block", input: "nested.md", ops: []Operation{{Op: "set", Key: "summary", Value: "Only line.\nAnd another.\n"}, {Op: "unset", Key: "project"}}},
+		{name: "flow", input: "flow.md", ops: []Operation{{Op: "append", Key: "tags", Value: "c"}}},
+	}
+
+	handler := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", tt.input))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			got, err := handler.ApplyOperations(string(input), tt.ops)
+			if err != nil {
+				t.Fatalf("ApplyOperations() error = %v", err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("ApplyOperations() = %q, want %q", got, want)
+			}
+
+			// The result must parse back to the same frontmatter as
+			// applying the operations to the parsed map.
+			fm, _ := Apply(handler.Parse(string(input)).Frontmatter, tt.ops)
+			if parsed := handler.Parse(got); !equal(parsed.Frontmatter, fm) {
+				t.Errorf("Parse(ApplyOperations()) = %v, want %v", parsed.Frontmatter, fm)
+			}
+		})
+	}
+}
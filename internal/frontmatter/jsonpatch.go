@@ -0,0 +1,239 @@
+package frontmatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PatchOp is a JSON Patch (RFC 6902) operation.
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// parsePatch converts a decoded JSON value into patch operations.
+func parsePatch(v any) ([]PatchOp, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+	}
+	var patch []PatchOp
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("value must be a list of JSON Patch operations")
+	}
+	return patch, nil
+}
+
+// ApplyPatch applies a JSON Patch (RFC 6902) to doc. Operations apply in
+// order and the patch fails as a whole if any of them fails, including a
+// failed test. doc is modified.
+func ApplyPatch(doc map[string]any, patch []PatchOp) (map[string]any, error) {
+	var root any = doc
+	for i, op := range patch {
+		var err error
+		if root, err = applyPatchOp(root, op); err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %s): %w", i+1, op.Op, op.Path, err)
+		}
+	}
+	result, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("frontmatter must be an object")
+	}
+	return result, nil
+}
+
+func applyPatchOp(root any, op PatchOp) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return update(root, path, func(container any, token string) (any, error) {
+			return add(container, token, deepCopy(op.Value))
+		}, deepCopy(op.Value))
+	case "remove":
+		if len(path) == 0 {
+			return nil, fmt.Errorf("cannot remove the whole document")
+		}
+		return update(root, path, remove, nil)
+	case "replace":
+		if _, err := get(root, path); err != nil {
+			return nil, err
+		}
+		return update(root, path, func(container any, token string) (any, error) {
+			return replace(container, token, deepCopy(op.Value))
+		}, deepCopy(op.Value))
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("cannot move a value into itself")
+			}
+			if root, err = update(root, from, remove, nil); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return update(root, path, func(container any, token string) (any, error) {
+			return add(container, token, value)
+		}, value)
+	case "test":
+		value, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.Value) {
+			return nil, fmt.Errorf("test failed: value is %v", value)
+		}
+		return root, nil
+	default:
+		return nil, fmt.Errorf("unknown operation (use add, remove, replace, move, copy or test)")
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q: must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// get returns the value at path.
+func get(v any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := v.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %s", token)
+			}
+			v = value
+		case []any:
+			i, err := index(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			v = container[i]
+		default:
+			return nil, fmt.Errorf("path not found: %s", token)
+		}
+	}
+	return v, nil
+}
+
+// update applies fn to the container holding the last token of path and
+// returns root with the updated container in place. An empty path
+// replaces root with whole.
+func update(root any, path []string, fn func(container any, token string) (any, error), whole any) (any, error) {
+	if len(path) == 0 {
+		return whole, nil
+	}
+	if len(path) == 1 {
+		return fn(root, path[0])
+	}
+
+	child, err := get(root, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = update(child, path[1:], fn, whole)
+	if err != nil {
+		return nil, err
+	}
+	return replace(root, path[0], child)
+}
+
+func add(container any, token string, value any) (any, error) {
+	switch c := container.(type) {
+	case map[string]any:
+		c[token] = value
+		return c, nil
+	case []any:
+		if token == "-" {
+			return append(c, value), nil
+		}
+		i, err := index(token, len(c)+1)
+		if err != nil {
+			return nil, err
+		}
+		c = append(c, nil)
+		copy(c[i+1:], c[i:])
+		c[i] = value
+		return c, nil
+	default:
+		return nil, fmt.Errorf("cannot add %s to a value that is not an object or array", token)
+	}
+}
+
+func remove(container any, token string) (any, error) {
+	switch c := container.(type) {
+	case map[string]any:
+		if _, ok := c[token]; !ok {
+			return nil, fmt.Errorf("path not found: %s", token)
+		}
+		delete(c, token)
+		return c, nil
+	case []any:
+		i, err := index(token, len(c))
+		if err != nil {
+			return nil, err
+		}
+		return append(c[:i], c[i+1:]...), nil
+	default:
+		return nil, fmt.Errorf("path not found: %s", token)
+	}
+}
+
+func replace(container any, token string, value any) (any, error) {
+	switch c := container.(type) {
+	case map[string]any:
+		if _, ok := c[token]; !ok {
+			return nil, fmt.Errorf("path not found: %s", token)
+		}
+		c[token] = value
+		return c, nil
+	case []any:
+		i, err := index(token, len(c))
+		if err != nil {
+			return nil, err
+		}
+		c[i] = value
+		return c, nil
+	default:
+		return nil, fmt.Errorf("path not found: %s", token)
+	}
+}
+
+// index parses an array index token that must be below n.
+func index(token string, n int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index: %s", token)
+	}
+	if i >= n {
+		return 0, fmt.Errorf("array index out of range: %s", token)
+	}
+	return i, nil
+}
@@ -0,0 +1,219 @@
+package frontmatter
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/taigrr/obsidian-mcp/internal/values"
+)
+
+// Operation is a change to the frontmatter of a note.
+//
+//   - set: set Key to Value
+//   - unset: remove Key
+//   - rename: move the value of Key to NewKey
+//   - append: add Value, or each item of a list Value, to the list at Key
+//     unless already present; a scalar at Key becomes a list
+//   - remove: remove Value, or each item of a list Value, from the list at
+//     Key; the key is removed when the list ends up empty
+//   - merge: deep merge the map Value, merging nested maps and replacing
+//     everything else
+//   - merge_patch: apply Value as a JSON Merge Patch (RFC 7396), in which
+//     null removes a key
+//   - json_patch: apply Value, a list of operations, as a JSON Patch
+//     (RFC 6902) with JSON Pointer paths
+type Operation struct {
+	Op     string
+	Key    string
+	NewKey string
+	Value  any
+}
+
+// Apply applies ops in order to a copy of fm and returns the result. fm is
+// not modified, even when an operation fails.
+func Apply(fm map[string]any, ops []Operation) (map[string]any, error) {
+	result := deepCopy(fm).(map[string]any)
+	if result == nil {
+		result = make(map[string]any)
+	}
+	for i, op := range ops {
+		var err error
+		if result, err = applyOperation(result, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i+1, op.Op, err)
+		}
+	}
+	return result, nil
+}
+
+func applyOperation(fm map[string]any, op Operation) (map[string]any, error) {
+	name := strings.ToLower(strings.TrimSpace(op.Op))
+	switch name {
+	case "set", "unset", "rename", "append", "remove":
+		if op.Key == "" {
+			return nil, fmt.Errorf("key cannot be empty")
+		}
+	}
+
+	switch name {
+	case "set":
+		fm[op.Key] = deepCopy(op.Value)
+	case "unset":
+		delete(fm, op.Key)
+	case "rename":
+		if op.NewKey == "" {
+			return nil, fmt.Errorf("newKey cannot be empty")
+		}
+		value, ok := fm[op.Key]
+		if !ok {
+			return nil, fmt.Errorf("key not found: %s", op.Key)
+		}
+		if _, exists := fm[op.NewKey]; exists && op.NewKey != op.Key {
+			return nil, fmt.Errorf("key already exists: %s", op.NewKey)
+		}
+		delete(fm, op.Key)
+		fm[op.NewKey] = value
+	case "append":
+		list := toList(fm[op.Key])
+		for _, item := range toList(op.Value) {
+			if !slices.ContainsFunc(list, func(v any) bool { return equal(v, item) }) {
+				list = append(list, deepCopy(item))
+			}
+		}
+		fm[op.Key] = list
+	case "remove":
+		value, ok := fm[op.Key]
+		if !ok {
+			return fm, nil
+		}
+		items := toList(op.Value)
+		list := slices.DeleteFunc(toList(value), func(v any) bool {
+			return slices.ContainsFunc(items, func(item any) bool { return equal(v, item) })
+		})
+		if len(list) == 0 {
+			delete(fm, op.Key)
+		} else {
+			fm[op.Key] = list
+		}
+	case "merge":
+		patch, ok := op.Value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("value must be an object")
+		}
+		return DeepMerge(fm, patch), nil
+	case "merge_patch":
+		patch, ok := op.Value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("value must be an object")
+		}
+		return MergePatch(fm, patch), nil
+	case "json_patch":
+		patch, err := parsePatch(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return ApplyPatch(fm, patch)
+	default:
+		return nil, fmt.Errorf("unknown operation (use set, unset, rename, append, remove, merge, merge_patch or json_patch)")
+	}
+	return fm, nil
+}
+
+// toList returns v as a list: itself if it is one, nothing if it is nil,
+// and a list of one item otherwise.
+func toList(v any) []any {
+	switch v := v.(type) {
+	case nil:
+		return []any{}
+	case []any:
+		return slices.Clone(v)
+	case []string:
+		list := make([]any, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	default:
+		return []any{v}
+	}
+}
+
+// DeepMerge merges src into dst: nested maps are merged recursively and
+// every other value in src replaces the one in dst. It returns dst.
+func DeepMerge(dst, src map[string]any) map[string]any {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			dst[key] = DeepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = deepCopy(value)
+	}
+	return dst
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to target and returns
+// it: null values remove keys, nested objects are patched recursively and
+// other values replace the target's.
+func MergePatch(target, patch map[string]any) map[string]any {
+	if target == nil {
+		target = make(map[string]any)
+	}
+	for key, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(target, key)
+		case map[string]any:
+			existing, _ := target[key].(map[string]any)
+			target[key] = MergePatch(existing, value)
+		default:
+			target[key] = deepCopy(value)
+		}
+	}
+	return target
+}
+
+// deepCopy copies the maps and lists in v.
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			return map[string]any(nil)
+		}
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = deepCopy(value)
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, value := range v {
+			list[i] = deepCopy(value)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// equal reports whether two values are equal, treating numbers of
+// different types as equal if their values are, since YAML decodes
+// integers as int and JSON as float64.
+func equal(a, b any) bool {
+	if x, ok := values.Number(a); ok {
+		y, ok := values.Number(b)
+		return ok && x == y
+	}
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		return ok && maps.EqualFunc(a, b, equal)
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, equal)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,232 @@
+package frontmatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultIndent is the indentation used for frontmatter that has no nested
+// values to take it from, and matches what Obsidian writes.
+const defaultIndent = 2
+
+// Update returns a note with the given frontmatter and body. If content
+// already has frontmatter, its YAML is edited in place rather than
+// re-marshaled: keys whose values are unchanged keep their position,
+// comments and formatting byte for byte, changed keys keep their position,
+// comments and quoting or flow style, removed keys are dropped along with
+// the comment lines right above them, and new keys are added at the end.
+func (h *Handler) Update(content string, frontmatter map[string]any, body string) (string, error) {
+	if len(frontmatter) == 0 {
+		return body, nil
+	}
+
+	yamlContent, _, ok := split(content)
+	if !ok || h.Parse(content).Content == content {
+		// No frontmatter, or frontmatter that does not parse and is left
+		// in the body.
+		return h.Stringify(frontmatter, body)
+	}
+
+	updated, err := editYAML(yamlContent, frontmatter)
+	if err != nil {
+		return "", fmt.Errorf("failed to stringify frontmatter: %w", err)
+	}
+	return "---\n" + updated + "---\n" + body, nil
+}
+
+// editYAML returns src, the YAML of a frontmatter block, changed to hold
+// frontmatter. Top-level entries are copied line by line when unchanged
+// and re-encoded one at a time when changed.
+func editYAML(src string, frontmatter map[string]any) (string, error) {
+	lines := strings.SplitAfter(src, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	indent := detectIndent(lines)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return "", err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode || doc.Content[0].Style&yaml.FlowStyle != 0 {
+		return encode(frontmatter, indent)
+	}
+	root := doc.Content[0]
+
+	// Each entry spans from the comment lines right above its key to the
+	// start of the next entry.
+	n := len(root.Content) / 2
+	keys := make(map[string]bool, n)
+	starts := make([]int, n+1)
+	for i := range n {
+		key := root.Content[2*i]
+		if key.Kind != yaml.ScalarNode || keys[key.Value] {
+			return encode(frontmatter, indent)
+		}
+		keys[key.Value] = true
+
+		start, floor := key.Line-1, 0
+		if i > 0 {
+			floor = root.Content[2*i-2].Line
+		}
+		for start > floor && strings.HasPrefix(lines[start-1], "#") {
+			start--
+		}
+		starts[i] = start
+	}
+	starts[n] = len(lines)
+
+	var b strings.Builder
+	b.WriteString(strings.Join(lines[:starts[0]], ""))
+	for i := range n {
+		key, value := root.Content[2*i], root.Content[2*i+1]
+		start, end := starts[i], starts[i+1]
+
+		v, ok := frontmatter[key.Value]
+		if !ok {
+			continue
+		}
+		var current any
+		if err := value.Decode(&current); err == nil && equal(current, v) {
+			b.WriteString(strings.Join(lines[start:end], ""))
+			continue
+		}
+
+		// Blank and comment lines after the value stay as they are.
+		keyLine := key.Line - 1
+		trailing := end
+		for trailing > keyLine+1 && isBlankOrComment(lines[trailing-1]) {
+			trailing--
+		}
+
+		node, err := updateNode(value, v)
+		if err != nil {
+			return "", err
+		}
+		k := *key
+		k.HeadComment, k.FootComment = "", ""
+		updated := *node
+		updated.HeadComment, updated.FootComment = "", ""
+		entry, err := encode(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{&k, &updated}}, indent)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(strings.Join(lines[start:keyLine], ""))
+		b.WriteString(entry)
+		b.WriteString(strings.Join(lines[trailing:end], ""))
+	}
+
+	added := make(map[string]any)
+	for key, value := range frontmatter {
+		if !keys[key] {
+			added[key] = value
+		}
+	}
+	if len(added) > 0 {
+		entries, err := encode(added, indent)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(entries)
+	}
+	return b.String(), nil
+}
+
+// updateNode returns a node for v that keeps what it can of old: old
+// itself if its value is v, the entries of a mapping that did not change,
+// the items of a list that did not change, the flow style of a list, the
+// quoting of a string and the comments.
+func updateNode(old *yaml.Node, v any) (*yaml.Node, error) {
+	var current any
+	if err := old.Decode(&current); err == nil && equal(current, v) {
+		return old, nil
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case old.Kind == yaml.MappingNode && node.Kind == yaml.MappingNode:
+		m, ok := v.(map[string]any)
+		if !ok {
+			break
+		}
+		updated := *old
+		updated.Content = nil
+		seen := make(map[string]bool)
+		for i := 0; i+1 < len(old.Content); i += 2 {
+			key := old.Content[i]
+			value, ok := m[key.Value]
+			if !ok || key.Kind != yaml.ScalarNode || seen[key.Value] {
+				continue
+			}
+			seen[key.Value] = true
+			n, err := updateNode(old.Content[i+1], value)
+			if err != nil {
+				return nil, err
+			}
+			updated.Content = append(updated.Content, key, n)
+		}
+		// Encoded mappings have sorted keys, so new keys go in order.
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !seen[node.Content[i].Value] {
+				updated.Content = append(updated.Content, node.Content[i], node.Content[i+1])
+			}
+		}
+		return &updated, nil
+	case old.Kind == yaml.SequenceNode && node.Kind == yaml.SequenceNode:
+		node.Style = old.Style
+		for i := range min(len(old.Content), len(node.Content)) {
+			var prev, next any
+			if old.Content[i].Decode(&prev) == nil && node.Content[i].Decode(&next) == nil && equal(prev, next) {
+				node.Content[i] = old.Content[i]
+			}
+		}
+	case old.Kind == yaml.ScalarNode && node.Kind == yaml.ScalarNode && old.ShortTag() == "!!str" && node.ShortTag() == "!!str":
+		// Plain style is never copied, since a new value may need quotes.
+		block := old.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0
+		if old.Style != 0 && (!block || strings.Contains(node.Value, "\n")) {
+			node.Style = old.Style
+		}
+	}
+	node.HeadComment, node.LineComment, node.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	return node, nil
+}
+
+// encode marshals v as YAML with the given indentation.
+func encode(v any, indent int) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// detectIndent returns the indentation of the first indented line, which
+// is how deep the YAML nests values.
+func detectIndent(lines []string) int {
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if n := len(line) - len(trimmed); n > 0 && !isBlankOrComment(trimmed) {
+			return min(max(n, 2), 9)
+		}
+	}
+	return defaultIndent
+}
+
+func isBlankOrComment(line string) bool {
+	return strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#")
+}
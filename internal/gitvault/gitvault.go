@@ -0,0 +1,223 @@
+// Package gitvault commits vault changes to the git repository the vault
+// lives in and reads the history of notes, using the local git binary.
+package gitvault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultName and defaultEmail identify the committer when git has no
+// user configured.
+const (
+	defaultName  = "obsidian-mcp"
+	defaultEmail = "obsidian-mcp@localhost"
+)
+
+// ErrNotRepository is returned by Open when the vault is not inside a git
+// work tree.
+var ErrNotRepository = errors.New("vault is not in a git repository")
+
+// Repo is the git work tree containing a vault. Paths are relative to the
+// vault, which may be a subdirectory of the work tree.
+type Repo struct {
+	mu       sync.Mutex
+	dir      string
+	identity []string
+}
+
+// Commit is a revision that changed a note.
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Path    string    `json:"path"` // the note's path in this revision
+}
+
+// Open returns the repository containing vaultPath.
+func Open(vaultPath string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git not found: %w", err)
+	}
+	r := &Repo{dir: vaultPath}
+	out, err := r.git(nil, "rev-parse", "--is-inside-work-tree")
+	if err != nil || strings.TrimSpace(out) != "true" {
+		return nil, ErrNotRepository
+	}
+
+	// Commits fail without an identity; fall back to one rather than
+	// leaving changes uncommitted.
+	if name, _ := r.git(nil, "config", "user.name"); strings.TrimSpace(name) == "" {
+		r.identity = append(r.identity, "-c", "user.name="+defaultName)
+	}
+	if email, _ := r.git(nil, "config", "user.email"); strings.TrimSpace(email) == "" {
+		r.identity = append(r.identity, "-c", "user.email="+defaultEmail)
+	}
+	return r, nil
+}
+
+// git runs a git command in the vault and returns its standard output.
+func (r *Repo) git(stdin []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return stdout.String(), fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return stdout.String(), fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// CommitPaths commits the current state of the given vault paths, and
+// nothing else that may be staged, with message. Paths that were removed
+// are committed as deletions. It returns the new commit hash, or an empty
+// string if the paths had no changes to commit.
+func (r *Repo) CommitPaths(message string, paths []string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	specs, err := r.pathspecs(paths)
+	if err != nil || len(specs) == 0 {
+		return "", err
+	}
+	if _, err := r.git(nil, append([]string{"add", "-A", "--"}, specs...)...); err != nil {
+		return "", err
+	}
+	if _, err := r.git(nil, append([]string{"diff", "--cached", "--quiet", "--"}, specs...)...); err == nil {
+		return "", nil
+	}
+
+	args := append(slices.Clone(r.identity), "commit", "--quiet", "--no-verify", "-F", "-", "--")
+	if _, err := r.git([]byte(message), append(args, specs...)...); err != nil {
+		return "", err
+	}
+	out, err := r.git(nil, "rev-parse", "HEAD")
+	return strings.TrimSpace(out), err
+}
+
+// pathspecs returns the literal pathspecs of the paths git can commit:
+// those that exist or are tracked.
+func (r *Repo) pathspecs(paths []string) ([]string, error) {
+	var specs, missing []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		if _, err := os.Lstat(filepath.Join(r.dir, filepath.FromSlash(path))); err == nil {
+			specs = append(specs, literal(path))
+		} else {
+			missing = append(missing, literal(path))
+		}
+	}
+	if len(missing) > 0 {
+		out, err := r.git(nil, append([]string{"ls-files", "-z", "--"}, missing...)...)
+		if err != nil {
+			return nil, err
+		}
+		for tracked := range strings.SplitSeq(strings.TrimSuffix(out, "\x00"), "\x00") {
+			if tracked != "" {
+				specs = append(specs, literal(tracked))
+			}
+		}
+	}
+	return specs, nil
+}
+
+// literal turns a path into a pathspec that matches only that path.
+func literal(path string) string {
+	return ":(literal)" + path
+}
+
+// Log returns the commits that changed a note, newest first, following
+// renames.
+func (r *Repo) Log(path string, limit int) ([]Commit, error) {
+	args := []string{"log", "--follow", "--name-only", "--format=%x1e%H%x1f%an%x1f%aI%x1f%s"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	out, err := r.git(nil, append(args, "--", literal(path))...)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := r.prefix()
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []Commit{}
+	for record := range strings.SplitSeq(out, "\x1e") {
+		header, names, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		commit := Commit{Hash: fields[0], Author: fields[1], Date: date, Subject: fields[3], Path: path}
+		for name := range strings.SplitSeq(strings.TrimSpace(names), "\n") {
+			if rel, ok := strings.CutPrefix(name, prefix); ok && name != "" {
+				commit.Path = rel
+				break
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// prefix returns the vault's path inside the work tree, with a trailing
+// slash, or "" if the vault is the work tree.
+func (r *Repo) prefix() (string, error) {
+	out, err := r.git(nil, "rev-parse", "--show-prefix")
+	return strings.TrimSpace(out), err
+}
+
+// Show returns the content of a note at a revision.
+func (r *Repo) Show(revision, path string) (string, error) {
+	if err := checkRevision(revision); err != nil {
+		return "", err
+	}
+	return r.git(nil, "show", revision+":./"+path)
+}
+
+// Diff returns the unified diff of a note between two revisions. An empty
+// to compares with the note in the vault.
+func (r *Repo) Diff(path, from, to string) (string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	for _, revision := range []string{from, to} {
+		if revision == "" {
+			continue
+		}
+		if err := checkRevision(revision); err != nil {
+			return "", err
+		}
+		args = append(args, revision)
+	}
+	return r.git(nil, append(args, "--", literal(path))...)
+}
+
+// checkRevision rejects revisions git would parse as options.
+func checkRevision(revision string) error {
+	if revision == "" || strings.HasPrefix(revision, "-") || strings.ContainsAny(revision, " \t\n:") {
+		return fmt.Errorf("invalid revision: %q", revision)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package gitvault
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupRepo creates a repository with the vault in a subdirectory, so
+// paths relative to the vault differ from paths in the work tree.
+func setupRepo(t *testing.T) (string, *Repo) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	vault := filepath.Join(root, "vault")
+	os.MkdirAll(vault, 0o755)
+
+	repo, err := Open(vault)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return vault, repo
+}
+
+func writeFile(t *testing.T, vault, path, content string) {
+	t.Helper()
+	full := filepath.Join(vault, path)
+	os.MkdirAll(filepath.Dir(full), 0o755)
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	if _, err := Open(t.TempDir()); err != ErrNotRepository {
+		t.Errorf("Open() error = %v, want ErrNotRepository", err)
+	}
+}
+
+func TestCommitPaths(t *testing.T) {
+	vault, repo := setupRepo(t)
+
+	writeFile(t, vault, "a.md", "one\n")
+	writeFile(t, vault, "unrelated.md", "not mine\n")
+	hash, err := repo.CommitPaths("write: a.md\n", []string{"a.md", "gone.md"})
+	if err != nil || hash == "" {
+		t.Fatalf("CommitPaths() = %q, %v", hash, err)
+	}
+
+	out, _ := repo.git(nil, "status", "--porcelain")
+	if strings.TrimSpace(out) != "?? vault/unrelated.md" {
+		t.Errorf("git status = %q, want only unrelated.md left", out)
+	}
+
+	if hash, err := repo.CommitPaths("write: a.md\n", []string{"a.md"}); err != nil || hash != "" {
+		t.Errorf("CommitPaths() without changes = %q, %v, want no commit", hash, err)
+	}
+
+	os.Rename(filepath.Join(vault, "a.md"), filepath.Join(vault, "notes", "b.md"))
+	writeFile(t, vault, "notes/b.md", "one\ntwo\n")
+	if _, err := repo.CommitPaths("rename: a.md -> notes/b.md\n", []string{"notes/b.md", "a.md"}); err != nil {
+		t.Fatalf("CommitPaths() rename error = %v", err)
+	}
+
+	commits, err := repo.Log("notes/b.md", 0)
+	if err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Log() = %+v, want 2 commits following the rename", commits)
+	}
+	if commits[0].Path != "notes/b.md" || commits[1].Path != "a.md" || commits[1].Subject != "write: a.md" {
+		t.Errorf("Log() = %+v", commits)
+	}
+	if commits[0].Author != defaultName || commits[0].Date.IsZero() {
+		t.Errorf("Log() author = %q, date = %v", commits[0].Author, commits[0].Date)
+	}
+
+	content, err := repo.Show(commits[1].Hash, "a.md")
+	if err != nil || content != "one\n" {
+		t.Errorf("Show() = %q, %v, want %q", content, err, "one\n")
+	}
+
+	writeFile(t, vault, "notes/b.md", "one\nthree\n")
+	diff, err := repo.Diff("notes/b.md", "HEAD", "")
+	if err != nil || !strings.Contains(diff, "-two") || !strings.Contains(diff, "+three") {
+		t.Errorf("Diff() = %q, %v", diff, err)
+	}
+
+	for _, revision := range []string{"", "--output=x", "HEAD:other", "a b"} {
+		if _, err := repo.Show(revision, "a.md"); err == nil {
+			t.Errorf("Show(%q) succeeded", revision)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format identifies a graph serialization format.
+type Format string
+
+const (
+	// FormatJSON writes nodes and edges as a JSON document.
+	FormatJSON Format = "json"
+	// FormatDOT writes a Graphviz digraph.
+	FormatDOT Format = "dot"
+	// FormatGraphML writes a GraphML document.
+	FormatGraphML Format = "graphml"
+)
+
+// ParseFormat parses a format name, defaulting to JSON when empty.
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatDOT, "gv", "graphviz":
+		return FormatDOT, nil
+	case FormatGraphML, "xml":
+		return FormatGraphML, nil
+	default:
+		return "", fmt.Errorf("unsupported graph format %q: use json, dot or graphml", name)
+	}
+}
+
+// Label returns a human readable label for a node: the note name without
+// folder and extension, or the tag ID.
+func (n Node) Label() string {
+	if n.Kind == NodeTag {
+		return n.ID
+	}
+	return strings.TrimSuffix(path.Base(n.ID), ".md")
+}
+
+// Write serializes the graph in the given format.
+func (g *Graph) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		return g.WriteJSON(w)
+	case FormatDOT:
+		return g.WriteDOT(w)
+	case FormatGraphML:
+		return g.WriteGraphML(w)
+	default:
+		return fmt.Errorf("unsupported graph format %q", format)
+	}
+}
+
+type jsonNode struct {
+	ID    string         `json:"id"`
+	Kind  NodeKind       `json:"kind"`
+	Label string         `json:"label"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+type jsonGraph struct {
+	Nodes []jsonNode `json:"nodes"`
+	Edges []Edge     `json:"edges"`
+}
+
+// WriteJSON writes the graph as {"nodes": [...], "edges": [...]}.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	doc := jsonGraph{Nodes: []jsonNode{}, Edges: g.Edges()}
+	for _, n := range g.Nodes() {
+		doc.Nodes = append(doc.Nodes, jsonNode{ID: n.ID, Kind: n.Kind, Label: n.Label(), Attrs: n.Attrs})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteDOT writes the graph as a Graphviz digraph. Tag nodes are drawn as
+// boxes and tag edges as dashed lines.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph vault {\n")
+
+	for _, n := range g.Nodes() {
+		attrs := []string{
+			"label=" + dotQuote(n.Label()),
+			"kind=" + dotQuote(string(n.Kind)),
+		}
+		if n.Kind == NodeTag {
+			attrs = append(attrs, "shape=box")
+		}
+		for _, key := range sortedKeys(n.Attrs) {
+			attrs = append(attrs, dotQuote(key)+"="+dotQuote(AttrString(n.Attrs[key])))
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+
+	for _, e := range g.Edges() {
+		attrs := "kind=" + dotQuote(string(e.Kind))
+		if e.Kind == EdgeTag {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(e.From), dotQuote(e.To), attrs)
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteGraphML writes the graph as a GraphML document. Every attribute is
+// declared as a string key.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	nodes := g.Nodes()
+
+	attrNames := make(map[string]bool)
+	for _, n := range nodes {
+		for key := range n.Attrs {
+			attrNames[key] = true
+		}
+	}
+	var names []string
+	for name := range attrNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="kind" for="all" attr.name="kind" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  <key id=%s for=\"node\" attr.name=%s attr.type=\"string\"/>\n",
+			xmlQuote("attr:"+name), xmlQuote(name))
+	}
+	b.WriteString(`  <graph id="vault" edgedefault="directed">` + "\n")
+
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "    <node id=%s>\n", xmlQuote(n.ID))
+		writeGraphMLData(&b, "kind", string(n.Kind))
+		writeGraphMLData(&b, "label", n.Label())
+		for _, key := range sortedKeys(n.Attrs) {
+			writeGraphMLData(&b, "attr:"+key, AttrString(n.Attrs[key]))
+		}
+		b.WriteString("    </node>\n")
+	}
+
+	for i, e := range g.Edges() {
+		fmt.Fprintf(&b, "    <edge id=\"e%d\" source=%s target=%s>\n", i, xmlQuote(e.From), xmlQuote(e.To))
+		writeGraphMLData(&b, "kind", string(e.Kind))
+		b.WriteString("    </edge>\n")
+	}
+
+	b.WriteString("  </graph>\n</graphml>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeGraphMLData(b *strings.Builder, key, value string) {
+	fmt.Fprintf(b, "      <data key=%s>%s</data>\n", xmlQuote(key), xmlEscape(value))
+}
+
+// AttrString renders an attribute value for text-based formats. Lists are
+// joined with ", " and dates without a time of day are written as dates.
+func AttrString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case time.Time:
+		if val.Hour() == 0 && val.Minute() == 0 && val.Second() == 0 && val.Nanosecond() == 0 {
+			return val.Format(time.DateOnly)
+		}
+		return val.Format(time.RFC3339)
+	case []any:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, AttrString(item))
+		}
+		return strings.Join(parts, ", ")
+	case []string:
+		return strings.Join(val, ", ")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + r.Replace(s) + `"`
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func xmlQuote(s string) string {
+	return `"` + xmlEscape(s) + `"`
+}
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func buildExportGraph() *Graph {
+	g := New()
+	g.AddNote("notes/a.md", map[string]any{"status": "active", "aliases": []any{"first", "A"}})
+	g.AddNote("b.md", nil)
+	g.AddLink("notes/a.md", "b.md")
+	g.AddTag("notes/a.md", "project")
+	return g
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{name: "", want: FormatJSON},
+		{name: "JSON", want: FormatJSON},
+		{name: "dot", want: FormatDOT},
+		{name: "graphml", want: FormatGraphML},
+		{name: "csv", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraph_WriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := buildExportGraph().WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var doc jsonGraph
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("WriteJSON() produced invalid JSON: %v", err)
+	}
+	if len(doc.Nodes) != 3 || len(doc.Edges) != 2 {
+		t.Fatalf("WriteJSON() = %d nodes, %d edges, want 3, 2", len(doc.Nodes), len(doc.Edges))
+	}
+	if doc.Nodes[1].ID != "notes/a.md" || doc.Nodes[1].Label != "a" || doc.Nodes[1].Attrs["status"] != "active" {
+		t.Errorf("WriteJSON() node = %+v, want notes/a.md with label and attrs", doc.Nodes[1])
+	}
+	if doc.Nodes[2].Kind != NodeTag {
+		t.Errorf("WriteJSON() last node kind = %q, want tag", doc.Nodes[2].Kind)
+	}
+}
+
+func TestGraph_WriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := buildExportGraph().WriteDOT(&buf); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+	got := buf.String()
+
+	wantLines := []string{
+		"digraph vault {",
+		`  "notes/a.md" [label="a", kind="note", "aliases"="first, A", "status"="active"];`,
+		`  "#project" [label="#project", kind="tag", shape=box];`,
+		`  "notes/a.md" -> "b.md" [kind="link"];`,
+		`  "notes/a.md" -> "#project" [kind="tag", style=dashed];`,
+		"}",
+	}
+	for _, line := range wantLines {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("WriteDOT() missing line %q in:\n%s", line, got)
+		}
+	}
+}
+
+func TestGraph_WriteGraphML(t *testing.T) {
+	g := buildExportGraph()
+	g.AddNote(`odd "name" & <co>.md`, nil)
+
+	var buf bytes.Buffer
+	if err := g.WriteGraphML(&buf); err != nil {
+		t.Fatalf("WriteGraphML() error = %v", err)
+	}
+
+	var doc struct {
+		Keys []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"key"`
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("WriteGraphML() produced invalid XML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Keys) != 4 {
+		t.Errorf("WriteGraphML() declared %d keys, want 4", len(doc.Keys))
+	}
+	if len(doc.Graph.Nodes) != 4 || len(doc.Graph.Edges) != 2 {
+		t.Errorf("WriteGraphML() = %d nodes, %d edges, want 4, 2", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	if doc.Graph.Nodes[2].ID != `odd "name" & <co>.md` {
+		t.Errorf("WriteGraphML() node ID = %q, want escaped name to round-trip", doc.Graph.Nodes[2].ID)
+	}
+}
+
+func TestAttrString(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{value: nil, want: ""},
+		{value: "text", want: "text"},
+		{value: 3, want: "3"},
+		{value: true, want: "true"},
+		{value: []any{"a", 1}, want: "a, 1"},
+		{value: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), want: "2024-01-05"},
+		{value: time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC), want: "2024-01-05T09:30:00Z"},
+	}
+
+	for _, tt := range tests {
+		if got := AttrString(tt.value); got != tt.want {
+			t.Errorf("AttrString(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+// Package graph models the note/link/tag graph of an Obsidian vault and
+// provides traversal and centrality queries over it.
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NodeKind identifies what a node in the graph represents.
+type NodeKind string
+
+// EdgeKind identifies what an edge in the graph represents.
+type EdgeKind string
+
+// Direction selects which link edges are followed during traversal.
+type Direction string
+
+const (
+	// NodeNote is a markdown note, identified by its vault-relative path.
+	NodeNote NodeKind = "note"
+	// NodeTag is a tag, identified by "#" followed by the tag name.
+	NodeTag NodeKind = "tag"
+
+	// EdgeLink is a wiki-link from one note to another.
+	EdgeLink EdgeKind = "link"
+	// EdgeTag connects a note to a tag it carries.
+	EdgeTag EdgeKind = "tag"
+
+	// Outgoing follows links from the note to the notes it links to.
+	Outgoing Direction = "out"
+	// Incoming follows links from the note to the notes linking to it.
+	Incoming Direction = "in"
+	// Both follows links in either direction.
+	Both Direction = "both"
+)
+
+// Relations reported for each traversal step.
+const (
+	RelationOutgoing = "outgoing"
+	RelationBacklink = "backlink"
+	RelationTag      = "shared-tag"
+)
+
+type (
+	// Node is a vertex in the graph.
+	Node struct {
+		ID    string         `json:"id"`
+		Kind  NodeKind       `json:"kind"`
+		Attrs map[string]any `json:"attrs,omitempty"`
+	}
+
+	// Edge is a directed connection between two nodes.
+	Edge struct {
+		From string   `json:"from"`
+		To   string   `json:"to"`
+		Kind EdgeKind `json:"kind"`
+	}
+
+	// TraversalOptions controls which edges are followed during traversal.
+	TraversalOptions struct {
+		Direction   Direction
+		IncludeTags bool
+	}
+
+	// Hop is a note reached during a neighborhood expansion.
+	Hop struct {
+		ID       string
+		Depth    int
+		Via      string
+		Relation string
+		Tag      string
+	}
+
+	// Step is a single note on a shortest path. Relation and Tag describe
+	// the edge used to reach it from the previous step.
+	Step struct {
+		ID       string
+		Relation string
+		Tag      string
+	}
+
+	// Degree holds the link and tag degree of a note.
+	Degree struct {
+		In   int
+		Out  int
+		Tags int
+	}
+)
+
+// Graph is an in-memory note/link/tag graph. It is not safe for concurrent
+// mutation.
+type Graph struct {
+	nodes map[string]*Node
+	order []string
+	out   map[string][]string
+	in    map[string][]string
+	tags  map[string][]string // note -> tag node IDs
+	notes map[string][]string // tag node ID -> notes
+	edges map[Edge]bool
+}
+
+// New creates an empty graph.
+func New() *Graph {
+	return &Graph{
+		nodes: make(map[string]*Node),
+		out:   make(map[string][]string),
+		in:    make(map[string][]string),
+		tags:  make(map[string][]string),
+		notes: make(map[string][]string),
+		edges: make(map[Edge]bool),
+	}
+}
+
+// TagID returns the node ID used for a tag.
+func TagID(tag string) string {
+	return "#" + tag
+}
+
+func (g *Graph) addNode(id string, kind NodeKind) *Node {
+	if n, ok := g.nodes[id]; ok {
+		return n
+	}
+	n := &Node{ID: id, Kind: kind}
+	g.nodes[id] = n
+	g.order = append(g.order, id)
+	return n
+}
+
+// AddNote adds a note node. Calling it again for the same note merges attrs.
+func (g *Graph) AddNote(id string, attrs map[string]any) {
+	n := g.addNode(id, NodeNote)
+	if len(attrs) == 0 {
+		return
+	}
+	if n.Attrs == nil {
+		n.Attrs = make(map[string]any, len(attrs))
+	}
+	for k, v := range attrs {
+		n.Attrs[k] = v
+	}
+}
+
+// AddLink adds a link edge between two notes, creating them if needed.
+// Self-links and duplicate links are ignored.
+func (g *Graph) AddLink(from, to string) {
+	if from == to {
+		return
+	}
+	edge := Edge{From: from, To: to, Kind: EdgeLink}
+	if g.edges[edge] {
+		return
+	}
+	g.addNode(from, NodeNote)
+	g.addNode(to, NodeNote)
+	g.edges[edge] = true
+	g.out[from] = append(g.out[from], to)
+	g.in[to] = append(g.in[to], from)
+}
+
+// AddTag attaches a tag to a note, creating both nodes if needed.
+func (g *Graph) AddTag(note, tag string) {
+	tagID := TagID(tag)
+	edge := Edge{From: note, To: tagID, Kind: EdgeTag}
+	if g.edges[edge] {
+		return
+	}
+	g.addNode(note, NodeNote)
+	g.addNode(tagID, NodeTag)
+	g.edges[edge] = true
+	g.tags[note] = append(g.tags[note], tagID)
+	g.notes[tagID] = append(g.notes[tagID], note)
+}
+
+// HasNote reports whether the graph contains the given note.
+func (g *Graph) HasNote(id string) bool {
+	n, ok := g.nodes[id]
+	return ok && n.Kind == NodeNote
+}
+
+// Node returns the node with the given ID.
+func (g *Graph) Node(id string) (Node, bool) {
+	n, ok := g.nodes[id]
+	if !ok {
+		return Node{}, false
+	}
+	return *n, true
+}
+
+// Nodes returns all nodes sorted by kind (notes first) and ID.
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, id := range g.order {
+		nodes = append(nodes, *g.nodes[id])
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Kind != nodes[j].Kind {
+			return nodes[i].Kind == NodeNote
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
+
+// Edges returns all edges sorted by kind, source and target.
+func (g *Graph) Edges() []Edge {
+	edges := make([]Edge, 0, len(g.edges))
+	for e := range g.edges {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Kind != edges[j].Kind {
+			return edges[i].Kind == EdgeLink
+		}
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// neighbor is a note adjacent to another note along with how it was reached.
+type neighbor struct {
+	id       string
+	relation string
+	tag      string
+}
+
+// neighbors returns the notes adjacent to id in deterministic order.
+func (g *Graph) neighbors(id string, opts TraversalOptions) []neighbor {
+	var result []neighbor
+	seen := make(map[string]bool)
+
+	add := func(ids []string, relation, tag string) {
+		sorted := append([]string(nil), ids...)
+		sort.Strings(sorted)
+		for _, other := range sorted {
+			if other == id || seen[other] {
+				continue
+			}
+			seen[other] = true
+			result = append(result, neighbor{id: other, relation: relation, tag: tag})
+		}
+	}
+
+	dir := opts.Direction
+	if dir == "" {
+		dir = Both
+	}
+	if dir == Outgoing || dir == Both {
+		add(g.out[id], RelationOutgoing, "")
+	}
+	if dir == Incoming || dir == Both {
+		add(g.in[id], RelationBacklink, "")
+	}
+	if opts.IncludeTags {
+		tagIDs := append([]string(nil), g.tags[id]...)
+		sort.Strings(tagIDs)
+		for _, tagID := range tagIDs {
+			add(g.notes[tagID], RelationTag, tagID[1:])
+		}
+	}
+	return result
+}
+
+// Neighborhood returns every note within depth hops of start, ordered by
+// depth and then path. The start note itself is not included.
+func (g *Graph) Neighborhood(start string, depth int, opts TraversalOptions) ([]Hop, error) {
+	if !g.HasNote(start) {
+		return nil, fmt.Errorf("note not found in graph: %s", start)
+	}
+	if depth <= 0 {
+		depth = 1
+	}
+
+	visited := map[string]bool{start: true}
+	frontier := []string{start}
+	var hops []Hop
+
+	for d := 1; d <= depth && len(frontier) > 0; d++ {
+		var level []Hop
+		for _, id := range frontier {
+			for _, n := range g.neighbors(id, opts) {
+				if visited[n.id] {
+					continue
+				}
+				visited[n.id] = true
+				level = append(level, Hop{
+					ID:       n.id,
+					Depth:    d,
+					Via:      id,
+					Relation: n.relation,
+					Tag:      n.tag,
+				})
+			}
+		}
+		sort.Slice(level, func(i, j int) bool {
+			return level[i].ID < level[j].ID
+		})
+
+		frontier = frontier[:0]
+		for _, hop := range level {
+			frontier = append(frontier, hop.ID)
+		}
+		hops = append(hops, level...)
+	}
+
+	return hops, nil
+}
+
+// ShortestPath returns the shortest sequence of notes connecting from and
+// to, including both ends. It returns nil when no path exists.
+func (g *Graph) ShortestPath(from, to string, opts TraversalOptions) ([]Step, error) {
+	if !g.HasNote(from) {
+		return nil, fmt.Errorf("note not found in graph: %s", from)
+	}
+	if !g.HasNote(to) {
+		return nil, fmt.Errorf("note not found in graph: %s", to)
+	}
+	if from == to {
+		return []Step{{ID: from}}, nil
+	}
+
+	prev := map[string]neighbor{from: {}}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, n := range g.neighbors(id, opts) {
+			if _, ok := prev[n.id]; ok {
+				continue
+			}
+			prev[n.id] = neighbor{id: id, relation: n.relation, tag: n.tag}
+			if n.id == to {
+				return buildPath(prev, from, to), nil
+			}
+			queue = append(queue, n.id)
+		}
+	}
+
+	return nil, nil
+}
+
+func buildPath(prev map[string]neighbor, from, to string) []Step {
+	var path []Step
+	for id := to; id != from; id = prev[id].id {
+		p := prev[id]
+		path = append(path, Step{ID: id, Relation: p.relation, Tag: p.tag})
+	}
+	path = append(path, Step{ID: from})
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Degrees returns the link and tag degree of every note.
+func (g *Graph) Degrees() map[string]Degree {
+	degrees := make(map[string]Degree)
+	for id, n := range g.nodes {
+		if n.Kind != NodeNote {
+			continue
+		}
+		degrees[id] = Degree{
+			In:   len(g.in[id]),
+			Out:  len(g.out[id]),
+			Tags: len(g.tags[id]),
+		}
+	}
+	return degrees
+}
+
+// PageRank computes PageRank over the link edges between notes. Rank held
+// by notes without outgoing links is spread evenly across all notes.
+func (g *Graph) PageRank(damping float64, iterations int) map[string]float64 {
+	var ids []string
+	for _, id := range g.order {
+		if g.nodes[id].Kind == NodeNote {
+			ids = append(ids, id)
+		}
+	}
+	n := len(ids)
+	ranks := make(map[string]float64, n)
+	if n == 0 {
+		return ranks
+	}
+
+	if damping <= 0 || damping >= 1 {
+		damping = 0.85
+	}
+	if iterations <= 0 {
+		iterations = 100
+	}
+
+	for _, id := range ids {
+		ranks[id] = 1 / float64(n)
+	}
+
+	const tolerance = 1e-9
+	for range iterations {
+		dangling := 0.0
+		for _, id := range ids {
+			if len(g.out[id]) == 0 {
+				dangling += ranks[id]
+			}
+		}
+
+		base := (1-damping)/float64(n) + damping*dangling/float64(n)
+		next := make(map[string]float64, n)
+		for _, id := range ids {
+			next[id] = base
+		}
+		for _, id := range ids {
+			outs := g.out[id]
+			if len(outs) == 0 {
+				continue
+			}
+			share := damping * ranks[id] / float64(len(outs))
+			for _, to := range outs {
+				next[to] += share
+			}
+		}
+
+		delta := 0.0
+		for _, id := range ids {
+			diff := next[id] - ranks[id]
+			if diff < 0 {
+				diff = -diff
+			}
+			delta += diff
+		}
+		ranks = next
+		if delta < tolerance {
+			break
+		}
+	}
+
+	return ranks
+}
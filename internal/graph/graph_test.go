@@ -0,0 +1,195 @@
+package graph
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// buildTestGraph creates the following vault:
+//
+//	a -> b -> c -> d
+//	e -> b
+//	a, f tagged #project
+func buildTestGraph() *Graph {
+	g := New()
+	for _, id := range []string{"a.md", "b.md", "c.md", "d.md", "e.md", "f.md"} {
+		g.AddNote(id, nil)
+	}
+	g.AddLink("a.md", "b.md")
+	g.AddLink("b.md", "c.md")
+	g.AddLink("c.md", "d.md")
+	g.AddLink("e.md", "b.md")
+	g.AddTag("a.md", "project")
+	g.AddTag("f.md", "project")
+	return g
+}
+
+func hopIDs(hops []Hop) []string {
+	ids := make([]string, 0, len(hops))
+	for _, h := range hops {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+func TestGraph_Neighborhood(t *testing.T) {
+	g := buildTestGraph()
+
+	tests := []struct {
+		name  string
+		start string
+		depth int
+		opts  TraversalOptions
+		want  []string
+	}{
+		{name: "one hop both directions", start: "b.md", depth: 1, want: []string{"a.md", "c.md", "e.md"}},
+		{name: "two hops outgoing", start: "a.md", depth: 2, opts: TraversalOptions{Direction: Outgoing}, want: []string{"b.md", "c.md"}},
+		{name: "incoming only", start: "c.md", depth: 2, opts: TraversalOptions{Direction: Incoming}, want: []string{"b.md", "a.md", "e.md"}},
+		{name: "tag edges", start: "a.md", depth: 1, opts: TraversalOptions{IncludeTags: true}, want: []string{"b.md", "f.md"}},
+		{name: "tags excluded by default", start: "f.md", depth: 3, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hops, err := g.Neighborhood(tt.start, tt.depth, tt.opts)
+			if err != nil {
+				t.Fatalf("Neighborhood() error = %v", err)
+			}
+			got := hopIDs(hops)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Neighborhood() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraph_NeighborhoodRecordsHops(t *testing.T) {
+	g := buildTestGraph()
+
+	hops, err := g.Neighborhood("a.md", 2, TraversalOptions{IncludeTags: true})
+	if err != nil {
+		t.Fatalf("Neighborhood() error = %v", err)
+	}
+
+	want := []Hop{
+		{ID: "b.md", Depth: 1, Via: "a.md", Relation: RelationOutgoing},
+		{ID: "f.md", Depth: 1, Via: "a.md", Relation: RelationTag, Tag: "project"},
+		{ID: "c.md", Depth: 2, Via: "b.md", Relation: RelationOutgoing},
+		{ID: "e.md", Depth: 2, Via: "b.md", Relation: RelationBacklink},
+	}
+	if !reflect.DeepEqual(hops, want) {
+		t.Errorf("Neighborhood() = %#v, want %#v", hops, want)
+	}
+}
+
+func TestGraph_NeighborhoodUnknownNote(t *testing.T) {
+	g := buildTestGraph()
+	if _, err := g.Neighborhood("missing.md", 1, TraversalOptions{}); err == nil {
+		t.Error("Neighborhood() error = nil, want error for unknown note")
+	}
+}
+
+func TestGraph_ShortestPath(t *testing.T) {
+	g := buildTestGraph()
+
+	t.Run("follows links", func(t *testing.T) {
+		path, err := g.ShortestPath("e.md", "d.md", TraversalOptions{Direction: Outgoing})
+		if err != nil {
+			t.Fatalf("ShortestPath() error = %v", err)
+		}
+		want := []Step{
+			{ID: "e.md"},
+			{ID: "b.md", Relation: RelationOutgoing},
+			{ID: "c.md", Relation: RelationOutgoing},
+			{ID: "d.md", Relation: RelationOutgoing},
+		}
+		if !reflect.DeepEqual(path, want) {
+			t.Errorf("ShortestPath() = %#v, want %#v", path, want)
+		}
+	})
+
+	t.Run("respects direction", func(t *testing.T) {
+		path, err := g.ShortestPath("d.md", "a.md", TraversalOptions{Direction: Outgoing})
+		if err != nil {
+			t.Fatalf("ShortestPath() error = %v", err)
+		}
+		if path != nil {
+			t.Errorf("ShortestPath() = %v, want nil", path)
+		}
+	})
+
+	t.Run("uses tags when enabled", func(t *testing.T) {
+		path, err := g.ShortestPath("f.md", "b.md", TraversalOptions{IncludeTags: true})
+		if err != nil {
+			t.Fatalf("ShortestPath() error = %v", err)
+		}
+		want := []Step{
+			{ID: "f.md"},
+			{ID: "a.md", Relation: RelationTag, Tag: "project"},
+			{ID: "b.md", Relation: RelationOutgoing},
+		}
+		if !reflect.DeepEqual(path, want) {
+			t.Errorf("ShortestPath() = %#v, want %#v", path, want)
+		}
+	})
+}
+
+func TestGraph_Degrees(t *testing.T) {
+	g := buildTestGraph()
+
+	degrees := g.Degrees()
+	if got, want := degrees["b.md"], (Degree{In: 2, Out: 1}); got != want {
+		t.Errorf("Degrees()[b.md] = %+v, want %+v", got, want)
+	}
+	if got, want := degrees["a.md"], (Degree{Out: 1, Tags: 1}); got != want {
+		t.Errorf("Degrees()[a.md] = %+v, want %+v", got, want)
+	}
+	if _, ok := degrees["#project"]; ok {
+		t.Error("Degrees() should not include tag nodes")
+	}
+}
+
+func TestGraph_PageRank(t *testing.T) {
+	g := buildTestGraph()
+
+	ranks := g.PageRank(0.85, 100)
+	if len(ranks) != 6 {
+		t.Fatalf("PageRank() returned %d ranks, want 6", len(ranks))
+	}
+
+	sum := 0.0
+	for _, r := range ranks {
+		sum += r
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("PageRank() sum = %f, want 1", sum)
+	}
+
+	if ranks["b.md"] <= ranks["a.md"] || ranks["b.md"] <= ranks["e.md"] {
+		t.Errorf("PageRank() b.md = %f should outrank its sources a.md = %f, e.md = %f", ranks["b.md"], ranks["a.md"], ranks["e.md"])
+	}
+	if ranks["d.md"] <= ranks["f.md"] {
+		t.Errorf("PageRank() d.md = %f should outrank isolated f.md = %f", ranks["d.md"], ranks["f.md"])
+	}
+}
+
+func TestGraph_IgnoresDuplicatesAndSelfLinks(t *testing.T) {
+	g := New()
+	g.AddLink("a.md", "b.md")
+	g.AddLink("a.md", "b.md")
+	g.AddLink("a.md", "a.md")
+	g.AddTag("a.md", "x")
+	g.AddTag("a.md", "x")
+
+	want := []Edge{
+		{From: "a.md", To: "b.md", Kind: EdgeLink},
+		{From: "a.md", To: "#x", Kind: EdgeTag},
+	}
+	if got := g.Edges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Edges() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,222 @@
+// Package journal records every change made to a vault in an append-only
+// log, so that operations can be listed and reverted later.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/taigrr/obsidian-mcp/internal/types"
+)
+
+// Dir is the vault folder the journal lives in. It is hidden from Obsidian
+// and from the tools, like .obsidian.
+const Dir = ".obsidian-mcp"
+
+// fileName is the journal file inside Dir.
+const fileName = "journal.jsonl"
+
+// DefaultMaxSize is the size past which the oldest entries are dropped
+// from the journal.
+const DefaultMaxSize = 32 << 20
+
+// Entry is one operation: the changes a single commit made to the vault
+// and the tool call that caused them.
+type Entry struct {
+	ID      int64              `json:"id"`
+	Time    time.Time          `json:"time"`
+	Tool    string             `json:"tool"`
+	Args    json.RawMessage    `json:"args,omitempty"`
+	Undoes  []int64            `json:"undoes,omitempty"` // operations reverted by this one
+	Changes []types.NoteChange `json:"changes"`
+	Notes   []Note             `json:"notes"`
+}
+
+// Note is the content of a note before and after an operation. A nil
+// content means the note did not exist.
+type Note struct {
+	Path   string  `json:"path"`
+	Before *string `json:"before,omitempty"`
+	After  *string `json:"after,omitempty"`
+}
+
+// Journal is an append-only log of entries stored as JSON lines. Once
+// the file grows past its size limit, the oldest entries are dropped, so
+// only recent operations can be listed and undone.
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	lastID  int64
+	loaded  bool
+}
+
+// New returns the journal of the vault at vaultPath, limited to
+// DefaultMaxSize. The file is only created when the first entry is
+// appended.
+func New(vaultPath string) *Journal {
+	return &Journal{path: filepath.Join(vaultPath, Dir, fileName), maxSize: DefaultMaxSize}
+}
+
+// Path returns the location of the journal file.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Append assigns the next ID to entry, stamps it and appends it to the
+// journal. It returns the stored entry.
+func (j *Journal) Append(entry Entry) (Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.loaded {
+		entries, err := j.read()
+		if err != nil {
+			return Entry{}, err
+		}
+		if len(entries) > 0 {
+			j.lastID = entries[len(entries)-1].ID
+		}
+		j.loaded = true
+	}
+
+	entry.ID = j.lastID + 1
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return Entry{}, err
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return Entry{}, err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	j.lastID = entry.ID
+	if info, err := os.Stat(j.path); err == nil && info.Size() > j.maxSize {
+		if err := j.trim(); err != nil {
+			return entry, fmt.Errorf("failed to trim journal: %w", err)
+		}
+	}
+	return entry, nil
+}
+
+// trim drops the oldest entries so that the journal takes at most half of
+// its size limit, leaving room for new entries before the next trim. The
+// newest entry is always kept.
+func (j *Journal) trim() error {
+	entries, err := j.read()
+	if err != nil {
+		return err
+	}
+
+	var lines [][]byte
+	var size int64
+	for _, entry := range slices.Backward(entries) {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		size += int64(len(line)) + 1
+		if size > j.maxSize/2 && len(lines) > 0 {
+			break
+		}
+		lines = append(lines, append(line, '\n'))
+	}
+	slices.Reverse(lines)
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, bytes.Join(lines, nil), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// Entries returns every entry in the journal, oldest first.
+func (j *Journal) Entries() ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.read()
+}
+
+// read decodes the journal. A torn last line, left by a crash during an
+// append, is ignored.
+func (j *Journal) read() ([]Entry, error) {
+	f, err := os.Open(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var entry Entry
+			if jsonErr := json.Unmarshal(line, &entry); jsonErr != nil {
+				if err == io.EOF {
+					return entries, nil
+				}
+				return nil, fmt.Errorf("failed to read journal: entry %d: %w", len(entries)+1, jsonErr)
+			}
+			entries = append(entries, entry)
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+	}
+}
+
+// Find returns the entry with the given ID.
+func Find(entries []Entry, id int64) (Entry, bool) {
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// UndoneBy maps the IDs of reverted entries to the entry that reverted
+// them.
+func UndoneBy(entries []Entry) map[int64]int64 {
+	undone := make(map[int64]int64)
+	for _, entry := range entries {
+		for _, id := range entry.Undoes {
+			undone[id] = entry.ID
+		}
+	}
+	return undone
+}
@@ -0,0 +1,101 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	vault := t.TempDir()
+	j := New(vault)
+
+	entries, err := j.Entries()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Entries() = %v, %v, want none", entries, err)
+	}
+
+	after := "# Note\n"
+	for i := range 3 {
+		entry, err := j.Append(Entry{Tool: "write", Notes: []Note{{Path: "note.md", After: &after}}})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		if entry.ID != int64(i+1) || entry.Time.IsZero() {
+			t.Errorf("Append() = %+v, want ID %d with a time", entry, i+1)
+		}
+	}
+
+	// A new journal on the same file continues the IDs.
+	entry, err := New(vault).Append(Entry{Tool: "undo", Undoes: []int64{2, 3}})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if entry.ID != 4 {
+		t.Errorf("Append() ID = %d, want 4", entry.ID)
+	}
+
+	entries, err = j.Entries()
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 4 || *entries[0].Notes[0].After != after || entries[0].Notes[0].Before != nil {
+		t.Fatalf("Entries() = %+v", entries)
+	}
+	if got, want := UndoneBy(entries), map[int64]int64{2: 4, 3: 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UndoneBy() = %v, want %v", got, want)
+	}
+	if found, ok := Find(entries, 3); !ok || found.ID != 3 {
+		t.Errorf("Find(3) = %+v, %v", found, ok)
+	}
+}
+
+func TestJournalIgnoresTornLastLine(t *testing.T) {
+	j := New(t.TempDir())
+	if _, err := j.Append(Entry{Tool: "write"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	f, err := os.OpenFile(j.Path(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":2,"tool":"wri`)
+	f.Close()
+
+	entries, err := j.Entries()
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Entries() = %+v, %v, want the complete entry", entries, err)
+	}
+}
+
+func TestJournalTrimsOldEntries(t *testing.T) {
+	j := New(t.TempDir())
+	j.maxSize = 1024
+
+	content := strings.Repeat("x", 100)
+	for range 20 {
+		if _, err := j.Append(Entry{Tool: "write", Notes: []Note{{Path: "note.md", After: &content}}}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	info, err := os.Stat(j.Path())
+	if err != nil || info.Size() > j.maxSize {
+		t.Fatalf("journal size = %v, %v, want at most %d", info.Size(), err, j.maxSize)
+	}
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) == 0 || len(entries) == 20 || entries[len(entries)-1].ID != 20 {
+		t.Fatalf("Entries() kept %d entries, last %+v, want the newest ones", len(entries), entries[len(entries)-1])
+	}
+
+	// IDs continue after a trim.
+	if entry, err := New(filepath.Dir(filepath.Dir(j.Path()))).Append(Entry{Tool: "write"}); err != nil || entry.ID != 21 {
+		t.Errorf("Append() after a trim = %+v, %v, want ID 21", entry, err)
+	}
+}
@@ -0,0 +1,182 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/taigrr/obsidian-mcp/internal/tokenizer"
+)
+
+// InlineField is a Dataview inline field: a "key:: value" line, or a
+// "[key:: value]" or "(key:: value)" span within a line. Line is the
+// zero-based line index and Value the raw text of the value. Start and
+// End are the byte offsets of the field, brackets included, within the
+// content it was found in.
+type InlineField struct {
+	Key   string
+	Value string
+	Line  int
+	Start int
+	End   int
+}
+
+var (
+	// lineFieldPattern matches a whole line holding a field, optionally in
+	// a quote, list item or task, and with the key in bold or italics.
+	lineFieldPattern = regexp.MustCompile(`^[ \t]*(?:>[ \t]*)*(?:(?:[-*+]|\d+[.)])[ \t]+(?:\[.\][ \t]+)?)?([*_]{0,2}([\p{L}\p{N}_][\p{L}\p{N}_ /-]*?)[*_]{0,2})::(.*)$`)
+	spanFieldPattern = regexp.MustCompile(`[\[(]([\p{L}\p{N}_][\p{L}\p{N}_ /-]*?)::`)
+)
+
+// InlineFields returns the inline fields in content, in order. Fields
+// inside code, math and comments are ignored.
+func InlineFields(content string) []InlineField {
+	masked := tokenizer.Mask(content)
+	if !strings.Contains(masked, "::") {
+		return nil
+	}
+
+	var fields []InlineField
+	lines, maskedLines := Lines(content), Lines(masked)
+	offset := 0
+	for i, line := range maskedLines {
+		lineStart := offset
+		offset += len(line) + 1
+		if !strings.Contains(line, "::") {
+			continue
+		}
+		spans := spanFields(lines[i], line, i)
+		if m := lineFieldPattern.FindStringSubmatchIndex(line); m != nil && !insideSpan(m[2], spans) {
+			fields = append(fields, InlineField{
+				Key:   strings.TrimSpace(lines[i][m[4]:m[5]]),
+				Value: strings.TrimSpace(lines[i][m[6]:m[7]]),
+				Line:  i,
+				Start: lineStart + m[2],
+				End:   lineStart + len(strings.TrimRight(line, " \t")),
+			})
+		}
+		for _, span := range spans {
+			span.Start += lineStart
+			span.End += lineStart
+			fields = append(fields, span)
+		}
+	}
+	return fields
+}
+
+// spanFields returns the bracketed fields of a line, with offsets within
+// the line. The value runs to the matching closing bracket, so it may hold
+// links such as [[Note]].
+func spanFields(line, masked string, index int) []InlineField {
+	var spans []InlineField
+	offset := 0
+	for {
+		m := spanFieldPattern.FindStringSubmatchIndex(masked[offset:])
+		if m == nil {
+			return spans
+		}
+		start, valueStart := offset+m[0], offset+m[1]
+		open := masked[start]
+		closing := byte(']')
+		if open == '(' {
+			closing = ')'
+		}
+
+		depth, end := 0, -1
+		for j := valueStart; j < len(masked) && end == -1; j++ {
+			switch masked[j] {
+			case open:
+				depth++
+			case closing:
+				if depth == 0 {
+					end = j
+				}
+				depth--
+			}
+		}
+		if end == -1 {
+			return spans
+		}
+		spans = append(spans, InlineField{
+			Key:   strings.TrimSpace(line[offset+m[2] : offset+m[3]]),
+			Value: strings.TrimSpace(line[valueStart:end]),
+			Line:  index,
+			Start: start,
+			End:   end + 1,
+		})
+		offset = end + 1
+	}
+}
+
+func insideSpan(pos int, spans []InlineField) bool {
+	for _, span := range spans {
+		if pos >= span.Start && pos < span.End {
+			return true
+		}
+	}
+	return false
+}
+
+// InlineFieldMap returns the inline fields of content by key, with their
+// values parsed by FieldValue. A key given more than once holds a list of
+// its values. It returns nil if content has no inline fields.
+func InlineFieldMap(content string) map[string]any {
+	fields := InlineFields(content)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	result := make(map[string]any, len(fields))
+	for _, field := range fields {
+		value := FieldValue(field.Value)
+		existing, ok := result[field.Key]
+		if !ok {
+			result[field.Key] = value
+			continue
+		}
+		if list, ok := existing.(multiValue); ok {
+			result[field.Key] = append(list, value)
+		} else {
+			result[field.Key] = multiValue{existing, value}
+		}
+	}
+	for key, value := range result {
+		if list, ok := value.(multiValue); ok {
+			result[key] = []any(list)
+		}
+	}
+	return result
+}
+
+// multiValue collects the values of a repeated key, so that they are not
+// confused with a single value that is already a list.
+type multiValue []any
+
+// FieldValue parses the raw value of an inline field the way Dataview
+// does for the simple types: empty values are null, numbers and booleans
+// are parsed, quotes around a string are removed, and anything else,
+// including dates and links, stays text.
+func FieldValue(raw string) any {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	if n, err := strconv.Atoi(raw); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil && !strings.ContainsAny(raw, "xXnN") {
+		return f
+	}
+	switch strings.ToLower(raw) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		if s, err := strconv.Unquote(raw); err == nil {
+			return s
+		}
+	}
+	return raw
+}
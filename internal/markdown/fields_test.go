@@ -0,0 +1,60 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInlineFields(t *testing.T) {
+	content := strings.Join([]string{
+		"status:: active",
+		"**Due Date**:: 2024-05-01",
+		"- [ ] Call Bob [priority:: high] (owner:: [[People/Bob]])",
+		"> rating:: 4",
+		"Some text:",
+		"`code:: ignored`",
+		"```",
+		"fenced:: ignored",
+		"```",
+		"empty::",
+	}, "\n")
+
+	want := []InlineField{
+		{Key: "status", Value: "active", Line: 0},
+		{Key: "Due Date", Value: "2024-05-01", Line: 1},
+		{Key: "priority", Value: "high", Line: 2},
+		{Key: "owner", Value: "[[People/Bob]]", Line: 2},
+		{Key: "rating", Value: "4", Line: 3},
+		{Key: "empty", Value: "", Line: 9},
+	}
+	got := InlineFields(content)
+	for i := range got {
+		if field := content[got[i].Start:got[i].End]; !strings.Contains(field, got[i].Key) || !strings.Contains(field, "::") {
+			t.Errorf("InlineFields() offsets %d:%d = %q, want field %s", got[i].Start, got[i].End, content[got[i].Start:got[i].End], got[i].Key)
+		}
+		got[i].Start, got[i].End = 0, 0
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InlineFields() = %+v, want %+v", got, want)
+	}
+}
+
+func TestInlineFieldMap(t *testing.T) {
+	content := "rating:: 4\ndone:: true\nlink:: [[Note]]\n[author:: Ann] and [author:: Bob]\nscore:: 1.5\nquoted:: \"42\"\n"
+
+	want := map[string]any{
+		"rating": 4,
+		"done":   true,
+		"link":   "[[Note]]",
+		"author": []any{"Ann", "Bob"},
+		"score":  1.5,
+		"quoted": "42",
+	}
+	if got := InlineFieldMap(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("InlineFieldMap() = %v, want %v", got, want)
+	}
+	if got := InlineFieldMap("no fields here"); got != nil {
+		t.Errorf("InlineFieldMap() = %v, want nil", got)
+	}
+}
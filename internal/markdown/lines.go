@@ -0,0 +1,86 @@
+package markdown
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// LineOp is an edit operation on a range of lines.
+type LineOp string
+
+// Line edit operations. Insert adds the new lines after the checked range.
+const (
+	LineReplace LineOp = "replace"
+	LineInsert  LineOp = "insert"
+	LineDelete  LineOp = "delete"
+)
+
+// HashLines returns a short hash of lines, used to check that a range has
+// not changed since it was read.
+func HashLines(lines []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:6])
+}
+
+// NumberLines prefixes each line with its 1-based line number, starting at
+// first, right-aligned and separated from the text by a tab.
+func NumberLines(lines []string, first int) string {
+	width := len(fmt.Sprint(first + len(lines) - 1))
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%*d\t%s", width, first+i, line)
+	}
+	return b.String()
+}
+
+// EditLines applies op to content after checking that lines start through
+// end (1-based, inclusive) still hash to hash. An empty range, with end one
+// less than start, hashes like no lines and lets insert add lines before
+// start. It returns the updated content and the range now occupied by the
+// new lines.
+func EditLines(content string, op LineOp, start, end int, hash, text string) (string, Range, error) {
+	lines := Lines(content)
+	if start < 1 || end < start-1 || end > len(lines) {
+		return "", Range{}, fmt.Errorf("invalid line range %d-%d: note has %d lines", start, end, len(lines))
+	}
+
+	current := lines[start-1 : end]
+	if got := HashLines(current); got != hash {
+		return "", Range{}, fmt.Errorf("hash mismatch for lines %d-%d: got %s, want %s; the note changed since it was read", start, end, got, hash)
+	}
+
+	var newLines []string
+	if text != "" {
+		newLines = Lines(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"))
+	}
+
+	var from, to int
+	switch op {
+	case LineReplace:
+		if len(current) == 0 {
+			return "", Range{}, fmt.Errorf("replace requires a non-empty line range")
+		}
+		from, to = start-1, end
+	case LineInsert:
+		if len(newLines) == 0 {
+			return "", Range{}, fmt.Errorf("insert requires content")
+		}
+		from, to = end, end
+	case LineDelete:
+		if len(current) == 0 {
+			return "", Range{}, fmt.Errorf("delete requires a non-empty line range")
+		}
+		from, to, newLines = start-1, end, nil
+	default:
+		return "", Range{}, fmt.Errorf("unknown line operation: %s", op)
+	}
+
+	result := make([]string, 0, len(lines)-(to-from)+len(newLines))
+	result = append(append(append(result, lines[:from]...), newLines...), lines[to:]...)
+	return strings.Join(result, "\n"), Range{Start: from, End: from + len(newLines)}, nil
+}
@@ -0,0 +1,82 @@
+package markdown
+
+import "testing"
+
+func TestNumberLines(t *testing.T) {
+	got := NumberLines([]string{"a", "b", "c"}, 9)
+	want := " 9\ta\n10\tb\n11\tc"
+	if got != want {
+		t.Errorf("NumberLines() = %q, want %q", got, want)
+	}
+}
+
+func TestEditLines(t *testing.T) {
+	content := "one\ntwo\nthree\nfour"
+	hash := func(lines ...string) string { return HashLines(lines) }
+
+	tests := []struct {
+		name       string
+		op         LineOp
+		start, end int
+		hash       string
+		text       string
+		want       string
+		wantRange  Range
+	}{
+		{
+			name: "replace", op: LineReplace, start: 2, end: 3, hash: hash("two", "three"),
+			text: "TWO\nTHREE\nTHREE AND A HALF\n",
+			want: "one\nTWO\nTHREE\nTHREE AND A HALF\nfour", wantRange: Range{Start: 1, End: 4},
+		},
+		{
+			name: "insert after range", op: LineInsert, start: 4, end: 4, hash: hash("four"),
+			text: "five",
+			want: "one\ntwo\nthree\nfour\nfive", wantRange: Range{Start: 4, End: 5},
+		},
+		{
+			name: "insert at top", op: LineInsert, start: 1, end: 0, hash: hash(),
+			text: "zero",
+			want: "zero\none\ntwo\nthree\nfour", wantRange: Range{Start: 0, End: 1},
+		},
+		{
+			name: "delete", op: LineDelete, start: 1, end: 2, hash: hash("one", "two"),
+			want: "three\nfour", wantRange: Range{Start: 0, End: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, r, err := EditLines(content, tt.op, tt.start, tt.end, tt.hash, tt.text)
+			if err != nil {
+				t.Fatalf("EditLines() error = %v", err)
+			}
+			if got != tt.want || r != tt.wantRange {
+				t.Errorf("EditLines() = %q, %+v, want %q, %+v", got, r, tt.want, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestEditLinesErrors(t *testing.T) {
+	content := "one\ntwo"
+
+	tests := []struct {
+		name       string
+		op         LineOp
+		start, end int
+		hash       string
+		text       string
+	}{
+		{name: "stale hash", op: LineReplace, start: 1, end: 1, hash: HashLines([]string{"ONE"}), text: "x"},
+		{name: "out of range", op: LineDelete, start: 2, end: 3, hash: HashLines([]string{"two"})},
+		{name: "empty replace", op: LineReplace, start: 2, end: 1, hash: HashLines(nil), text: "x"},
+		{name: "insert without content", op: LineInsert, start: 1, end: 1, hash: HashLines([]string{"one"})},
+		{name: "unknown op", op: "move", start: 1, end: 1, hash: HashLines([]string{"one"})},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := EditLines(content, tt.op, tt.start, tt.end, tt.hash, tt.text); err == nil {
+			t.Errorf("EditLines(%s) error = nil, want error", tt.name)
+		}
+	}
+}
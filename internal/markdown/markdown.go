@@ -0,0 +1,247 @@
+// Package markdown provides structural helpers for Obsidian-flavored
+// markdown: headings, heading sections and block references.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+type (
+	// Heading is an ATX heading. Line is the zero-based line index.
+	Heading struct {
+		Level int
+		Text  string
+		Line  int
+	}
+
+	// Range is a half-open range of zero-based line indexes.
+	Range struct {
+		Start int
+		End   int
+	}
+)
+
+var (
+	headingPattern  = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?[ \t]*$`)
+	closingHashes   = regexp.MustCompile(`[ \t]+#+$`)
+	blockIDPattern  = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)[ \t]*$`)
+	listItemPattern = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d+[.)])[ \t]`)
+	fencePattern    = regexp.MustCompile("^[ \t]{0,3}(`{3,}|~{3,})")
+)
+
+// Lines splits content into lines the same way the read tool does.
+func Lines(content string) []string {
+	return strings.Split(content, "\n")
+}
+
+// codeLines reports, for every line, whether it is part of a fenced code
+// block (including the fences themselves).
+func codeLines(lines []string) []bool {
+	inCode := make([]bool, len(lines))
+	var fence string
+	for i, line := range lines {
+		m := fencePattern.FindStringSubmatch(line)
+		switch {
+		case fence == "" && m != nil:
+			fence = m[1]
+			inCode[i] = true
+		case fence != "":
+			inCode[i] = true
+			if m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) &&
+				strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), string(fence[0]))) == "" {
+				fence = ""
+			}
+		}
+	}
+	return inCode
+}
+
+// Headings returns every heading in content, ignoring lines inside fenced
+// code blocks.
+func Headings(content string) []Heading {
+	lines := Lines(content)
+	inCode := codeLines(lines)
+
+	var headings []Heading
+	for i, line := range lines {
+		if inCode[i] {
+			continue
+		}
+		m := headingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		text := closingHashes.ReplaceAllString(m[2], "")
+		headings = append(headings, Heading{
+			Level: len(m[1]),
+			Text:  strings.TrimSpace(text),
+			Line:  i,
+		})
+	}
+	return headings
+}
+
+// NormalizeHeading normalizes heading text for comparison with the heading
+// part of a wiki-link. Obsidian drops characters that cannot appear in
+// links and matches case-insensitively.
+func NormalizeHeading(text string) string {
+	text = strings.NewReplacer("[[", "", "]]", "", "#", "", "|", "", "^", "", ":", "", "%%", "").Replace(text)
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// SplitHeadingPath splits a link anchor such as "Projects#Alpha" or a
+// heading path such as "Projects > Alpha" into its components.
+func SplitHeadingPath(path string) []string {
+	sep := "#"
+	if strings.Contains(path, ">") {
+		sep = ">"
+	}
+	var parts []string
+	for part := range strings.SplitSeq(path, sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// FindHeading finds the heading addressed by path. Each component must be
+// nested (at any depth) below the previous one; the last component is the
+// heading returned. As in Obsidian, the first heading component does not
+// have to be top-level.
+func FindHeading(content string, path []string) (Heading, bool) {
+	if len(path) == 0 {
+		return Heading{}, false
+	}
+	return findHeading(Headings(content), path)
+}
+
+func findHeading(headings []Heading, path []string) (Heading, bool) {
+	if len(path) == 0 {
+		return Heading{}, false
+	}
+
+	// searchEnd bounds the search to the section of the previously
+	// matched component.
+	start, searchEnd := 0, len(headings)
+	var found Heading
+	for depth, part := range path {
+		want := NormalizeHeading(part)
+		matched := false
+		for i := start; i < searchEnd; i++ {
+			h := headings[i]
+			if depth > 0 && h.Level <= found.Level {
+				continue
+			}
+			if NormalizeHeading(h.Text) != want {
+				continue
+			}
+			found = h
+			matched = true
+			start = i + 1
+			searchEnd = len(headings)
+			for j := i + 1; j < len(headings); j++ {
+				if headings[j].Level <= h.Level {
+					searchEnd = j
+					break
+				}
+			}
+			break
+		}
+		if !matched {
+			return Heading{}, false
+		}
+	}
+	return found, true
+}
+
+// Section returns the lines covered by the heading addressed by path: the
+// heading line itself through the line before the next heading of the same
+// or a higher level.
+func Section(content string, path []string) (Heading, Range, bool) {
+	lines := Lines(content)
+	headings := Headings(content)
+	h, ok := findHeading(headings, path)
+	if !ok {
+		return Heading{}, Range{}, false
+	}
+	return h, Range{Start: h.Line, End: sectionEnd(headings, h, len(lines))}, true
+}
+
+func sectionEnd(headings []Heading, h Heading, totalLines int) int {
+	for _, other := range headings {
+		if other.Line > h.Line && other.Level <= h.Level {
+			return other.Line
+		}
+	}
+	return totalLines
+}
+
+// BlockIDs returns every block ID defined in content, in order.
+func BlockIDs(content string) []string {
+	lines := Lines(content)
+	inCode := codeLines(lines)
+
+	var ids []string
+	for i, line := range lines {
+		if inCode[i] {
+			continue
+		}
+		if m := blockIDPattern.FindStringSubmatch(line); m != nil {
+			ids = append(ids, m[1])
+		}
+	}
+	return ids
+}
+
+// Block returns the lines making up the block identified by id (without
+// the leading "^"). A block ID at the end of a list item identifies that
+// item; at the end of a paragraph it identifies the whole paragraph; on a
+// line of its own it identifies the preceding block, such as a list, table
+// or quote, and the returned range excludes the ID line.
+func Block(content, id string) (Range, bool) {
+	id = strings.TrimPrefix(strings.TrimSpace(id), "^")
+	lines := Lines(content)
+	inCode := codeLines(lines)
+
+	for i, line := range lines {
+		if inCode[i] {
+			continue
+		}
+		m := blockIDPattern.FindStringSubmatch(line)
+		if m == nil || !strings.EqualFold(m[1], id) {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "^"+m[1]:
+			end := i
+			for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+				end--
+			}
+			start := end
+			for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+				start--
+			}
+			if start == end {
+				return Range{Start: i, End: i + 1}, true
+			}
+			return Range{Start: start, End: end}, true
+		case listItemPattern.MatchString(line):
+			return Range{Start: i, End: i + 1}, true
+		default:
+			start := i
+			for start > 0 {
+				prev := lines[start-1]
+				if strings.TrimSpace(prev) == "" || headingPattern.MatchString(prev) || inCode[start-1] {
+					break
+				}
+				start--
+			}
+			return Range{Start: start, End: i + 1}, true
+		}
+	}
+	return Range{}, false
+}
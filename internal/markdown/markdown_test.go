@@ -0,0 +1,179 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleNote = `# Projects
+
+Intro paragraph.
+
+## Alpha
+
+Alpha overview. ^alpha-overview
+
+### Status
+
+On track.
+
+## Beta
+
+- first item
+- second item ^second
+- third item
+
+| a | b |
+| - | - |
+| 1 | 2 |
+
+^table
+
+` + "```" + `
+# not a heading ^not-a-block
+` + "```" + `
+
+# Archive ##
+`
+
+func TestHeadings(t *testing.T) {
+	got := Headings(sampleNote)
+	want := []Heading{
+		{Level: 1, Text: "Projects", Line: 0},
+		{Level: 2, Text: "Alpha", Line: 4},
+		{Level: 3, Text: "Status", Line: 8},
+		{Level: 2, Text: "Beta", Line: 12},
+		{Level: 1, Text: "Archive", Line: 28},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Headings() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHeadingsIgnoresTags(t *testing.T) {
+	if got := Headings("#tag at line start\n#\n####### too deep"); len(got) != 1 || got[0].Text != "" {
+		t.Errorf("Headings() = %+v, want only the empty heading", got)
+	}
+}
+
+func TestFindHeading(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     []string
+		wantLine int
+		wantOK   bool
+	}{
+		{name: "top level", path: []string{"Projects"}, wantLine: 0, wantOK: true},
+		{name: "nested without parent", path: []string{"status"}, wantLine: 8, wantOK: true},
+		{name: "full path", path: []string{"Projects", "Alpha", "Status"}, wantLine: 8, wantOK: true},
+		{name: "skipping levels", path: []string{"Projects", "Status"}, wantLine: 8, wantOK: true},
+		{name: "wrong parent", path: []string{"Beta", "Status"}, wantOK: false},
+		{name: "closing hashes stripped", path: []string{"Archive"}, wantLine: 28, wantOK: true},
+		{name: "missing", path: []string{"Gamma"}, wantOK: false},
+		{name: "empty path", path: nil, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FindHeading(sampleNote, tt.path)
+			if ok != tt.wantOK {
+				t.Fatalf("FindHeading(%v) ok = %v, want %v", tt.path, ok, tt.wantOK)
+			}
+			if ok && got.Line != tt.wantLine {
+				t.Errorf("FindHeading(%v).Line = %d, want %d", tt.path, got.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestSection(t *testing.T) {
+	tests := []struct {
+		path []string
+		want Range
+	}{
+		{path: []string{"Alpha"}, want: Range{Start: 4, End: 12}},
+		{path: []string{"Alpha", "Status"}, want: Range{Start: 8, End: 12}},
+		{path: []string{"Projects"}, want: Range{Start: 0, End: 28}},
+		{path: []string{"Archive"}, want: Range{Start: 28, End: 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.path, ">"), func(t *testing.T) {
+			_, got, ok := Section(sampleNote, tt.path)
+			if !ok {
+				t.Fatalf("Section(%v) not found", tt.path)
+			}
+			if got != tt.want {
+				t.Errorf("Section(%v) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitHeadingPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "Projects#Alpha", want: []string{"Projects", "Alpha"}},
+		{path: "Projects > Alpha > Status", want: []string{"Projects", "Alpha", "Status"}},
+		{path: "#Alpha", want: []string{"Alpha"}},
+		{path: "C# tips > Basics", want: []string{"C# tips", "Basics"}},
+		{path: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := SplitHeadingPath(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitHeadingPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeHeading(t *testing.T) {
+	if got, want := NormalizeHeading("  Step 1:  Plan [[Link]] "), "step 1 plan link"; got != want {
+		t.Errorf("NormalizeHeading() = %q, want %q", got, want)
+	}
+}
+
+func TestBlockIDs(t *testing.T) {
+	got := BlockIDs(sampleNote)
+	want := []string{"alpha-overview", "second", "table"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BlockIDs() = %v, want %v", got, want)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	tests := []struct {
+		id     string
+		want   Range
+		wantOK bool
+	}{
+		{id: "alpha-overview", want: Range{Start: 6, End: 7}, wantOK: true},
+		{id: "^second", want: Range{Start: 15, End: 16}, wantOK: true},
+		{id: "table", want: Range{Start: 18, End: 21}, wantOK: true},
+		{id: "not-a-block", wantOK: false},
+		{id: "missing", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, ok := Block(sampleNote, tt.id)
+			if ok != tt.wantOK {
+				t.Fatalf("Block(%q) ok = %v, want %v", tt.id, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("Block(%q) = %+v, want %+v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockParagraph(t *testing.T) {
+	content := "## Heading\nfirst line\nsecond line ^para\n\nafter"
+	got, ok := Block(content, "para")
+	if !ok || got != (Range{Start: 1, End: 3}) {
+		t.Errorf("Block() = %+v, %v, want {1 3}, true", got, ok)
+	}
+}
@@ -0,0 +1,174 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SectionOp is an edit operation on a heading section.
+type SectionOp string
+
+// Section edit operations. Replace, append and prepend act on the section's
+// own content, between the heading and its first subheading, so nested
+// subsections are kept. Insert adds a new subsection at the end of the
+// section, and delete removes the heading together with its subsections.
+const (
+	SectionReplace SectionOp = "replace"
+	SectionAppend  SectionOp = "append"
+	SectionPrepend SectionOp = "prepend"
+	SectionInsert  SectionOp = "insert"
+	SectionDelete  SectionOp = "delete"
+)
+
+// SectionEdit describes an edit to a heading section. Heading is the text
+// of the new subsection for SectionInsert. Separator, if set, joins Text to
+// the section's content for SectionAppend and SectionPrepend in place of
+// the usual blank line.
+type SectionEdit struct {
+	Op        SectionOp
+	Text      string
+	Heading   string
+	Separator string
+}
+
+// EditSection applies edit to the section addressed by path and returns
+// the updated content along with the heading that was edited.
+//
+// The edited section is normalized: one blank line separates the heading
+// from its content and the section from the heading that follows, and
+// blank lines around the new text are dropped. Consecutive list items or
+// table rows are joined without a blank line; other blocks are separated
+// by one.
+func EditSection(content string, path []string, edit SectionEdit) (string, Heading, error) {
+	lines := Lines(content)
+	headings := Headings(content)
+	h, ok := findHeading(headings, path)
+	if !ok {
+		return "", Heading{}, fmt.Errorf("heading not found: %s", strings.Join(path, " > "))
+	}
+	end := sectionEnd(headings, h, len(lines))
+
+	childStart := end
+	for _, other := range headings {
+		if other.Line > h.Line && other.Line < end {
+			childStart = other.Line
+			break
+		}
+	}
+	own := trimBlankLines(lines[h.Line+1 : childStart])
+	children := trimBlankLines(lines[childStart:end])
+	text := trimBlankLines(Lines(strings.ReplaceAll(edit.Text, "\r\n", "\n")))
+
+	switch edit.Op {
+	case SectionReplace:
+		own = text
+	case SectionAppend:
+		own = joinBlocks(own, text, edit.Separator)
+	case SectionPrepend:
+		own = joinBlocks(text, own, edit.Separator)
+	case SectionInsert:
+		title := strings.TrimSpace(edit.Heading)
+		if title == "" {
+			return "", Heading{}, fmt.Errorf("heading is required to insert a subsection")
+		}
+		sub := []string{strings.Repeat("#", min(h.Level+1, 6)) + " " + title}
+		if len(text) > 0 {
+			sub = append(append(sub, ""), text...)
+		}
+		children = joinSections(children, sub)
+	case SectionDelete:
+	default:
+		return "", Heading{}, fmt.Errorf("unknown section operation: %s", edit.Op)
+	}
+
+	var section []string
+	if edit.Op != SectionDelete {
+		section = []string{lines[h.Line]}
+		if len(own) > 0 {
+			section = append(append(section, ""), own...)
+		}
+		if len(children) > 0 {
+			section = append(append(section, ""), children...)
+		}
+	}
+
+	before := lines[:h.Line]
+	after := lines[end:]
+	if edit.Op == SectionDelete {
+		// Collapse the blank lines left on either side of the removed
+		// section into a single one.
+		before = trimTrailingBlankLines(before)
+		if len(before) > 0 && len(trimBlankLines(after)) > 0 {
+			section = []string{""}
+		}
+		after = trimLeadingBlankLines(after)
+	} else if len(after) > 0 {
+		section = append(section, "")
+	}
+
+	result := make([]string, 0, len(before)+len(section)+len(after)+1)
+	result = append(append(append(result, before...), section...), after...)
+	if end == len(lines) && strings.HasSuffix(content, "\n") {
+		result = append(trimTrailingBlankLines(result), "")
+	}
+	return strings.Join(result, "\n"), h, nil
+}
+
+// joinBlocks joins two runs of lines, separating them with a blank line
+// unless both sides are list items or both are table rows. A non-empty
+// separator is put between them instead, unless either side already
+// provides it.
+func joinBlocks(a, b []string, separator string) []string {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	if separator != "" {
+		first, second := strings.Join(a, "\n"), strings.Join(b, "\n")
+		if !strings.HasSuffix(first, separator) && !strings.HasPrefix(second, separator) {
+			first += separator
+		}
+		return Lines(first + second)
+	}
+	last, first := a[len(a)-1], b[0]
+	joined := append([]string{}, a...)
+	if !(listItemPattern.MatchString(last) && listItemPattern.MatchString(first)) &&
+		!(isTableRow(last) && isTableRow(first)) {
+		joined = append(joined, "")
+	}
+	return append(joined, b...)
+}
+
+// joinSections joins two runs of section lines with a blank line.
+func joinSections(a, b []string) []string {
+	if len(a) == 0 {
+		return b
+	}
+	return append(append(append([]string{}, a...), ""), b...)
+}
+
+func isTableRow(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+func trimBlankLines(lines []string) []string {
+	return trimTrailingBlankLines(trimLeadingBlankLines(lines))
+}
+
+func trimLeadingBlankLines(lines []string) []string {
+	start := 0
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	return lines[start:]
+}
+
+func trimTrailingBlankLines(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end]
+}
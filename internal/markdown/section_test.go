@@ -0,0 +1,104 @@
+package markdown
+
+import "testing"
+
+const sectionNote = `# Projects
+
+## Alpha
+Alpha overview.
+
+
+### Status
+
+On track.
+
+## Beta
+
+- one
+`
+
+func TestEditSection(t *testing.T) {
+	tests := []struct {
+		name string
+		path []string
+		edit SectionEdit
+		want string
+	}{
+		{
+			name: "replace keeps subsections",
+			path: []string{"Projects", "Alpha"},
+			edit: SectionEdit{Op: SectionReplace, Text: "\nNew overview.\n\n"},
+			want: "# Projects\n\n## Alpha\n\nNew overview.\n\n### Status\n\nOn track.\n\n## Beta\n\n- one\n",
+		},
+		{
+			name: "append list item",
+			path: []string{"Beta"},
+			edit: SectionEdit{Op: SectionAppend, Text: "- two\n"},
+			want: "# Projects\n\n## Alpha\nAlpha overview.\n\n\n### Status\n\nOn track.\n\n## Beta\n\n- one\n- two\n",
+		},
+		{
+			name: "append paragraph before subsection",
+			path: []string{"Alpha"},
+			edit: SectionEdit{Op: SectionAppend, Text: "More detail."},
+			want: "# Projects\n\n## Alpha\n\nAlpha overview.\n\nMore detail.\n\n### Status\n\nOn track.\n\n## Beta\n\n- one\n",
+		},
+		{
+			name: "prepend",
+			path: []string{"Alpha", "Status"},
+			edit: SectionEdit{Op: SectionPrepend, Text: "Updated today."},
+			want: "# Projects\n\n## Alpha\nAlpha overview.\n\n\n### Status\n\nUpdated today.\n\nOn track.\n\n## Beta\n\n- one\n",
+		},
+		{
+			name: "insert subsection",
+			path: []string{"Projects > Beta"},
+			edit: SectionEdit{Op: SectionInsert, Heading: "Risks", Text: "None yet."},
+			want: "# Projects\n\n## Alpha\nAlpha overview.\n\n\n### Status\n\nOn track.\n\n## Beta\n\n- one\n\n### Risks\n\nNone yet.\n",
+		},
+		{
+			name: "delete middle section",
+			path: []string{"Alpha"},
+			edit: SectionEdit{Op: SectionDelete},
+			want: "# Projects\n\n## Beta\n\n- one\n",
+		},
+		{
+			name: "delete last section",
+			path: []string{"Beta"},
+			edit: SectionEdit{Op: SectionDelete},
+			want: "# Projects\n\n## Alpha\nAlpha overview.\n\n\n### Status\n\nOn track.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			if len(path) == 1 {
+				path = SplitHeadingPath(path[0])
+			}
+			got, _, err := EditSection(sectionNote, path, tt.edit)
+			if err != nil {
+				t.Fatalf("EditSection() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EditSection() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditSectionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path []string
+		edit SectionEdit
+	}{
+		{name: "missing heading", path: []string{"Gamma"}, edit: SectionEdit{Op: SectionAppend, Text: "x"}},
+		{name: "insert without heading", path: []string{"Beta"}, edit: SectionEdit{Op: SectionInsert}},
+		{name: "unknown operation", path: []string{"Beta"}, edit: SectionEdit{Op: "move"}},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := EditSection(sectionNote, tt.path, tt.edit); err == nil {
+			t.Errorf("EditSection(%s) error = nil, want error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/taigrr/obsidian-mcp/internal/tokenizer"
+)
+
+// InlineTag is an inline #tag. Start and End are the byte offsets of the
+// tag name, without the leading "#", within the content it was found in.
+type InlineTag struct {
+	Name  string
+	Start int
+	End   int
+}
+
+var inlineTagPattern = regexp.MustCompile(`(?:^|\s)#([a-zA-Z0-9_/-]+)`)
+
+// InlineTags returns the inline tags in content. Only prose is searched:
+// tags inside code, math, comments and URLs are ignored.
+func InlineTags(content string) []InlineTag {
+	var tags []InlineTag
+	for _, m := range inlineTagPattern.FindAllStringSubmatchIndex(tokenizer.Mask(content), -1) {
+		tags = append(tags, InlineTag{
+			Name:  content[m[2]:m[3]],
+			Start: m[2],
+			End:   m[3],
+		})
+	}
+	return tags
+}
+
+// ReplaceInlineTags calls fn for every inline tag found by InlineTags and
+// replaces the tag name with the returned name when fn reports a change.
+// It returns the updated content and the number of tags replaced.
+func ReplaceInlineTags(content string, fn func(tag string) (string, bool)) (string, int) {
+	tags := InlineTags(content)
+
+	var b strings.Builder
+	last, replaced := 0, 0
+	for _, tag := range tags {
+		newName, ok := fn(tag.Name)
+		if !ok || newName == tag.Name {
+			continue
+		}
+		b.WriteString(content[last:tag.Start])
+		b.WriteString(newName)
+		last = tag.End
+		replaced++
+	}
+	if replaced == 0 {
+		return content, 0
+	}
+	b.WriteString(content[last:])
+	return b.String(), replaced
+}
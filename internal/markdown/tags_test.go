@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInlineTags(t *testing.T) {
+	content := strings.Join([]string{
+		"#start and #proj/alpha in prose",
+		"`#inline-code` but #after-code",
+		"see https://example.com/page#anchor and [link](notes.md#heading)",
+		"```",
+		"#fenced",
+		"```",
+		"issue#12 is not a tag",
+	}, "\n")
+
+	var got []string
+	for _, tag := range InlineTags(content) {
+		got = append(got, tag.Name)
+		if content[tag.Start:tag.End] != tag.Name {
+			t.Errorf("InlineTags() offsets %d:%d = %q, want %q", tag.Start, tag.End, content[tag.Start:tag.End], tag.Name)
+		}
+	}
+	want := []string{"start", "proj/alpha", "after-code"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InlineTags() = %v, want %v", got, want)
+	}
+}
+
+func TestReplaceInlineTags(t *testing.T) {
+	content := "#proj and #proj/alpha\n`#proj` stays\n#projects stays"
+	rename := func(tag string) (string, bool) {
+		if tag == "proj" {
+			return "project", true
+		}
+		if rest, ok := strings.CutPrefix(tag, "proj/"); ok {
+			return "project/" + rest, true
+		}
+		return "", false
+	}
+
+	got, n := ReplaceInlineTags(content, rename)
+	want := "#project and #project/alpha\n`#proj` stays\n#projects stays"
+	if got != want || n != 2 {
+		t.Errorf("ReplaceInlineTags() = %q, %d, want %q, 2", got, n, want)
+	}
+}
@@ -20,6 +20,8 @@ func New(config *types.PathFilterConfig) *PathFilter {
 		ignoredPatterns: []string{
 			".obsidian/**",
 			".git/**",
+			".trash/**",
+			".obsidian-mcp/**",
 			"node_modules/**",
 			".DS_Store",
 			"Thumbs.db",
@@ -41,6 +43,13 @@ func New(config *types.PathFilterConfig) *PathFilter {
 
 // simpleGlobMatch converts a glob pattern to regex and tests against the path.
 func (pf *PathFilter) simpleGlobMatch(pattern, path string) bool {
+	return MatchGlob(pattern, path)
+}
+
+// MatchGlob reports whether path matches a glob pattern in which ** matches
+// any characters, * any characters except a slash and ? a single character
+// other than a slash.
+func MatchGlob(pattern, path string) bool {
 	// Normalize pattern path separators (Windows compatibility)
 	normalizedPattern := strings.ReplaceAll(pattern, "\\", "/")
 
@@ -0,0 +1,248 @@
+// Package properties reads the property types Obsidian keeps in
+// .obsidian/types.json and coerces frontmatter values to them.
+package properties
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taigrr/obsidian-mcp/internal/values"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the path of Obsidian's property types relative to the vault
+// root. The path filter keeps it from tools; only this package reads it.
+const File = ".obsidian/types.json"
+
+// Property types, as Obsidian names them.
+const (
+	Text     = "text"
+	List     = "multitext"
+	Number   = "number"
+	Checkbox = "checkbox"
+	Date     = "date"
+	DateTime = "datetime"
+	Aliases  = "aliases"
+	Tags     = "tags"
+)
+
+// Types maps property names to their types.
+type Types map[string]string
+
+// DateValue is a date that is written to YAML as a plain timestamp, such
+// as 2024-01-05, which is how Obsidian stores date properties.
+type DateValue string
+
+// MarshalYAML writes the date unquoted.
+func (d DateValue) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: string(d)}, nil
+}
+
+// Coerce converts the values in after that differ from before to the
+// types of their properties, and returns the result and whether anything
+// was converted. Values that are unchanged are left alone, as are
+// properties without a type. after is not modified.
+func (t Types) Coerce(before, after map[string]any) (map[string]any, bool, error) {
+	var result map[string]any
+	for key, value := range after {
+		propertyType, ok := t[key]
+		if !ok || value == nil {
+			continue
+		}
+		if previous, ok := before[key]; ok && reflect.DeepEqual(previous, value) {
+			continue
+		}
+
+		coerced, err := coerce(propertyType, value)
+		if err != nil {
+			return nil, false, fmt.Errorf("property %s is a %s property: %w", key, propertyType, err)
+		}
+		if reflect.DeepEqual(coerced, value) {
+			continue
+		}
+		if result == nil {
+			result = maps.Clone(after)
+		}
+		result[key] = coerced
+	}
+	if result == nil {
+		return after, false, nil
+	}
+	return result, true, nil
+}
+
+// coerce converts value to a property type.
+func coerce(propertyType string, value any) (any, error) {
+	switch propertyType {
+	case Text:
+		switch v := value.(type) {
+		case int, int64, uint64, float64, bool:
+			return fmt.Sprint(v), nil
+		}
+	case List, Aliases, Tags:
+		items, ok := value.([]any)
+		if !ok {
+			items = []any{value}
+		}
+		list := make([]any, 0, len(items))
+		for _, item := range items {
+			switch v := item.(type) {
+			case nil:
+				continue
+			case int, int64, uint64, float64, bool:
+				item = fmt.Sprint(v)
+			case string:
+				if propertyType == Tags {
+					item = strings.TrimPrefix(v, "#")
+				}
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case Number:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, nil
+		}
+		if n, err := strconv.Atoi(s); err == nil {
+			return n, nil
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		return n, nil
+	case Checkbox:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", s)
+		}
+		return b, nil
+	case Date:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		d, err := time.Parse(time.DateOnly, strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a date in the form YYYY-MM-DD", s)
+		}
+		return DateValue(d.Format(time.DateOnly)), nil
+	case DateTime:
+		switch v := value.(type) {
+		case time.Time:
+			return formatDateTime(v), nil
+		case string:
+			if t, ok := values.ParseDate(v); ok {
+				return formatDateTime(t), nil
+			}
+			return nil, fmt.Errorf("%q is not a date and time in the form YYYY-MM-DDTHH:MM", v)
+		}
+	}
+	return value, nil
+}
+
+// Infer returns the type Obsidian would give a property from one of its
+// values, or "" for a value without one, such as null or an object.
+func Infer(name string, value any) string {
+	switch name {
+	case "tags":
+		return Tags
+	case "aliases":
+		return Aliases
+	}
+	switch v := value.(type) {
+	case string:
+		return Text
+	case []any:
+		return List
+	case int, int64, uint64, float64:
+		return Number
+	case bool:
+		return Checkbox
+	case time.Time:
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return Date
+		}
+		return DateTime
+	}
+	return ""
+}
+
+// formatDateTime formats a datetime the way Obsidian writes it, in local
+// time without a zone and without seconds unless there are any.
+func formatDateTime(t time.Time) string {
+	if t.Second() != 0 {
+		return t.Format("2006-01-02T15:04:05")
+	}
+	return t.Format("2006-01-02T15:04")
+}
+
+// Loader loads a vault's property types, reloading them when the file
+// changes.
+type Loader struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	types   Types
+}
+
+// NewLoader returns a loader for the property types of the vault at
+// vaultPath.
+func NewLoader(vaultPath string) *Loader {
+	return &Loader{path: filepath.Join(vaultPath, filepath.FromSlash(File))}
+}
+
+// Load returns the vault's property types; a vault without the file has
+// none.
+func (l *Loader) Load() (Types, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	info, err := os.Stat(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		l.types = nil
+		return Types{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.types != nil && info.ModTime().Equal(l.modTime) && info.Size() == l.size {
+		return l.types, nil
+	}
+
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Types Types `json:"types"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", File, err)
+	}
+	if file.Types == nil {
+		file.Types = Types{}
+	}
+	l.types, l.modTime, l.size = file.Types, info.ModTime(), info.Size()
+	return l.types, nil
+}
@@ -0,0 +1,120 @@
+package properties
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypes_Coerce(t *testing.T) {
+	types := Types{
+		"title":   Text,
+		"related": List,
+		"rating":  Number,
+		"done":    Checkbox,
+		"due":     Date,
+		"start":   DateTime,
+		"tags":    Tags,
+	}
+
+	tests := []struct {
+		name    string
+		before  map[string]any
+		after   map[string]any
+		want    map[string]any
+		changed bool
+	}{
+		{
+			name: "converts strings",
+			after: map[string]any{
+				"rating": "3", "done": "true", "due": "2024-01-05", "start": "2024-01-05 09:30", "tags": "#project",
+				"related": []any{"[[A]]", 2}, "title": 10.5, "other": "3",
+			},
+			want: map[string]any{
+				"rating": 3, "done": true, "due": DateValue("2024-01-05"), "start": "2024-01-05T09:30", "tags": []any{"project"},
+				"related": []any{"[[A]]", "2"}, "title": "10.5", "other": "3",
+			},
+			changed: true,
+		},
+		{
+			name:    "floats and empty numbers",
+			after:   map[string]any{"rating": " 2.5 ", "done": false},
+			want:    map[string]any{"rating": 2.5, "done": false},
+			changed: true,
+		},
+		{
+			name:   "leaves unchanged values alone",
+			before: map[string]any{"rating": "3", "due": time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+			after:  map[string]any{"rating": "3", "due": time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+			want:   map[string]any{"rating": "3", "due": time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := types.Coerce(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("Coerce() error = %v", err)
+			}
+			if changed != tt.changed || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Coerce() = %#v, %v, want %#v, %v", got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+
+	for _, after := range []map[string]any{{"rating": "three"}, {"done": "maybe"}, {"due": "Jan 5"}, {"start": "soon"}} {
+		if _, _, err := types.Coerce(nil, after); err == nil || !strings.Contains(err.Error(), "property") {
+			t.Errorf("Coerce(%v) error = %v, want a property error", after, err)
+		}
+	}
+}
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"title", "Note", Text},
+		{"related", []any{"a"}, List},
+		{"tags", "a", Tags},
+		{"aliases", []any{"a"}, Aliases},
+		{"rating", 3, Number},
+		{"done", true, Checkbox},
+		{"due", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Date},
+		{"start", time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC), DateTime},
+		{"meta", map[string]any{}, ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := Infer(tt.name, tt.value); got != tt.want {
+			t.Errorf("Infer(%s, %v) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoader(t *testing.T) {
+	vault := t.TempDir()
+	loader := NewLoader(vault)
+
+	types, err := loader.Load()
+	if err != nil || len(types) != 0 {
+		t.Fatalf("Load() without a file = %v, %v, want no types", types, err)
+	}
+
+	file := filepath.Join(vault, filepath.FromSlash(File))
+	os.MkdirAll(filepath.Dir(file), 0o755)
+	os.WriteFile(file, []byte(`{"types":{"due":"date","rating":"number"}}`), 0o644)
+	types, err = loader.Load()
+	if err != nil || !reflect.DeepEqual(types, Types{"due": Date, "rating": Number}) {
+		t.Errorf("Load() = %v, %v", types, err)
+	}
+
+	os.WriteFile(file, []byte(`{"types":`), 0o644)
+	if _, err := loader.Load(); err == nil || !strings.Contains(err.Error(), "invalid "+File) {
+		t.Errorf("Load() of an invalid file error = %v", err)
+	}
+}
@@ -0,0 +1,403 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/taigrr/obsidian-mcp/internal/values"
+)
+
+// expr is an expression evaluated against a page.
+type expr interface {
+	eval(p *Page) any
+}
+
+type (
+	literal struct{ value any }
+
+	// field is a field name followed by the members accessed on it, such
+	// as file.name.
+	field []string
+
+	listExpr []expr
+
+	logical struct {
+		or          bool
+		left, right expr
+	}
+
+	not struct{ inner expr }
+
+	comparison struct {
+		op          string
+		left, right expr
+	}
+
+	callExpr struct {
+		name string
+		fn   func(args []any) any
+		args []expr
+	}
+)
+
+func (e literal) eval(*Page) any { return e.value }
+
+func (e field) eval(p *Page) any {
+	value := p.field(e[0])
+	for _, member := range e[1:] {
+		value = memberOf(value, member)
+	}
+	return value
+}
+
+func (e listExpr) eval(p *Page) any {
+	list := make([]any, len(e))
+	for i, item := range e {
+		list[i] = item.eval(p)
+	}
+	return list
+}
+
+func (e logical) eval(p *Page) any {
+	left := truthy(e.left.eval(p))
+	if e.or {
+		return left || truthy(e.right.eval(p))
+	}
+	return left && truthy(e.right.eval(p))
+}
+
+func (e not) eval(p *Page) any { return !truthy(e.inner.eval(p)) }
+
+func (e comparison) eval(p *Page) any {
+	left, right := e.left.eval(p), e.right.eval(p)
+	switch e.op {
+	case "=":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	}
+	cmp, ok := compare(left, right)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func (e callExpr) eval(p *Page) any {
+	args := make([]any, len(e.args))
+	for i, arg := range e.args {
+		args[i] = arg.eval(p)
+	}
+	return e.fn(args)
+}
+
+// memberOf returns a member of an object or the year, month or day of a
+// date.
+func memberOf(value any, member string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return lookup(v, member)
+	case time.Time:
+		switch strings.ToLower(member) {
+		case "year":
+			return v.Year()
+		case "month":
+			return int(v.Month())
+		case "day":
+			return v.Day()
+		case "hour":
+			return v.Hour()
+		case "minute":
+			return v.Minute()
+		}
+	}
+	return nil
+}
+
+// lookup finds a field the way Dataview does: by its exact name, or else
+// by its name in lowercase with spaces as dashes, so "Due Date" can be
+// queried as due-date.
+func lookup(fields map[string]any, name string) any {
+	if value, ok := fields[name]; ok {
+		return value
+	}
+	want := normalizeKey(name)
+	for key, value := range fields {
+		if normalizeKey(key) == want {
+			return value
+		}
+	}
+	return nil
+}
+
+func normalizeKey(key string) string {
+	key = strings.Trim(strings.TrimSpace(key), "*_")
+	return strings.ToLower(strings.Join(strings.Fields(key), "-"))
+}
+
+// truthy reports whether a value counts as true in WHERE: false, null,
+// zero and empty strings, lists and objects do not.
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	if n, ok := values.Number(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+// equal compares two values. Numbers compare by value, dates by instant,
+// and a date matches text holding the same date, such as an inline field.
+func equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if cmp, ok := compare(a, b); ok {
+		return cmp == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare orders two numbers, two dates, a date and text holding a date,
+// two strings, two booleans or two lists.
+func compare(a, b any) (int, bool) {
+	if x, ok := values.Number(a); ok {
+		if y, ok := values.Number(b); ok {
+			return compareOrdered(x, y), true
+		}
+		return 0, false
+	}
+	_, aTime := a.(time.Time)
+	_, bTime := b.(time.Time)
+	if aTime || bTime {
+		x, ok := values.Time(a)
+		y, ok2 := values.Time(b)
+		if !ok || !ok2 {
+			return 0, false
+		}
+		return x.Compare(y), true
+	}
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, true
+			case y:
+				return -1, true
+			}
+			return 1, true
+		}
+	case []any:
+		y, ok := b.([]any)
+		if !ok {
+			return 0, false
+		}
+		for i := range min(len(x), len(y)) {
+			cmp, ok := compare(x[i], y[i])
+			if !ok {
+				return 0, false
+			}
+			if cmp != 0 {
+				return cmp, true
+			}
+		}
+		return compareOrdered(len(x), len(y)), true
+	}
+	return 0, false
+}
+
+func compareOrdered[T int | float64](x, y T) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// typeRank orders values of different types when sorting.
+func typeRank(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case int, int64, uint64, float64:
+		return 2
+	case time.Time:
+		return 3
+	case string:
+		return 4
+	case []any:
+		return 5
+	}
+	return 6
+}
+
+// sortCompare orders any two values: by type first, and by value within a
+// type, with nulls first.
+func sortCompare(a, b any) int {
+	if cmp, ok := compare(a, b); ok {
+		return cmp
+	}
+	if cmp := compareOrdered(typeRank(a), typeRank(b)); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// relativeDate returns the date named by today, now, yesterday or
+// tomorrow, relative to now.
+func relativeDate(name string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch strings.ToLower(name) {
+	case "today":
+		return today, true
+	case "now":
+		return now, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	}
+	return time.Time{}, false
+}
+
+type function struct {
+	minArgs, maxArgs int
+	call             func(args []any) any
+}
+
+func (f function) arity() string {
+	switch {
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+}
+
+// functions are the DQL functions supported in expressions.
+var functions = map[string]function{
+	"contains":  {2, 2, func(args []any) any { return contains(args[0], args[1], false) }},
+	"icontains": {2, 2, func(args []any) any { return contains(args[0], args[1], true) }},
+	"length": {1, 1, func(args []any) any {
+		switch v := args[0].(type) {
+		case string:
+			return len([]rune(v))
+		case []any:
+			return len(v)
+		case map[string]any:
+			return len(v)
+		}
+		return 0
+	}},
+	"lower": {1, 1, func(args []any) any { return mapString(args[0], strings.ToLower) }},
+	"upper": {1, 1, func(args []any) any { return mapString(args[0], strings.ToUpper) }},
+	"startswith": {2, 2, func(args []any) any {
+		s, ok := args[0].(string)
+		prefix, ok2 := args[1].(string)
+		return ok && ok2 && strings.HasPrefix(s, prefix)
+	}},
+	"endswith": {2, 2, func(args []any) any {
+		s, ok := args[0].(string)
+		suffix, ok2 := args[1].(string)
+		return ok && ok2 && strings.HasSuffix(s, suffix)
+	}},
+	"regexmatch": {2, 2, func(args []any) any {
+		pattern, ok := args[0].(string)
+		s, ok2 := args[1].(string)
+		if !ok || !ok2 {
+			return false
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		return err == nil && re.MatchString(s)
+	}},
+	"default": {2, 2, func(args []any) any {
+		if args[0] == nil {
+			return args[1]
+		}
+		return args[0]
+	}},
+	"date": {1, 1, func(args []any) any {
+		if t, ok := values.Time(args[0]); ok {
+			return t
+		}
+		return nil
+	}},
+}
+
+// contains reports whether a list holds a value, a string holds a
+// substring or an object holds a key.
+func contains(haystack, needle any, fold bool) bool {
+	switch h := haystack.(type) {
+	case []any:
+		for _, item := range h {
+			if s, ok := item.(string); ok && fold {
+				if n, ok := needle.(string); ok && strings.EqualFold(s, n) {
+					return true
+				}
+			}
+			if equal(item, needle) {
+				return true
+			}
+		}
+	case string:
+		n, ok := needle.(string)
+		if !ok {
+			return false
+		}
+		if fold {
+			return strings.Contains(strings.ToLower(h), strings.ToLower(n))
+		}
+		return strings.Contains(h, n)
+	case map[string]any:
+		n, ok := needle.(string)
+		return ok && lookup(h, n) != nil
+	}
+	return false
+}
+
+// mapString applies fn to a string or to the strings in a list.
+func mapString(v any, fn func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = mapString(item, fn)
+		}
+		return list
+	}
+	return v
+}
@@ -0,0 +1,129 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenDate
+	tokenTag
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // identifier, symbol or literal text, unquoted for strings
+	start int
+	end   int
+}
+
+// is reports whether t is the keyword or symbol s, ignoring case.
+func (t token) is(s string) bool {
+	return (t.kind == tokenIdent || t.kind == tokenSymbol) && strings.EqualFold(t.text, s)
+}
+
+var (
+	dateLiteral   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:T\d{2}:\d{2}(?::\d{2})?)?`)
+	numberLiteral = regexp.MustCompile(`^\d+(?:\.\d+)?`)
+	twoCharOps    = []string{"!=", "<=", ">="}
+)
+
+// lex splits a query into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '"':
+			text, end, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, start: i, end: end})
+			i = end
+		case r == '#':
+			end := i + 1
+			for end < len(src) {
+				r, size := utf8.DecodeRuneInString(src[end:])
+				if !isIdentRune(r) && r != '/' {
+					break
+				}
+				end += size
+			}
+			if end == i+1 {
+				return nil, fmt.Errorf("empty tag at position %d", i+1)
+			}
+			tokens = append(tokens, token{kind: tokenTag, text: src[i+1 : end], start: i, end: end})
+			i = end
+		case r >= '0' && r <= '9':
+			kind, m := tokenDate, dateLiteral.FindString(src[i:])
+			if m == "" {
+				kind, m = tokenNumber, numberLiteral.FindString(src[i:])
+			}
+			tokens = append(tokens, token{kind: kind, text: m, start: i, end: i + len(m)})
+			i += len(m)
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(src) {
+				r, size := utf8.DecodeRuneInString(src[end:])
+				if !isIdentRune(r) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i:end], start: i, end: end})
+			i = end
+		default:
+			op := string(r)
+			for _, two := range twoCharOps {
+				if strings.HasPrefix(src[i:], two) {
+					op = two
+				}
+			}
+			if !strings.Contains("=!<>()[],.-&|", op[:1]) {
+				return nil, fmt.Errorf("unexpected %q at position %d", op, i+1)
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: op, start: i, end: i + len(op)})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, start: len(src), end: len(src)}), nil
+}
+
+// lexString reads the double-quoted string starting at src[start] and
+// returns its text and the offset after the closing quote.
+func lexString(src string, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if i+1 < len(src) {
+				i++
+				b.WriteByte(src[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(src[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", start+1)
+}
+
+// isIdentRune reports whether r may appear in an identifier after its
+// first character. As in Dataview, field names may contain dashes.
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
@@ -0,0 +1,479 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/taigrr/obsidian-mcp/internal/values"
+)
+
+// Query types.
+const (
+	Table = "table"
+	List  = "list"
+)
+
+type (
+	// Query is a parsed DQL query.
+	Query struct {
+		Type      string
+		WithoutID bool
+		Columns   []Column
+		from      source
+		commands  []command
+	}
+
+	// Column is a TABLE column, or the value shown by a LIST query.
+	Column struct {
+		Name string
+		expr expr
+	}
+
+	// command is a data command, run in the order written: a WHERE, a
+	// SORT or a LIMIT.
+	command struct {
+		where expr
+		sort  []sortKey
+		limit *int
+	}
+
+	sortKey struct {
+		expr expr
+		desc bool
+	}
+)
+
+// unsupported are DQL commands and query types outside the subset.
+var unsupported = []string{"TASK", "CALENDAR", "FLATTEN", "GROUP"}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+// Parse parses a query in the supported subset of DQL:
+//
+//	TABLE [WITHOUT ID] expr [AS "name"], ... | LIST [WITHOUT ID] [expr]
+//	[FROM source]
+//	[WHERE expr] [SORT expr [ASC|DESC], ...] [LIMIT n] ...
+//
+// A source combines "folders" and #tags with and, or, - (not) and
+// parentheses. Expressions compare fields, literals and function calls
+// with =, !=, <, <=, >, >=, and, or and !.
+func Parse(src string) (*Query, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	return p.query()
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the keyword or symbol s.
+func (p *parser) accept(s string) bool {
+	if p.peek().is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	if !p.accept(s) {
+		return p.errorf("expected %s", s)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	t := p.peek()
+	found := "end of query"
+	if t.kind != tokenEOF {
+		found = fmt.Sprintf("%q", p.src[t.start:t.end])
+	}
+	return fmt.Errorf("%s at position %d, found %s", fmt.Sprintf(format, args...), t.start+1, found)
+}
+
+// atCommand reports whether the next token starts a clause.
+func (p *parser) atCommand() bool {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return true
+	}
+	for _, keyword := range append([]string{"FROM", "WHERE", "SORT", "LIMIT"}, unsupported...) {
+		if t.is(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) query() (*Query, error) {
+	for _, keyword := range unsupported {
+		if p.peek().is(keyword) {
+			return nil, fmt.Errorf("%s is not supported; use TABLE or LIST", strings.ToUpper(p.peek().text))
+		}
+	}
+
+	q := &Query{}
+	switch {
+	case p.accept("TABLE"):
+		q.Type = Table
+	case p.accept("LIST"):
+		q.Type = List
+	default:
+		return nil, p.errorf("expected TABLE or LIST")
+	}
+	if p.accept("WITHOUT") {
+		if err := p.expect("ID"); err != nil {
+			return nil, err
+		}
+		q.WithoutID = true
+	}
+
+	if !p.atCommand() {
+		for {
+			column, err := p.column()
+			if err != nil {
+				return nil, err
+			}
+			q.Columns = append(q.Columns, column)
+			if q.Type == List || !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if p.accept("FROM") {
+		from, err := p.sourceOr()
+		if err != nil {
+			return nil, err
+		}
+		q.from = from
+	}
+
+	for p.peek().kind != tokenEOF {
+		cmd, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		q.commands = append(q.commands, cmd)
+	}
+	return q, nil
+}
+
+func (p *parser) column() (Column, error) {
+	start := p.peek().start
+	e, err := p.expr()
+	if err != nil {
+		return Column{}, err
+	}
+	name := strings.TrimSpace(p.src[start:p.tokens[p.pos-1].end])
+	if p.accept("AS") {
+		t := p.peek()
+		if t.kind != tokenString && t.kind != tokenIdent {
+			return Column{}, p.errorf("expected a column name after AS")
+		}
+		p.next()
+		name = t.text
+	}
+	return Column{Name: name, expr: e}, nil
+}
+
+func (p *parser) command() (command, error) {
+	for _, keyword := range unsupported {
+		if t := p.peek(); t.is(keyword) {
+			return command{}, fmt.Errorf("%s at position %d is not supported", strings.ToUpper(t.text), t.start+1)
+		}
+	}
+
+	switch {
+	case p.accept("WHERE"):
+		e, err := p.expr()
+		return command{where: e}, err
+	case p.accept("SORT"):
+		var keys []sortKey
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return command{}, err
+			}
+			key := sortKey{expr: e}
+			switch {
+			case p.accept("DESC"), p.accept("DESCENDING"):
+				key.desc = true
+			case p.accept("ASC"), p.accept("ASCENDING"):
+			}
+			keys = append(keys, key)
+			if !p.accept(",") {
+				return command{sort: keys}, nil
+			}
+		}
+	case p.accept("LIMIT"):
+		n, err := strconv.Atoi(p.peek().text)
+		if p.peek().kind != tokenNumber || err != nil {
+			return command{}, p.errorf("expected a number after LIMIT")
+		}
+		p.next()
+		return command{limit: &n}, nil
+	case p.peek().is("FROM"):
+		return command{}, p.errorf("FROM must come right after the query type")
+	}
+	return command{}, p.errorf("expected WHERE, SORT or LIMIT")
+}
+
+// source selects pages by folder and tag.
+type source func(Page) bool
+
+func (p *parser) sourceOr() (source, error) {
+	left, err := p.sourceAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") || p.accept("|") {
+		right, err := p.sourceAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(page Page) bool { return l(page) || right(page) }
+	}
+	return left, nil
+}
+
+func (p *parser) sourceAnd() (source, error) {
+	left, err := p.sourceNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") || p.accept("&") {
+		right, err := p.sourceNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(page Page) bool { return l(page) && right(page) }
+	}
+	return left, nil
+}
+
+func (p *parser) sourceNot() (source, error) {
+	if p.accept("-") || p.accept("!") {
+		inner, err := p.sourceNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(page Page) bool { return !inner(page) }, nil
+	}
+	if p.accept("(") {
+		inner, err := p.sourceOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+
+	switch t := p.peek(); t.kind {
+	case tokenTag:
+		p.next()
+		tag := t.text
+		return func(page Page) bool { return page.hasTag(tag) }, nil
+	case tokenString:
+		p.next()
+		folder := strings.Trim(t.text, "/")
+		return func(page Page) bool { return page.inFolder(folder) }, nil
+	}
+	if p.peek().is("[") {
+		return nil, p.errorf("link sources are not supported")
+	}
+	return nil, p.errorf("expected a \"folder\" or #tag")
+}
+
+func (p *parser) expr() (expr, error) {
+	return p.or()
+}
+
+func (p *parser) or() (expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") || p.accept("|") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (expr, error) {
+	left, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") || p.accept("&") {
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) comparison() (expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "!=", "<", "<=", ">", ">="} {
+		if p.accept(op) {
+			right, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return comparison{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.accept("!") {
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{inner}, nil
+	}
+	if p.peek().is("-") && p.tokens[p.pos+1].kind == tokenNumber {
+		p.pos++
+		e, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		lit := e.(literal)
+		return literal{-lit.value.(float64)}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (expr, error) {
+	mark := p.pos
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return literal{t.text}, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, err
+		}
+		return literal{n}, nil
+	case tokenDate:
+		d, ok := values.ParseDate(t.text)
+		if !ok {
+			p.pos = mark
+			return nil, p.errorf("invalid date")
+		}
+		return literal{d}, nil
+	case tokenSymbol:
+		switch t.text {
+		case "(":
+			inner, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			var items []expr
+			for !p.accept("]") {
+				if len(items) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				item, err := p.expr()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			return listExpr(items), nil
+		}
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		}
+		if p.accept("(") {
+			return p.call(t)
+		}
+		f := field{t.text}
+		for p.accept(".") {
+			if p.peek().kind != tokenIdent {
+				return nil, p.errorf("expected a field name after .")
+			}
+			f = append(f, p.next().text)
+		}
+		return f, nil
+	}
+	p.pos = mark
+	return nil, p.errorf("expected a value")
+}
+
+// call parses the arguments of the function named by t.
+func (p *parser) call(t token) (expr, error) {
+	name := strings.ToLower(t.text)
+	fn, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at position %d", t.text, t.start+1)
+	}
+
+	var args []expr
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		// date(today) and the like name a date rather than a field.
+		if name == "date" && p.peek().kind == tokenIdent && p.tokens[p.pos+1].is(")") {
+			if d, ok := relativeDate(p.peek().text, time.Now()); ok {
+				p.pos++
+				args = append(args, literal{d})
+				continue
+			}
+		}
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("%s at position %d takes %s", name, t.start+1, fn.arity())
+	}
+	return callExpr{name: name, fn: fn.call, args: args}, nil
+}
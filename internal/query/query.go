@@ -0,0 +1,207 @@
+// Package query runs a subset of Dataview's query language (DQL) over the
+// notes of a vault: TABLE and LIST queries with FROM, WHERE, SORT and
+// LIMIT over frontmatter, inline fields and implicit file fields.
+package query
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/taigrr/obsidian-mcp/internal/tagtree"
+	"github.com/taigrr/obsidian-mcp/internal/values"
+)
+
+type (
+	// Page is a note as queries see it. Tags are without "#"; links are
+	// vault paths, or the link target if it does not resolve.
+	Page struct {
+		Path         string
+		Frontmatter  map[string]any
+		InlineFields map[string]any
+		Tags         []string
+		Outlinks     []string
+		Inlinks      []string
+		Size         int64
+		Modified     time.Time
+
+		file map[string]any
+	}
+
+	// Result is the result of a query.
+	Result struct {
+		Type    string
+		Columns []string
+		Rows    []Row
+	}
+
+	// Row is a page in a result and the values of its columns.
+	Row struct {
+		Path   string
+		Values []any
+	}
+)
+
+// dayPattern finds the date in the names of daily notes.
+var dayPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// field returns a field of the page: the implicit file object, or a
+// frontmatter or inline field. A field in both holds both values.
+func (p *Page) field(name string) any {
+	if name == "file" {
+		return p.fileFields()
+	}
+	fm, inline := lookup(p.Frontmatter, name), lookup(p.InlineFields, name)
+	switch {
+	case inline == nil:
+		return fm
+	case fm == nil:
+		return inline
+	}
+	return []any{fm, inline}
+}
+
+// fileFields returns the implicit file.* fields of the page.
+func (p *Page) fileFields() map[string]any {
+	if p.file != nil {
+		return p.file
+	}
+
+	name := strings.TrimSuffix(path.Base(p.Path), ".md")
+	folder := path.Dir(p.Path)
+	if folder == "." {
+		folder = ""
+	}
+	var tags, etags []any
+	seen := make(map[string]bool)
+	for _, tag := range p.Tags {
+		etags = append(etags, "#"+tag)
+		// file.tags holds every parent of a nested tag too.
+		for _, t := range tagtree.Ancestors(tag) {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, "#"+t)
+			}
+		}
+	}
+
+	p.file = map[string]any{
+		"name":     name,
+		"path":     p.Path,
+		"folder":   folder,
+		"ext":      strings.TrimPrefix(path.Ext(p.Path), "."),
+		"link":     p.Path,
+		"size":     int(p.Size),
+		"mtime":    p.Modified,
+		"tags":     orEmpty(tags),
+		"etags":    orEmpty(etags),
+		"outlinks": anyList(p.Outlinks),
+		"inlinks":  anyList(p.Inlinks),
+		"day":      nil,
+	}
+	if day, ok := values.ParseDate(dayPattern.FindString(name)); ok {
+		p.file["day"] = day
+	}
+	return p.file
+}
+
+func orEmpty(list []any) []any {
+	if list == nil {
+		return []any{}
+	}
+	return list
+}
+
+func anyList(list []string) []any {
+	result := make([]any, len(list))
+	for i, s := range list {
+		result[i] = s
+	}
+	return result
+}
+
+// hasTag reports whether the page has a tag or one nested below it.
+func (p *Page) hasTag(tag string) bool {
+	return tagtree.MatchesAny(p.Tags, strings.ToLower(tag), true)
+}
+
+// inFolder reports whether the page is in a folder, at any depth, or is
+// the note the path names.
+func (p *Page) inFolder(folder string) bool {
+	if folder == "" {
+		return true
+	}
+	return strings.HasPrefix(p.Path, folder+"/") || p.Path == folder || p.Path == folder+".md"
+}
+
+// Run runs the query over pages, which are taken in order until a SORT.
+func (q *Query) Run(pages []Page) Result {
+	var selected []*Page
+	for i := range pages {
+		if q.from == nil || q.from(pages[i]) {
+			selected = append(selected, &pages[i])
+		}
+	}
+
+	for _, cmd := range q.commands {
+		switch {
+		case cmd.where != nil:
+			selected = slices.DeleteFunc(selected, func(p *Page) bool { return !truthy(cmd.where.eval(p)) })
+		case cmd.sort != nil:
+			slices.SortStableFunc(selected, func(a, b *Page) int {
+				for _, key := range cmd.sort {
+					cmp := sortCompare(key.expr.eval(a), key.expr.eval(b))
+					if key.desc {
+						cmp = -cmp
+					}
+					if cmp != 0 {
+						return cmp
+					}
+				}
+				return 0
+			})
+		case cmd.limit != nil:
+			selected = selected[:min(*cmd.limit, len(selected))]
+		}
+	}
+
+	result := Result{Type: q.Type, Rows: make([]Row, 0, len(selected))}
+	for _, column := range q.Columns {
+		result.Columns = append(result.Columns, column.Name)
+	}
+	for _, p := range selected {
+		row := Row{Path: p.Path}
+		for _, column := range q.Columns {
+			row.Values = append(row.Values, Export(column.expr.eval(p)))
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result
+}
+
+// Export converts a value for output: dates without a time become plain
+// dates such as 2024-05-01, and other dates are formatted as RFC 3339.
+func Export(v any) any {
+	switch v := v.(type) {
+	case time.Time:
+		if v.Equal(v.Truncate(24*time.Hour)) && v.Location() == time.UTC {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = Export(item)
+		}
+		return list
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[key] = Export(item)
+		}
+		return m
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPages() []Page {
+	return []Page{
+		{
+			Path:        "daily/2024-05-01.md",
+			Frontmatter: map[string]any{"mood": "good"},
+			Tags:        []string{"daily"},
+			Modified:    time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			Path:         "projects/alpha.md",
+			Frontmatter:  map[string]any{"status": "active", "due": time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+			InlineFields: map[string]any{"Owner Name": "Ann", "effort": 3},
+			Tags:         []string{"project/active"},
+			Outlinks:     []string{"projects/beta.md"},
+		},
+		{
+			Path:         "projects/beta.md",
+			Frontmatter:  map[string]any{"status": "done"},
+			InlineFields: map[string]any{"due": "2024-04-15", "effort": 5},
+			Tags:         []string{"project"},
+			Inlinks:      []string{"projects/alpha.md"},
+		},
+		{
+			Path:         "projects/gamma.md",
+			Frontmatter:  map[string]any{"status": "active"},
+			InlineFields: map[string]any{"due": "2024-05-10", "effort": 1},
+			Tags:         []string{"project/active"},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+		rows    []Row
+	}{
+		{
+			name:  "list from folder",
+			query: `LIST FROM "projects"`,
+			rows:  []Row{{Path: "projects/alpha.md"}, {Path: "projects/beta.md"}, {Path: "projects/gamma.md"}},
+		},
+		{
+			name:    "table with where and sort",
+			query:   `TABLE status, due AS "Due date" FROM #project WHERE effort >= 3 SORT effort DESC`,
+			columns: []string{"status", "Due date"},
+			rows: []Row{
+				{Path: "projects/beta.md", Values: []any{"done", "2024-04-15"}},
+				{Path: "projects/alpha.md", Values: []any{"active", "2024-06-01"}},
+			},
+		},
+		{
+			name:  "nested tag and negation",
+			query: `list from #project and -#project/active`,
+			rows:  []Row{{Path: "projects/beta.md"}},
+		},
+		{
+			name:    "dates in frontmatter and inline fields",
+			query:   `TABLE WITHOUT ID file.name WHERE due < 2024-05-20 SORT due`,
+			columns: []string{"file.name"},
+			rows: []Row{
+				{Path: "projects/beta.md", Values: []any{"beta"}},
+				{Path: "projects/gamma.md", Values: []any{"gamma"}},
+			},
+		},
+		{
+			name:    "implicit fields",
+			query:   `TABLE file.folder, file.day, file.tags FROM "daily"`,
+			columns: []string{"file.folder", "file.day", "file.tags"},
+			rows:    []Row{{Path: "daily/2024-05-01.md", Values: []any{"daily", "2024-05-01", []any{"#daily"}}}},
+		},
+		{
+			name:  "links and functions",
+			query: `LIST WHERE contains(file.inlinks, "projects/alpha.md") OR length(file.outlinks) > 0`,
+			rows:  []Row{{Path: "projects/alpha.md"}, {Path: "projects/beta.md"}},
+		},
+		{
+			name:    "normalized field names",
+			query:   `LIST owner-name WHERE owner-name`,
+			columns: []string{"owner-name"},
+			rows:    []Row{{Path: "projects/alpha.md", Values: []any{"Ann"}}},
+		},
+		{
+			name:  "commands run in order",
+			query: `LIST FROM "projects" SORT effort LIMIT 2 WHERE status = "active"`,
+			rows:  []Row{{Path: "projects/gamma.md"}, {Path: "projects/alpha.md"}},
+		},
+		{
+			name:  "missing fields are null",
+			query: `LIST WHERE !status AND mood != null`,
+			rows:  []Row{{Path: "daily/2024-05-01.md"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := q.Run(testPages())
+			if !reflect.DeepEqual(got.Columns, tt.columns) || !reflect.DeepEqual(got.Rows, tt.rows) {
+				t.Errorf("Run() = %+v, want columns %v and rows %+v", got, tt.columns, tt.rows)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`TASK FROM "projects"`, "TASK is not supported"},
+		{`TABLE status FROM`, "expected a \"folder\" or #tag"},
+		{`LIST WHERE status = `, "expected a value at position 21"},
+		{`LIST WHERE nope(status)`, "unknown function nope"},
+		{`LIST WHERE contains(tags)`, "contains at position 12 takes 2 arguments"},
+		{`LIST LIMIT many`, "expected a number after LIMIT"},
+		{`LIST WHERE status = "open`, "unterminated string"},
+		{`LIST GROUP BY status`, "GROUP at position 6 is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
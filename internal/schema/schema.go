@@ -0,0 +1,426 @@
+// Package schema checks note frontmatter against schemas that a vault
+// defines in .obsidian-mcp/schemas.yaml.
+//
+// The file maps schema names to the notes they apply to and the fields
+// those notes must have:
+//
+//	project:
+//	  folder: projects
+//	  fields:
+//	    status: {type: string, required: true, enum: [active, paused, done]}
+//	    due: {type: date, required: true}
+//	meeting:
+//	  tag: meeting
+//	  fields:
+//	    attendees: {type: list, items: string}
+//	person:
+//	  type: person
+//	  fields:
+//	    email: {type: string, pattern: "^[^@]+@[^@]+$"}
+//
+// A schema applies to notes in any of its folders, notes tagged with any
+// of its tags (or a nested tag below them) in their frontmatter, and notes
+// whose type field is any of its types. A schema without folder, tag or
+// type applies to every note.
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taigrr/obsidian-mcp/internal/values"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the path of the schema file relative to the vault root.
+const File = ".obsidian-mcp/schemas.yaml"
+
+// Field types.
+const (
+	TypeString   = "string"
+	TypeNumber   = "number"
+	TypeInteger  = "integer"
+	TypeBoolean  = "boolean"
+	TypeDate     = "date"
+	TypeDateTime = "datetime"
+	TypeList     = "list"
+	TypeObject   = "object"
+)
+
+var types = []string{TypeString, TypeNumber, TypeInteger, TypeBoolean, TypeDate, TypeDateTime, TypeList, TypeObject}
+
+// Schema describes the frontmatter of a set of notes.
+type Schema struct {
+	Name    string           `yaml:"-"`
+	Folders stringList       `yaml:"folder"`
+	Tags    stringList       `yaml:"tag"`
+	Types   stringList       `yaml:"type"`
+	Fields  map[string]Field `yaml:"fields"`
+}
+
+// Field describes a frontmatter field. A field given as a plain string,
+// such as "due: date", only sets its type.
+type Field struct {
+	// Type is the type values must have; any type is allowed when empty.
+	Type     string `yaml:"type"`
+	Required bool   `yaml:"required"`
+	// Enum lists the allowed values.
+	Enum []any `yaml:"enum"`
+	// Pattern is a regular expression that strings, and the strings in a
+	// list, must match.
+	Pattern string `yaml:"pattern"`
+	// Min and Max bound numbers.
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
+	// Items is the type of the items of a list.
+	Items string `yaml:"items"`
+
+	pattern *regexp.Regexp
+}
+
+// UnmarshalYAML accepts a field as a mapping or as just its type.
+func (f *Field) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.Type = node.Value
+		return nil
+	}
+	type field Field
+	return node.Decode((*field)(f))
+}
+
+// stringList is a list of strings that may be written as a single string.
+type stringList []string
+
+// UnmarshalYAML accepts a string or a list of strings.
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Schemas is the set of schemas of a vault.
+type Schemas struct {
+	schemas []*Schema
+}
+
+// Parse parses a schema file.
+func Parse(data []byte) (*Schemas, error) {
+	var byName map[string]*Schema
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&byName); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	s := &Schemas{}
+	for name, schema := range byName {
+		if schema == nil {
+			return nil, fmt.Errorf("schema %s: empty", name)
+		}
+		schema.Name = name
+		for i, folder := range schema.Folders {
+			schema.Folders[i] = strings.Trim(path.Clean(filepath.ToSlash(folder)), "/")
+		}
+		for key, field := range schema.Fields {
+			if err := field.compile(); err != nil {
+				return nil, fmt.Errorf("schema %s, field %s: %w", name, key, err)
+			}
+			schema.Fields[key] = field
+		}
+		s.schemas = append(s.schemas, schema)
+	}
+	slices.SortFunc(s.schemas, func(a, b *Schema) int { return strings.Compare(a.Name, b.Name) })
+	return s, nil
+}
+
+func (f *Field) compile() error {
+	for _, t := range []string{f.Type, f.Items} {
+		if t != "" && !slices.Contains(types, t) {
+			return fmt.Errorf("unknown type %q (use %s)", t, strings.Join(types, ", "))
+		}
+	}
+	if f.Items != "" && f.Type != TypeList {
+		return fmt.Errorf("items requires type list")
+	}
+	if f.Pattern != "" {
+		re, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		f.pattern = re
+	}
+	return nil
+}
+
+// Len returns the number of schemas.
+func (s *Schemas) Len() int {
+	return len(s.schemas)
+}
+
+// For returns the schemas that apply to the note at notePath with the
+// given frontmatter.
+func (s *Schemas) For(notePath string, frontmatter map[string]any) []*Schema {
+	var matched []*Schema
+	for _, schema := range s.schemas {
+		if schema.matches(notePath, frontmatter) {
+			matched = append(matched, schema)
+		}
+	}
+	return matched
+}
+
+func (schema *Schema) matches(notePath string, frontmatter map[string]any) bool {
+	if len(schema.Folders) == 0 && len(schema.Tags) == 0 && len(schema.Types) == 0 {
+		return true
+	}
+
+	for _, folder := range schema.Folders {
+		if folder == "." || strings.HasPrefix(notePath, folder+"/") {
+			return true
+		}
+	}
+
+	for _, tag := range noteTags(frontmatter) {
+		for _, want := range schema.Tags {
+			want = strings.ToLower(strings.TrimPrefix(want, "#"))
+			if tag == want || strings.HasPrefix(tag, want+"/") {
+				return true
+			}
+		}
+	}
+
+	if noteType, ok := frontmatter["type"].(string); ok {
+		return slices.ContainsFunc(schema.Types, func(t string) bool { return strings.EqualFold(t, noteType) })
+	}
+	return false
+}
+
+// noteTags returns the lowercased tags in frontmatter.
+func noteTags(frontmatter map[string]any) []string {
+	var tags []string
+	add := func(v any) {
+		if tag, ok := v.(string); ok {
+			tags = append(tags, strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		}
+	}
+	switch v := frontmatter["tags"].(type) {
+	case []any:
+		for _, item := range v {
+			add(item)
+		}
+	case string:
+		for tag := range strings.FieldsFuncSeq(v, func(r rune) bool { return r == ',' || r == ' ' }) {
+			add(tag)
+		}
+	}
+	return tags
+}
+
+// Violation is a way in which a note does not match a schema.
+type Violation struct {
+	Path    string `json:"path"`
+	Schema  string `json:"schema"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s (schema %s)", v.Field, v.Message, v.Schema)
+}
+
+// Validate checks a note against the schemas that apply to it.
+func (s *Schemas) Validate(notePath string, frontmatter map[string]any) []Violation {
+	var violations []Violation
+	for _, schema := range s.For(notePath, frontmatter) {
+		keys := make([]string, 0, len(schema.Fields))
+		for key := range schema.Fields {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			if message := schema.Fields[key].check(frontmatter[key]); message != "" {
+				violations = append(violations, Violation{Path: notePath, Schema: schema.Name, Field: key, Message: message})
+			}
+		}
+	}
+	return violations
+}
+
+// check returns why value does not match the field, or "" if it does. A
+// nil value is a missing one.
+func (f Field) check(value any) string {
+	if value == nil {
+		if f.Required {
+			return "is required"
+		}
+		return ""
+	}
+
+	if !hasType(value, f.Type) {
+		return fmt.Sprintf("must be of type %s, got %s", f.Type, describe(value))
+	}
+
+	if len(f.Enum) > 0 && !slices.ContainsFunc(f.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+		allowed := make([]string, len(f.Enum))
+		for i, e := range f.Enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+		return fmt.Sprintf("must be one of %s, got %v", strings.Join(allowed, ", "), value)
+	}
+
+	if n, ok := values.Number(value); ok {
+		if f.Min != nil && n < *f.Min {
+			return fmt.Sprintf("must be at least %v, got %v", *f.Min, value)
+		}
+		if f.Max != nil && n > *f.Max {
+			return fmt.Sprintf("must be at most %v, got %v", *f.Max, value)
+		}
+	}
+
+	items, isList := value.([]any)
+	if !isList {
+		items = []any{value}
+	}
+	for i, item := range items {
+		if isList && f.Items != "" && !hasType(item, f.Items) {
+			return fmt.Sprintf("item %d must be of type %s, got %s", i+1, f.Items, describe(item))
+		}
+		if s, ok := item.(string); ok && f.pattern != nil && !f.pattern.MatchString(s) {
+			return fmt.Sprintf("must match %s, got %q", f.Pattern, s)
+		}
+	}
+	return ""
+}
+
+// hasType reports whether value is of the given field type. Dates may be
+// YAML timestamps or strings in a date format, since values written
+// through JSON are strings.
+func hasType(value any, fieldType string) bool {
+	switch fieldType {
+	case "":
+		return true
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeNumber:
+		_, ok := values.Number(value)
+		return ok
+	case TypeInteger:
+		n, ok := values.Number(value)
+		return ok && n == math.Trunc(n)
+	case TypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case TypeDate, TypeDateTime:
+		switch v := value.(type) {
+		case time.Time:
+			return true
+		case string:
+			if fieldType == TypeDate {
+				_, err := time.Parse(time.DateOnly, v)
+				return err == nil
+			}
+			_, ok := values.ParseDateTime(v)
+			return ok
+		}
+		return false
+	case TypeList:
+		_, ok := value.([]any)
+		return ok
+	case TypeObject:
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return false
+}
+
+func describe(value any) string {
+	switch value.(type) {
+	case string:
+		return fmt.Sprintf("%q", value)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprint(value)
+}
+
+// Error reports the violations that keep a note from being written.
+type Error struct {
+	Path       string
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.String()
+	}
+	return fmt.Sprintf("%s does not match its schema: %s", e.Path, strings.Join(messages, "; "))
+}
+
+// Loader loads a vault's schema file, reloading it when it changes.
+type Loader struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	schemas *Schemas
+}
+
+// NewLoader returns a loader for the schema file of the vault at
+// vaultPath.
+func NewLoader(vaultPath string) *Loader {
+	return &Loader{path: filepath.Join(vaultPath, filepath.FromSlash(File))}
+}
+
+// Load returns the vault's schemas; a vault without a schema file has
+// none.
+func (l *Loader) Load() (*Schemas, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	info, err := os.Stat(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		l.schemas = nil
+		return &Schemas{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.schemas != nil && info.ModTime().Equal(l.modTime) && info.Size() == l.size {
+		return l.schemas, nil
+	}
+
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", File, err)
+	}
+	l.schemas, l.modTime, l.size = schemas, info.ModTime(), info.Size()
+	return schemas, nil
+}
@@ -0,0 +1,169 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSchemas = `
+project:
+  folder: projects
+  fields:
+    status: {type: string, required: true, enum: [active, paused, done]}
+    due: {type: date, required: true}
+    rating: {type: integer, min: 1, max: 5}
+meeting:
+  tag: meeting
+  fields:
+    attendees: {type: list, items: string, pattern: "^\\[\\["}
+    start: datetime
+person:
+  type: [person, contact]
+  fields:
+    email: {type: string, pattern: "^[^@]+@[^@]+$"}
+`
+
+func TestParse(t *testing.T) {
+	schemas, err := Parse([]byte(testSchemas))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if schemas.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", schemas.Len())
+	}
+
+	empty, err := Parse(nil)
+	if err != nil || empty.Len() != 0 {
+		t.Errorf("Parse(nil) = %v, %v, want no schemas", empty, err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unknown key", "project:\n  folders: projects\n", "field folders not found"},
+		{"unknown type", "project:\n  fields:\n    due: day\n", "unknown type"},
+		{"items without list", "project:\n  fields:\n    tags: {type: string, items: string}\n", "items requires type list"},
+		{"invalid pattern", "project:\n  fields:\n    id: {pattern: \"(\"}\n", "invalid pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemas_Validate(t *testing.T) {
+	schemas, err := Parse([]byte(testSchemas))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		frontmatter map[string]any
+		want        []string
+	}{
+		{
+			name:        "valid project",
+			path:        "projects/alpha.md",
+			frontmatter: map[string]any{"status": "active", "due": time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), "rating": 4},
+		},
+		{
+			name:        "date as string",
+			path:        "projects/alpha.md",
+			frontmatter: map[string]any{"status": "done", "due": "2024-06-01", "rating": 5.0},
+		},
+		{
+			name:        "missing and invalid fields",
+			path:        "projects/deep/alpha.md",
+			frontmatter: map[string]any{"status": "started", "due": nil, "rating": 2.5},
+			want:        []string{"due is required", "rating must be of type integer, got 2.5", "status must be one of active, paused, done, got started"},
+		},
+		{
+			name:        "out of range",
+			path:        "projects/alpha.md",
+			frontmatter: map[string]any{"status": "paused", "due": "next week", "rating": 9},
+			want:        []string{`due must be of type date, got "next week"`, "rating must be at most 5, got 9"},
+		},
+		{
+			name:        "outside the folder",
+			path:        "projectsx/alpha.md",
+			frontmatter: map[string]any{},
+		},
+		{
+			name:        "nested tag",
+			path:        "notes/standup.md",
+			frontmatter: map[string]any{"tags": []any{"#Meeting/daily"}, "attendees": []any{"[[Sam]]", 3}, "start": "2024-06-01T09:30"},
+			want:        []string{"attendees item 2 must be of type string, got 3"},
+		},
+		{
+			name:        "pattern",
+			path:        "notes/standup.md",
+			frontmatter: map[string]any{"tags": "meeting", "attendees": []any{"Sam"}},
+			want:        []string{`attendees must match ^\[\[, got "Sam"`},
+		},
+		{
+			name:        "type field",
+			path:        "people/sam.md",
+			frontmatter: map[string]any{"type": "Contact", "email": "sam"},
+			want:        []string{`email must match ^[^@]+@[^@]+$, got "sam"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range schemas.Validate(tt.path, tt.frontmatter) {
+				got = append(got, v.Field+" "+v.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoader(t *testing.T) {
+	vault := t.TempDir()
+	loader := NewLoader(vault)
+
+	schemas, err := loader.Load()
+	if err != nil || schemas.Len() != 0 {
+		t.Fatalf("Load() without a file = %v, %v, want no schemas", schemas, err)
+	}
+
+	file := filepath.Join(vault, filepath.FromSlash(File))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("a:\n  fields:\n    title: string\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if schemas, err = loader.Load(); err != nil || schemas.Len() != 1 {
+		t.Fatalf("Load() = %v, %v, want 1 schema", schemas, err)
+	}
+
+	if err := os.WriteFile(file, []byte("a: {}\nb: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if schemas, err = loader.Load(); err != nil || schemas.Len() != 2 {
+		t.Fatalf("Load() after a change = %v, %v, want 2 schemas", schemas, err)
+	}
+
+	if err := os.WriteFile(file, []byte("a: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = loader.Load(); err == nil || !strings.Contains(err.Error(), "invalid "+File) {
+		t.Errorf("Load() of an invalid file error = %v", err)
+	}
+}
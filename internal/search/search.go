@@ -85,6 +85,17 @@ func (s *Service) SearchAdvanced(params types.SearchParamsAdvanced) ([]types.Sea
 		return nil, 0, err
 	}
 
+	// Restrict to the requested paths
+	if params.Paths != nil {
+		allowed := make(map[string]bool, len(params.Paths))
+		for _, p := range params.Paths {
+			allowed[filepath.Join(s.vaultPath, filepath.FromSlash(p))] = true
+		}
+		markdownFiles = slices.DeleteFunc(markdownFiles, func(path string) bool {
+			return !allowed[path]
+		})
+	}
+
 	// Sort files for stable ordering
 	sort.Strings(markdownFiles)
 
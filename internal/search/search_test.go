@@ -375,6 +375,38 @@ func TestService_SearchAdvanced(t *testing.T) {
 		}
 	})
 
+	t.Run("restricts search to paths", func(t *testing.T) {
+		tmpDir, svc := setupTestVault(t)
+		defer cleanupTestVault(t, tmpDir)
+
+		os.MkdirAll(filepath.Join(tmpDir, "sub"), 0o755)
+		os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("keyword"), 0o644)
+		os.WriteFile(filepath.Join(tmpDir, "sub", "b.md"), []byte("keyword"), 0o644)
+		os.WriteFile(filepath.Join(tmpDir, "c.md"), []byte("keyword"), 0o644)
+
+		results, total, err := svc.SearchAdvanced(types.SearchParamsAdvanced{
+			Query: "keyword",
+			Paths: []string{"sub/b.md", "c.md"},
+		})
+		if err != nil {
+			t.Fatalf("SearchAdvanced() error = %v", err)
+		}
+		if total != 2 || len(results) != 2 || results[0].Path != "c.md" || results[1].Path != "sub/b.md" {
+			t.Errorf("SearchAdvanced() = %v (total %d), want c.md and sub/b.md", results, total)
+		}
+
+		results, _, err = svc.SearchAdvanced(types.SearchParamsAdvanced{
+			Query: "keyword",
+			Paths: []string{},
+		})
+		if err != nil {
+			t.Fatalf("SearchAdvanced() error = %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("SearchAdvanced() with empty paths = %v, want no results", results)
+		}
+	})
+
 	t.Run("invalid regex returns error", func(t *testing.T) {
 		tmpDir, svc := setupTestVault(t)
 		defer cleanupTestVault(t, tmpDir)
@@ -0,0 +1,158 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Policy says which snapshots to keep: the Last most recent ones, and the
+// newest snapshot of each of the last Hourly hours, Daily days and Weekly
+// weeks that have one. A zero Policy keeps every snapshot.
+type Policy struct {
+	Last   int
+	Hourly int
+	Daily  int
+	Weekly int
+}
+
+// DefaultPolicy is the retention used when none is configured.
+var DefaultPolicy = Policy{Last: 10, Hourly: 24, Daily: 30, Weekly: 12}
+
+// ParsePolicy parses a policy like "last=10,hourly=24,daily=30,weekly=12".
+// Omitted keys are zero.
+func ParsePolicy(s string) (Policy, error) {
+	var p Policy
+	for part := range strings.SplitSeq(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || n < 0 {
+			return Policy{}, fmt.Errorf("invalid retention %q: want key=count", part)
+		}
+		switch strings.TrimSpace(key) {
+		case "last":
+			p.Last = n
+		case "hourly":
+			p.Hourly = n
+		case "daily":
+			p.Daily = n
+		case "weekly":
+			p.Weekly = n
+		default:
+			return Policy{}, fmt.Errorf("invalid retention %q: use last, hourly, daily or weekly", part)
+		}
+	}
+	return p, nil
+}
+
+// keep returns the IDs to keep out of ids, which are sorted oldest first.
+func (p Policy) keep(ids []string) map[string]bool {
+	keep := make(map[string]bool)
+	if p == (Policy{}) {
+		for _, id := range ids {
+			keep[id] = true
+		}
+		return keep
+	}
+
+	buckets := []struct {
+		count  int
+		key    func(id string) string
+		seen   map[string]bool
+		filled int
+	}{
+		{count: p.Last, key: func(id string) string { return id }},
+		{count: p.Hourly, key: func(id string) string { return id[:11] }},     // 20060102T15
+		{count: p.Daily, key: func(id string) string { return id[:8] }},       // 20060102
+		{count: p.Weekly, key: func(id string) string { return weekKey(id) }}, // ISO week
+	}
+	for i := len(ids) - 1; i >= 0; i-- {
+		id := ids[i]
+		for j := range buckets {
+			b := &buckets[j]
+			if b.filled >= b.count {
+				continue
+			}
+			if b.seen == nil {
+				b.seen = make(map[string]bool)
+			}
+			key := b.key(id)
+			if b.seen[key] {
+				continue
+			}
+			b.seen[key] = true
+			b.filled++
+			keep[id] = true
+		}
+	}
+	return keep
+}
+
+// weekKey returns the ISO year and week of a snapshot ID.
+func weekKey(id string) string {
+	t, err := parseID(id)
+	if err != nil {
+		return id
+	}
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-%02d", year, week)
+}
+
+// Prune deletes the snapshots the policy does not keep, and the contents
+// no remaining snapshot refers to. It returns the number of snapshots
+// deleted.
+func (s *Store) Prune() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.ids()
+	if err != nil {
+		return 0, err
+	}
+	return s.prune(ids)
+}
+
+func (s *Store) prune(ids []string) (int, error) {
+	keep := s.policy.keep(ids)
+	removed := 0
+	referenced := make(map[string]bool)
+	for _, id := range ids {
+		if !keep[id] {
+			if err := os.Remove(s.manifestPath(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return removed, err
+			}
+			removed++
+			continue
+		}
+		snap, err := s.load(id)
+		if err != nil {
+			return removed, err
+		}
+		for _, file := range snap.Notes {
+			referenced[file.Hash] = true
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	objects := filepath.Join(s.dir, "objects")
+	err := filepath.WalkDir(objects, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if !referenced[d.Name()] {
+			os.Remove(path)
+		}
+		return nil
+	})
+	return removed, err
+}
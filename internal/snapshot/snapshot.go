@@ -0,0 +1,326 @@
+// Package snapshot keeps point-in-time copies of a vault's notes. Note
+// contents are stored once per distinct content, addressed by their
+// SHA-256, and each snapshot is a manifest mapping note paths to contents,
+// so a snapshot only costs the notes that changed since the previous one.
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idLayout formats snapshot IDs, which sort chronologically.
+const idLayout = "20060102T150405Z"
+
+// Snapshot is the state of the vault's notes at a point in time.
+type Snapshot struct {
+	ID      string          `json:"id"`
+	Time    time.Time       `json:"time"`
+	Reason  string          `json:"reason"`
+	Changed int             `json:"changed"` // notes added, modified or removed since the previous snapshot
+	Notes   map[string]File `json:"notes"`
+}
+
+// File is a note in a snapshot.
+type File struct {
+	Hash    string    `json:"hash"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// Info summarizes a snapshot.
+type Info struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Reason  string    `json:"reason"`
+	Notes   int       `json:"notes"`
+	Changed int       `json:"changed"`
+}
+
+// Info returns the summary of the snapshot.
+func (s Snapshot) Info() Info {
+	return Info{ID: s.ID, Time: s.Time, Reason: s.Reason, Notes: len(s.Notes), Changed: s.Changed}
+}
+
+// Store keeps snapshots of a vault in a directory: contents in objects/
+// and manifests in snapshots/.
+type Store struct {
+	mu        sync.Mutex
+	dir       string
+	vaultPath string
+	allowed   func(path string) bool
+	policy    Policy
+}
+
+// New returns a store in dir for the vault at vaultPath. Only the notes,
+// the markdown files, for which allowed returns true are snapshotted;
+// attachments and hidden folders are always skipped. Snapshots beyond policy are pruned after each snapshot.
+func New(dir, vaultPath string, allowed func(path string) bool, policy Policy) *Store {
+	absDir, _ := filepath.Abs(dir)
+	return &Store{dir: absDir, vaultPath: vaultPath, allowed: allowed, policy: policy}
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Notes returns the sorted paths of the notes a snapshot would contain.
+func (s *Store) Notes() ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(s.vaultPath, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if fullPath != s.vaultPath && (strings.HasPrefix(d.Name(), ".") || fullPath == s.dir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+		rel, _ := filepath.Rel(s.vaultPath, fullPath)
+		rel = filepath.ToSlash(rel)
+		if s.allowed(rel) {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+// Take snapshots the vault. Notes whose size and modification time match
+// the latest snapshot are not read again. If nothing changed since the
+// latest snapshot, it is returned with false instead of taking a new one.
+func (s *Store) Take(reason string) (Info, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.ids()
+	if err != nil {
+		return Info{}, false, err
+	}
+	var latest Snapshot
+	if len(ids) > 0 {
+		if latest, err = s.load(ids[len(ids)-1]); err != nil {
+			return Info{}, false, err
+		}
+	}
+
+	paths, err := s.Notes()
+	if err != nil {
+		return Info{}, false, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	now := time.Now().UTC()
+	snap := Snapshot{Time: now, Reason: reason, Notes: make(map[string]File, len(paths))}
+	for _, path := range paths {
+		file, err := s.store(path, latest.Notes[path])
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Info{}, false, err
+		}
+		snap.Notes[path] = file
+		if prev, ok := latest.Notes[path]; !ok || prev.Hash != file.Hash {
+			snap.Changed++
+		}
+	}
+	for path := range latest.Notes {
+		if _, ok := snap.Notes[path]; !ok {
+			snap.Changed++
+		}
+	}
+	if latest.ID != "" && snap.Changed == 0 {
+		return latest.Info(), false, nil
+	}
+
+	snap.ID = now.Format(idLayout)
+	for i := 1; contains(ids, snap.ID); i++ {
+		snap.ID = fmt.Sprintf("%s-%d", now.Format(idLayout), i)
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return Info{}, false, err
+	}
+	if err := writeFile(s.manifestPath(snap.ID), data); err != nil {
+		return Info{}, false, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if _, err := s.prune(append(ids, snap.ID)); err != nil {
+		return snap.Info(), true, fmt.Errorf("snapshot %s taken, but pruning failed: %w", snap.ID, err)
+	}
+	return snap.Info(), true, nil
+}
+
+// store adds a note's content to the objects, unless prev shows it is
+// unchanged, and returns its entry.
+func (s *Store) store(path string, prev File) (File, error) {
+	fullPath := filepath.Join(s.vaultPath, filepath.FromSlash(path))
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return File{}, err
+	}
+	file := File{Size: info.Size(), ModTime: info.ModTime().UTC()}
+	if prev.Hash != "" && prev.Size == file.Size && prev.ModTime.Equal(file.ModTime) {
+		file.Hash = prev.Hash
+		return file, nil
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return File{}, err
+	}
+	sum := sha256.Sum256(data)
+	file.Hash = hex.EncodeToString(sum[:])
+	file.Size = int64(len(data))
+
+	objectPath := s.objectPath(file.Hash)
+	if _, err := os.Stat(objectPath); err == nil {
+		return file, nil
+	}
+	if err := writeFile(objectPath, data); err != nil {
+		return File{}, fmt.Errorf("failed to store %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// List returns the snapshots, newest first.
+func (s *Store) List() ([]Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]Info, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		snap, err := s.load(ids[i])
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, snap.Info())
+	}
+	return infos, nil
+}
+
+// Get returns the snapshot with the given ID.
+func (s *Store) Get(id string) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return Snapshot{}, fmt.Errorf("snapshot not found: %s", id)
+	}
+	snap, err := s.load(id)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Snapshot{}, fmt.Errorf("snapshot not found: %s", id)
+	}
+	return snap, err
+}
+
+// Read returns the content of a note in a snapshot, and false if the note
+// is not in it.
+func (s *Store) Read(snap Snapshot, path string) (string, bool, error) {
+	file, ok := snap.Notes[path]
+	if !ok {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(s.objectPath(file.Hash))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s from snapshot %s: %w", path, snap.ID, err)
+	}
+	return string(data), true, nil
+}
+
+// ids returns the IDs of the stored snapshots, oldest first.
+func (s *Store) ids() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "snapshots"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if _, err := parseID(id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *Store) load(id string) (Snapshot, error) {
+	data, err := os.ReadFile(s.manifestPath(id))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid snapshot %s: %w", id, err)
+	}
+	return snap, nil
+}
+
+func (s *Store) manifestPath(id string) string {
+	return filepath.Join(s.dir, "snapshots", id+".json")
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash[:2], hash)
+}
+
+// writeFile writes data to a temporary file and renames it into place, so
+// readers never see a partial object or manifest.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func contains(ids []string, id string) bool {
+	i := sort.SearchStrings(ids, id)
+	return i < len(ids) && ids[i] == id
+}
+
+// parseID returns the time of a snapshot ID.
+func parseID(id string) (time.Time, error) {
+	base, _, _ := strings.Cut(id, "-")
+	return time.Parse(idLayout, base)
+}
@@ -0,0 +1,184 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupStore(t *testing.T, policy Policy) (string, *Store) {
+	t.Helper()
+	vault := t.TempDir()
+	allowed := func(path string) bool { return !strings.HasPrefix(path, "private/") }
+	return vault, New(filepath.Join(vault, ".snapshots"), vault, allowed, policy)
+}
+
+func writeNote(t *testing.T, vault, path, content string) {
+	t.Helper()
+	full := filepath.Join(vault, path)
+	os.MkdirAll(filepath.Dir(full), 0o755)
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countObjects(t *testing.T, s *Store) int {
+	t.Helper()
+	n := 0
+	filepath.WalkDir(filepath.Join(s.Dir(), "objects"), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+func TestStore(t *testing.T) {
+	vault, s := setupStore(t, Policy{})
+	writeNote(t, vault, "a.md", "alpha\n")
+	writeNote(t, vault, "dir/b.md", "beta\n")
+	writeNote(t, vault, "dir/copy.md", "beta\n")
+	writeNote(t, vault, "image.png", "not a note")
+	writeNote(t, vault, "paper.pdf", "not a note")
+	writeNote(t, vault, "private/c.md", "filtered")
+	writeNote(t, vault, ".obsidian/app.md", "hidden")
+
+	first, taken, err := s.Take("manual")
+	if err != nil || !taken {
+		t.Fatalf("Take() = %+v, %v, %v", first, taken, err)
+	}
+	if first.Notes != 3 || first.Changed != 3 || first.Reason != "manual" {
+		t.Errorf("Take() = %+v, want 3 new notes", first)
+	}
+	if n := countObjects(t, s); n != 2 {
+		t.Errorf("objects = %d, want 2 for 2 distinct contents", n)
+	}
+
+	if info, taken, err := s.Take("periodic"); err != nil || taken || info.ID != first.ID {
+		t.Errorf("Take() without changes = %+v, %v, %v, want the first snapshot", info, taken, err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	writeNote(t, vault, "a.md", "alpha v2\n")
+	os.Remove(filepath.Join(vault, "dir", "copy.md"))
+	second, taken, err := s.Take("batch")
+	if err != nil || !taken {
+		t.Fatalf("Take() = %+v, %v, %v", second, taken, err)
+	}
+	if second.ID == first.ID || second.Notes != 2 || second.Changed != 2 {
+		t.Errorf("Take() = %+v, want a new snapshot with 2 changes", second)
+	}
+
+	infos, err := s.List()
+	if err != nil || len(infos) != 2 || infos[0].ID != second.ID {
+		t.Fatalf("List() = %+v, %v, want newest first", infos, err)
+	}
+
+	snap, err := s.Get(first.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if content, ok, err := s.Read(snap, "a.md"); err != nil || !ok || content != "alpha\n" {
+		t.Errorf("Read(a.md) = %q, %v, %v", content, ok, err)
+	}
+	if _, ok, _ := s.Read(snap, "missing.md"); ok {
+		t.Error("Read(missing.md) found a note")
+	}
+	for _, id := range []string{"", "../x", "20990101T000000Z"} {
+		if _, err := s.Get(id); err == nil {
+			t.Errorf("Get(%q) succeeded", id)
+		}
+	}
+}
+
+func TestStorePrunes(t *testing.T) {
+	vault, s := setupStore(t, Policy{Last: 1})
+	writeNote(t, vault, "a.md", "one\n")
+	first, _, _ := s.Take("manual")
+	time.Sleep(1100 * time.Millisecond)
+	writeNote(t, vault, "a.md", "two\n")
+	second, _, err := s.Take("manual")
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+
+	infos, _ := s.List()
+	if len(infos) != 1 || infos[0].ID != second.ID {
+		t.Errorf("List() = %+v, want only %s", infos, second.ID)
+	}
+	if _, err := s.Get(first.ID); err == nil {
+		t.Error("pruned snapshot still exists")
+	}
+	if n := countObjects(t, s); n != 1 {
+		t.Errorf("objects = %d, want the unreferenced content removed", n)
+	}
+}
+
+func TestPolicyKeep(t *testing.T) {
+	ids := []string{
+		"20260101T100000Z", // week 1
+		"20260110T100000Z", // week 2
+		"20260110T110000Z",
+		"20260111T090000Z",
+		"20260111T093000Z",
+		"20260111T094500Z",
+		"20260111T100000Z",
+	}
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []string
+	}{
+		{name: "zero keeps all", policy: Policy{}, want: ids},
+		{name: "last", policy: Policy{Last: 2}, want: []string{"20260111T094500Z", "20260111T100000Z"}},
+		{name: "hourly", policy: Policy{Hourly: 2}, want: []string{"20260111T094500Z", "20260111T100000Z"}},
+		{name: "daily", policy: Policy{Daily: 2}, want: []string{"20260110T110000Z", "20260111T100000Z"}},
+		{name: "weekly", policy: Policy{Weekly: 5}, want: []string{"20260101T100000Z", "20260111T100000Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep := tt.policy.keep(ids)
+			var got []string
+			for _, id := range ids {
+				if keep[id] {
+					got = append(got, id)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keep() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Policy
+		wantErr bool
+	}{
+		{input: "last=10,hourly=24,daily=30,weekly=12", want: DefaultPolicy},
+		{input: "daily=7", want: Policy{Daily: 7}},
+		{input: "", want: Policy{}},
+		{input: "monthly=3", wantErr: true},
+		{input: "last=-1", wantErr: true},
+		{input: "last", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePolicy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
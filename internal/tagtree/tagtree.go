@@ -0,0 +1,202 @@
+// Package tagtree handles Obsidian's nested tags, where "project/alpha" is
+// a child of "project".
+package tagtree
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// validTag matches the characters Obsidian allows in a tag.
+var validTag = regexp.MustCompile(`^[a-zA-Z0-9_/-]+$`)
+
+// Node is a tag in the hierarchy. Count is the number of notes carrying
+// exactly this tag; Total is the number of distinct notes carrying this tag
+// or any of its descendants.
+type Node struct {
+	Name     string
+	Tag      string
+	Count    int
+	Total    int
+	Children []*Node
+}
+
+// Normalize lowercases a tag and strips a leading "#" and surrounding
+// slashes.
+func Normalize(tag string) string {
+	tag = strings.TrimSpace(tag)
+	tag = strings.TrimPrefix(tag, "#")
+	return strings.ToLower(strings.Trim(tag, "/"))
+}
+
+// Matches reports whether tag matches query. With nested set, a parent tag
+// also matches all of its children, the way Obsidian's tag pane behaves:
+// "project" matches "project/alpha" but not "projects".
+func Matches(tag, query string, nested bool) bool {
+	tag, query = Normalize(tag), Normalize(query)
+	if tag == query {
+		return true
+	}
+	return nested && strings.HasPrefix(tag, query+"/")
+}
+
+// MatchesAny reports whether any of tags matches query.
+func MatchesAny(tags []string, query string, nested bool) bool {
+	for _, tag := range tags {
+		if Matches(tag, query, nested) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ancestors returns the tag and all of its parents, from the tag itself up
+// to the root: "a/b/c" yields "a/b/c", "a/b", "a".
+func Ancestors(tag string) []string {
+	tag = Normalize(tag)
+	if tag == "" {
+		return nil
+	}
+	result := []string{tag}
+	for {
+		idx := strings.LastIndex(tag, "/")
+		if idx <= 0 {
+			return result
+		}
+		tag = tag[:idx]
+		result = append(result, tag)
+	}
+}
+
+// HasPrefix reports whether tag falls under prefix. A prefix ending in "/"
+// selects only descendants ("project/" matches "project/alpha" but not
+// "project"); otherwise it is a plain string prefix on the normalized tag.
+func HasPrefix(tag, prefix string) bool {
+	prefix = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(prefix), "#"))
+	return strings.HasPrefix(Normalize(tag), prefix)
+}
+
+// Build builds the tag hierarchy from the tags of each note. Only tags
+// accepted by HasPrefix(tag, prefix) are counted. Roots and children are
+// sorted by name.
+func Build(noteTags [][]string, prefix string) []*Node {
+	nodes := make(map[string]*Node)
+	var get func(tag string) *Node
+	get = func(tag string) *Node {
+		if n, ok := nodes[tag]; ok {
+			return n
+		}
+		name := tag
+		if idx := strings.LastIndex(tag, "/"); idx != -1 {
+			name = tag[idx+1:]
+		}
+		n := &Node{Name: name, Tag: tag}
+		nodes[tag] = n
+		return n
+	}
+
+	for _, tags := range noteTags {
+		rolled := make(map[string]bool)
+		direct := make(map[string]bool)
+		for _, raw := range tags {
+			tag := Normalize(raw)
+			if tag == "" || !HasPrefix(tag, prefix) {
+				continue
+			}
+			direct[tag] = true
+			for _, ancestor := range Ancestors(tag) {
+				rolled[ancestor] = true
+			}
+		}
+		for tag := range direct {
+			get(tag).Count++
+		}
+		for tag := range rolled {
+			get(tag).Total++
+		}
+	}
+
+	var roots []*Node
+	for tag, n := range nodes {
+		idx := strings.LastIndex(tag, "/")
+		if idx == -1 {
+			roots = append(roots, n)
+			continue
+		}
+		parent := nodes[tag[:idx]]
+		parent.Children = append(parent.Children, n)
+	}
+
+	sortNodes(roots)
+	return roots
+}
+
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	for _, n := range nodes {
+		sortNodes(n.Children)
+	}
+}
+
+// Renamer maps one or more source tags onto a target tag. Nested tags move
+// with their parent: renaming "proj" to "project" also turns "proj/alpha"
+// into "project/alpha". Matching is case-insensitive; the case of a nested
+// suffix is kept.
+type Renamer struct {
+	from []string
+	to   string
+}
+
+// NewRenamer returns a Renamer that renames every tag in from to to.
+// Several sources merge them into one tag.
+func NewRenamer(from []string, to string) (*Renamer, error) {
+	target := strings.Trim(strings.TrimPrefix(strings.TrimSpace(to), "#"), "/")
+	if target == "" {
+		return nil, fmt.Errorf("target tag cannot be empty")
+	}
+	if !validTag.MatchString(target) {
+		return nil, fmt.Errorf("invalid target tag: %s", to)
+	}
+
+	r := &Renamer{to: target}
+	for _, tag := range from {
+		tag = Normalize(tag)
+		if tag == "" || slices.Contains(r.from, tag) {
+			continue
+		}
+		if tag == Normalize(target) && len(from) == 1 {
+			return nil, fmt.Errorf("source and target tags are the same: %s", tag)
+		}
+		r.from = append(r.from, tag)
+	}
+	if len(r.from) == 0 {
+		return nil, fmt.Errorf("at least one source tag is required")
+	}
+	return r, nil
+}
+
+// Rename returns the new name for tag and whether it is affected. A leading
+// "#" on tag is preserved.
+func (r *Renamer) Rename(tag string) (string, bool) {
+	hash := ""
+	name := strings.TrimSpace(tag)
+	if strings.HasPrefix(name, "#") {
+		hash, name = "#", name[1:]
+	}
+	normalized := strings.ToLower(name)
+
+	for _, from := range r.from {
+		if normalized == from {
+			return hash + r.to, true
+		}
+		if strings.HasPrefix(normalized, from+"/") {
+			return hash + r.to + name[len(from):], true
+		}
+	}
+	return tag, false
+}
@@ -0,0 +1,159 @@
+package tagtree
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		tag    string
+		query  string
+		nested bool
+		want   bool
+	}{
+		{tag: "project", query: "project", want: true},
+		{tag: "Project", query: "#project", want: true},
+		{tag: "project/alpha", query: "project", want: false},
+		{tag: "project/alpha", query: "project", nested: true, want: true},
+		{tag: "project/alpha/backend", query: "project/alpha", nested: true, want: true},
+		{tag: "projects", query: "project", nested: true, want: false},
+		{tag: "project", query: "project/alpha", nested: true, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := Matches(tt.tag, tt.query, tt.nested); got != tt.want {
+			t.Errorf("Matches(%q, %q, %v) = %v, want %v", tt.tag, tt.query, tt.nested, got, tt.want)
+		}
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	got := Ancestors("#Project/Alpha/backend")
+	want := []string{"project/alpha/backend", "project/alpha", "project"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Ancestors() = %v, want %v", got, want)
+	}
+	if got := Ancestors(""); got != nil {
+		t.Errorf("Ancestors(\"\") = %v, want nil", got)
+	}
+}
+
+func TestHasPrefix(t *testing.T) {
+	tests := []struct {
+		tag    string
+		prefix string
+		want   bool
+	}{
+		{tag: "project/alpha", prefix: "project/", want: true},
+		{tag: "project", prefix: "project/", want: false},
+		{tag: "projects", prefix: "project", want: true},
+		{tag: "daily", prefix: "", want: true},
+		{tag: "daily", prefix: "#dai", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := HasPrefix(tt.tag, tt.prefix); got != tt.want {
+			t.Errorf("HasPrefix(%q, %q) = %v, want %v", tt.tag, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	noteTags := [][]string{
+		{"project", "project/alpha"},
+		{"project/alpha/backend"},
+		{"project/beta", "daily"},
+		{"daily"},
+	}
+
+	got := Build(noteTags, "")
+	want := []*Node{
+		{Name: "daily", Tag: "daily", Count: 2, Total: 2},
+		{Name: "project", Tag: "project", Count: 1, Total: 3, Children: []*Node{
+			{Name: "alpha", Tag: "project/alpha", Count: 1, Total: 2, Children: []*Node{
+				{Name: "backend", Tag: "project/alpha/backend", Count: 1, Total: 1},
+			}},
+			{Name: "beta", Tag: "project/beta", Count: 1, Total: 1},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %s, want %s", dump(got), dump(want))
+	}
+}
+
+func TestBuildWithPrefix(t *testing.T) {
+	noteTags := [][]string{
+		{"project", "project/alpha"},
+		{"project/beta", "daily"},
+	}
+
+	got := Build(noteTags, "project/")
+	want := []*Node{
+		{Name: "project", Tag: "project", Count: 0, Total: 2, Children: []*Node{
+			{Name: "alpha", Tag: "project/alpha", Count: 1, Total: 1},
+			{Name: "beta", Tag: "project/beta", Count: 1, Total: 1},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %s, want %s", dump(got), dump(want))
+	}
+}
+
+func dump(nodes []*Node) string {
+	s := "["
+	for i, n := range nodes {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%s(%d/%d)%s", n.Tag, n.Count, n.Total, dump(n.Children))
+	}
+	return s + "]"
+}
+
+func TestRenamer(t *testing.T) {
+	r, err := NewRenamer([]string{"#proj", "Projects"}, "project")
+	if err != nil {
+		t.Fatalf("NewRenamer() error = %v", err)
+	}
+
+	tests := []struct {
+		tag     string
+		want    string
+		changed bool
+	}{
+		{tag: "proj", want: "project", changed: true},
+		{tag: "#Proj", want: "#project", changed: true},
+		{tag: "proj/Alpha", want: "project/Alpha", changed: true},
+		{tag: "projects", want: "project", changed: true},
+		{tag: "project", want: "project", changed: false},
+		{tag: "proj-old", want: "proj-old", changed: false},
+	}
+
+	for _, tt := range tests {
+		got, changed := r.Rename(tt.tag)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("Rename(%q) = %q, %v, want %q, %v", tt.tag, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestNewRenamerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		from []string
+		to   string
+	}{
+		{name: "no source", from: nil, to: "project"},
+		{name: "empty target", from: []string{"proj"}, to: "#"},
+		{name: "invalid target", from: []string{"proj"}, to: "my project"},
+		{name: "same tag", from: []string{"Project"}, to: "project"},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewRenamer(tt.from, tt.to); err == nil {
+			t.Errorf("NewRenamer(%s) error = nil, want error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Package tasks finds the tasks (checklist items) in a note, with their
+// status, nesting and the dates, recurrence and priority that the Tasks
+// plugin writes as emoji and Dataview writes as inline fields.
+package tasks
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/taigrr/obsidian-mcp/internal/markdown"
+	"github.com/taigrr/obsidian-mcp/internal/tokenizer"
+)
+
+// Status types. A status is the character between the brackets; the Tasks
+// plugin's defaults give it one of these types.
+const (
+	Todo       = "todo"
+	InProgress = "in_progress"
+	Done       = "done"
+	Cancelled  = "cancelled"
+	Other      = "other"
+)
+
+// Priorities, from highest to lowest.
+const (
+	Highest = "highest"
+	High    = "high"
+	Medium  = "medium"
+	Low     = "low"
+	Lowest  = "lowest"
+)
+
+// Task is a checklist item. Line and Parent are zero-based line indexes;
+// Parent is the line of the enclosing task, or -1. Dates are in the form
+// YYYY-MM-DD, and Text is the description without its metadata.
+type Task struct {
+	Line       int
+	Status     string
+	Text       string
+	Depth      int
+	Parent     int
+	Due        string
+	Scheduled  string
+	Start      string
+	Created    string
+	Done       string
+	Cancelled  string
+	Recurrence string
+	Priority   string
+	Tags       []string
+}
+
+// Type returns the type of the task's status.
+func (t Task) Type() string {
+	switch t.Status {
+	case " ":
+		return Todo
+	case "/":
+		return InProgress
+	case "x", "X":
+		return Done
+	case "-":
+		return Cancelled
+	}
+	return Other
+}
+
+// Open reports whether the task still needs doing: it is neither done
+// nor cancelled.
+func (t Task) Open() bool {
+	return t.Type() != Done && t.Type() != Cancelled
+}
+
+// Date returns one of the task's dates by name: due, scheduled, start,
+// created, done or cancelled.
+func (t Task) Date(name string) string {
+	switch name {
+	case "due":
+		return t.Due
+	case "scheduled":
+		return t.Scheduled
+	case "start":
+		return t.Start
+	case "created":
+		return t.Created
+	case "done":
+		return t.Done
+	case "cancelled":
+		return t.Cancelled
+	}
+	return ""
+}
+
+// PriorityRank orders priorities from highest (0) to lowest; a task
+// without one ranks between medium and low, as in the Tasks plugin.
+func PriorityRank(priority string) int {
+	switch priority {
+	case Highest:
+		return 0
+	case High:
+		return 1
+	case Medium:
+		return 2
+	case Low:
+		return 4
+	case Lowest:
+		return 5
+	}
+	return 3
+}
+
+var (
+	taskPattern     = regexp.MustCompile(`^([ \t]*)(?:>[ \t]*)*(?:[-*+]|\d+[.)])[ \t]+\[(.)\](?:[ \t]+|$)`)
+	listItemPattern = regexp.MustCompile(`^([ \t]*)(?:>[ \t]*)*(?:[-*+]|\d+[.)])(?:[ \t]|$)`)
+	blockIDPattern  = regexp.MustCompile(`[ \t]+\^[A-Za-z0-9-]+[ \t]*$`)
+
+	// dateSignifiers are the Tasks plugin's date emoji, each optionally
+	// followed by a variation selector.
+	dateSignifiers = map[string]string{
+		"📅": "due", "📆": "due", "🗓": "due",
+		"⏳": "scheduled", "⌛": "scheduled",
+		"🛫": "start",
+		"➕": "created",
+		"✅": "done",
+		"❌": "cancelled",
+	}
+	prioritySignifiers = map[string]string{
+		"🔺": Highest, "⏫": High, "🔼": Medium, "🔽": Low, "⏬": Lowest,
+	}
+	recurrenceSignifier = "🔁"
+
+	// dataviewFields maps Dataview's task field names to date names.
+	dataviewFields = map[string]string{
+		"due": "due", "scheduled": "scheduled", "start": "start",
+		"created": "created", "completion": "done", "cancelled": "cancelled",
+	}
+)
+
+// listItem is an open list item that later items may be nested in.
+type listItem struct {
+	indent int
+	task   int // line of the task, or -1 for a plain list item
+}
+
+// Parse returns the tasks in content, in order. Tasks inside code blocks
+// and comments are ignored.
+func Parse(content string) []Task {
+	lines, masked := markdown.Lines(content), markdown.Lines(tokenizer.Mask(content))
+
+	var tasks []Task
+	var stack []listItem
+	for i, line := range masked {
+		m := listItemPattern.FindStringSubmatch(line)
+		if m == nil {
+			// A line that is not indented ends the list.
+			if trimmed := strings.TrimSpace(line); trimmed != "" && indentWidth(line) == 0 {
+				stack = stack[:0]
+			}
+			continue
+		}
+
+		indent := indentWidth(m[1])
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		item := listItem{indent: indent, task: -1}
+
+		if tm := taskPattern.FindStringSubmatchIndex(line); tm != nil {
+			task := Task{
+				Line:   i,
+				Status: line[tm[4]:tm[5]],
+				Depth:  len(stack),
+				Parent: -1,
+			}
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].task >= 0 {
+					task.Parent = stack[j].task
+					break
+				}
+			}
+			parseDescription(&task, lines[i][tm[1]:])
+			tasks = append(tasks, task)
+			item.task = i
+		}
+		stack = append(stack, item)
+	}
+	return tasks
+}
+
+// parseDescription sets the text and metadata of a task from what follows
+// its checkbox.
+func parseDescription(task *Task, description string) {
+	for _, tag := range markdown.InlineTags(description) {
+		task.Tags = append(task.Tags, tag.Name)
+	}
+
+	// Dataview fields, such as [due:: 2024-05-01], are removed from the
+	// end backwards so offsets stay valid. A whole-line field such as
+	// "due:: 2024-05-01 [priority:: high]" ends at the first bracketed
+	// field after it.
+	fields := markdown.InlineFields(description)
+	slices.SortStableFunc(fields, func(a, b markdown.InlineField) int { return a.Start - b.Start })
+	for i := range fields {
+		if i+1 < len(fields) && fields[i+1].Start < fields[i].End {
+			fields[i].End = fields[i+1].Start
+			_, value, _ := strings.Cut(description[fields[i].Start:fields[i].End], "::")
+			fields[i].Value = strings.TrimSpace(value)
+		}
+	}
+	for _, field := range slices.Backward(fields) {
+		key := strings.ToLower(field.Key)
+		switch {
+		case dataviewFields[key] != "":
+			setDate(task, dataviewFields[key], field.Value)
+		case key == "repeat":
+			task.Recurrence = field.Value
+		case key == "priority":
+			task.Priority = strings.ToLower(field.Value)
+		default:
+			continue
+		}
+		description = description[:field.Start] + description[field.End:]
+	}
+
+	var text strings.Builder
+	for description != "" {
+		r, size := utf8.DecodeRuneInString(description)
+		signifier := string(r)
+		rest := strings.TrimPrefix(description[size:], "\uFE0F")
+
+		switch {
+		case dateSignifiers[signifier] != "":
+			value := strings.TrimLeft(rest, " \t")
+			if len(value) >= len(time.DateOnly) && setDate(task, dateSignifiers[signifier], value[:len(time.DateOnly)]) {
+				description = value[len(time.DateOnly):]
+				continue
+			}
+		case prioritySignifiers[signifier] != "":
+			task.Priority = prioritySignifiers[signifier]
+			description = rest
+			continue
+		case signifier == recurrenceSignifier:
+			end := nextSignifier(rest)
+			task.Recurrence = strings.TrimSpace(rest[:end])
+			description = rest[end:]
+			continue
+		}
+		text.WriteString(description[:size])
+		description = description[size:]
+	}
+
+	task.Text = blockIDPattern.ReplaceAllString(text.String(), "")
+	task.Text = strings.Join(strings.Fields(task.Text), " ")
+}
+
+// setDate sets a date of the task if value is a valid date.
+func setDate(task *Task, name, value string) bool {
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return false
+	}
+	switch name {
+	case "due":
+		task.Due = value
+	case "scheduled":
+		task.Scheduled = value
+	case "start":
+		task.Start = value
+	case "created":
+		task.Created = value
+	case "done":
+		task.Done = value
+	case "cancelled":
+		task.Cancelled = value
+	}
+	return true
+}
+
+// nextSignifier returns the offset of the first emoji signifier, tag or
+// block ID in s, which ends a recurrence rule.
+func nextSignifier(s string) int {
+	for i, r := range s {
+		signifier := string(r)
+		if dateSignifiers[signifier] != "" || prioritySignifiers[signifier] != "" || signifier == recurrenceSignifier {
+			return i
+		}
+		if (r == '#' || r == '^') && (i == 0 || s[i-1] == ' ') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// indentWidth returns the width of leading whitespace, counting a tab as
+// four spaces.
+func indentWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
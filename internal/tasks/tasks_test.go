@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := strings.Join([]string{
+		"# Project",
+		"- [ ] Write report #work 📅 2024-05-01 ⏳ 2024-04-28 ⏫",
+		"    - [x] Collect data ✅ 2024-04-20",
+		"    - Notes",
+		"        - [/] Draft intro 🛫 2024-04-25",
+		"- [-] Old idea ❌ 2024-04-01 ^old",
+		"- [ ] Water plants 🔁 every week when done 📅 2024-05-03",
+		"- [>] Call Bob [due:: 2024-05-02] [priority:: low] [repeat:: every month]",
+		"- plain item",
+		"```",
+		"- [ ] not a task",
+		"```",
+		"Paragraph",
+		"  - [ ] new list",
+		"- [ ] due:: 2024-05-01 [priority:: high]",
+		"- [ ] Review notes:: draft [due:: 2024-05-04]",
+	}, "\n")
+
+	want := []Task{
+		{Line: 1, Status: " ", Text: "Write report #work", Parent: -1, Due: "2024-05-01", Scheduled: "2024-04-28", Priority: High, Tags: []string{"work"}},
+		{Line: 2, Status: "x", Text: "Collect data", Depth: 1, Parent: 1, Done: "2024-04-20"},
+		{Line: 4, Status: "/", Text: "Draft intro", Depth: 2, Parent: 1, Start: "2024-04-25"},
+		{Line: 5, Status: "-", Text: "Old idea", Parent: -1, Cancelled: "2024-04-01"},
+		{Line: 6, Status: " ", Text: "Water plants", Parent: -1, Due: "2024-05-03", Recurrence: "every week when done"},
+		{Line: 7, Status: ">", Text: "Call Bob", Parent: -1, Due: "2024-05-02", Priority: Low, Recurrence: "every month"},
+		{Line: 13, Status: " ", Text: "new list", Parent: -1},
+		{Line: 14, Status: " ", Parent: -1, Due: "2024-05-01", Priority: High},
+		{Line: 15, Status: " ", Text: "Review notes:: draft", Parent: -1, Due: "2024-05-04"},
+	}
+	got := Parse(content)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestTaskType(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+		open   bool
+	}{
+		{" ", Todo, true},
+		{"/", InProgress, true},
+		{"x", Done, false},
+		{"X", Done, false},
+		{"-", Cancelled, false},
+		{"?", Other, true},
+	}
+	for _, tt := range tests {
+		task := Task{Status: tt.status}
+		if got := task.Type(); got != tt.want || task.Open() != tt.open {
+			t.Errorf("Task{Status: %q}.Type() = %q, Open() = %v, want %q, %v", tt.status, got, task.Open(), tt.want, tt.open)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Package tokenizer splits Obsidian markdown into prose and the spans that
+// are not prose: code, math, comments and URLs. Tag and link extraction
+// runs on prose only, so "#include" in a code block or "#anchor" in a URL
+// is not mistaken for a tag.
+package tokenizer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind identifies what a token contains.
+type Kind int
+
+const (
+	Prose Kind = iota
+	FencedCode
+	IndentedCode
+	InlineCode
+	MathBlock
+	InlineMath
+	HTMLComment
+	Comment
+	URL
+)
+
+var kindNames = map[Kind]string{
+	Prose:        "prose",
+	FencedCode:   "fenced-code",
+	IndentedCode: "indented-code",
+	InlineCode:   "inline-code",
+	MathBlock:    "math-block",
+	InlineMath:   "inline-math",
+	HTMLComment:  "html-comment",
+	Comment:      "comment",
+	URL:          "url",
+}
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Token is a span of content. Start and End are byte offsets; Text is
+// content[Start:End].
+type Token struct {
+	Kind  Kind
+	Text  string
+	Start int
+	End   int
+}
+
+var (
+	fencePattern    = regexp.MustCompile("^[ \t]{0,3}(`{3,}|~{3,})")
+	listItemPattern = regexp.MustCompile(`^[ \t]*([-*+]|\d+[.)])([ \t]|$)`)
+	urlPattern      = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://[^\s<>"'\]]+`)
+)
+
+// span is a non-prose region found while scanning.
+type span struct {
+	kind       Kind
+	start, end int
+}
+
+// Tokenize splits content into consecutive tokens that together cover all
+// of it. Adjacent prose is returned as a single token.
+func Tokenize(content string) []Token {
+	spans := blockSpans(content)
+	spans = append(spans, inlineSpans(content, spans)...)
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	var tokens []Token
+	add := func(kind Kind, start, end int) {
+		if start < end {
+			tokens = append(tokens, Token{Kind: kind, Text: content[start:end], Start: start, End: end})
+		}
+	}
+
+	pos := 0
+	for _, s := range spans {
+		if s.start < pos {
+			// Nested inside an earlier span, such as code inside a comment.
+			continue
+		}
+		add(Prose, pos, s.start)
+		add(s.kind, s.start, s.end)
+		pos = s.end
+	}
+	add(Prose, pos, len(content))
+	return tokens
+}
+
+// Mask returns content with every non-prose span blanked out. Newlines are
+// kept and every other byte is replaced by a space, so offsets and line
+// numbers in the result match the original content.
+func Mask(content string) string {
+	tokens := Tokenize(content)
+	if len(tokens) == 1 && tokens[0].Kind == Prose {
+		return content
+	}
+
+	b := []byte(content)
+	for _, token := range tokens {
+		if token.Kind == Prose {
+			continue
+		}
+		for i := token.Start; i < token.End; i++ {
+			if b[i] != '\n' {
+				b[i] = ' '
+			}
+		}
+	}
+	return string(b)
+}
+
+// blockSpans finds fenced code blocks, $$ math blocks and indented code
+// blocks. Each span covers whole lines, including the fences.
+func blockSpans(content string) []span {
+	var spans []span
+
+	var (
+		kind      Kind
+		fence     string
+		open      bool
+		openStart int
+		prevBlank = true
+		inList    bool
+	)
+
+	offset := 0
+	for line := range strings.SplitSeq(content, "\n") {
+		start, end := offset, offset+len(line)
+		offset = end + 1
+		trimmed := strings.TrimSpace(line)
+
+		if open {
+			closed := false
+			switch kind {
+			case FencedCode:
+				m := fencePattern.FindStringSubmatch(line)
+				closed = m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) &&
+					strings.Trim(trimmed, string(fence[0])) == ""
+			case MathBlock:
+				closed = strings.Contains(line, "$$")
+			case IndentedCode:
+				if trimmed != "" && !isIndented(line) {
+					spans = append(spans, span{kind: kind, start: openStart, end: lastNonBlankEnd(content, openStart, start)})
+					open = false
+				}
+			}
+			if closed {
+				spans = append(spans, span{kind: kind, start: openStart, end: end})
+				open = false
+				prevBlank = false
+				continue
+			}
+			if open {
+				continue
+			}
+		}
+
+		switch {
+		case fencePattern.MatchString(line):
+			kind, fence, open, openStart = FencedCode, fencePattern.FindStringSubmatch(line)[1], true, start
+		case strings.HasPrefix(trimmed, "$$"):
+			if strings.Contains(trimmed[2:], "$$") {
+				spans = append(spans, span{kind: MathBlock, start: start, end: end})
+			} else {
+				kind, open, openStart = MathBlock, true, start
+			}
+		case trimmed != "" && isIndented(line) && prevBlank && !inList:
+			kind, open, openStart = IndentedCode, true, start
+		}
+
+		switch {
+		case trimmed == "":
+		case listItemPattern.MatchString(line):
+			inList = true
+		case !isIndented(line):
+			inList = false
+		}
+		prevBlank = trimmed == ""
+	}
+
+	if open {
+		end := len(content)
+		if kind == IndentedCode {
+			end = lastNonBlankEnd(content, openStart, len(content))
+		}
+		spans = append(spans, span{kind: kind, start: openStart, end: end})
+	}
+	return spans
+}
+
+// inlineSpans finds inline code, inline math, comments and URLs outside
+// the given block spans.
+func inlineSpans(content string, blocks []span) []span {
+	var spans []span
+	next := 0
+	for i := 0; i < len(content); {
+		if next < len(blocks) && i >= blocks[next].start {
+			i = max(i, blocks[next].end)
+			next++
+			continue
+		}
+
+		rest := content[i:]
+		lineEnd := strings.IndexByte(rest, '\n')
+		if lineEnd == -1 {
+			lineEnd = len(rest)
+		}
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := closingIndex(rest, "<!--", "-->")
+			spans = append(spans, span{kind: HTMLComment, start: i, end: i + end})
+			i += end
+			continue
+		case strings.HasPrefix(rest, "%%"):
+			end := closingIndex(rest, "%%", "%%")
+			spans = append(spans, span{kind: Comment, start: i, end: i + end})
+			i += end
+			continue
+		case rest[0] == '`':
+			run := len(rest) - len(strings.TrimLeft(rest, "`"))
+			fence := rest[:run]
+			if closing := strings.Index(rest[run:lineEnd], fence); closing != -1 {
+				end := run + closing + run
+				spans = append(spans, span{kind: InlineCode, start: i, end: i + end})
+				i += end
+				continue
+			}
+			i += run
+			continue
+		case rest[0] == '$':
+			if end := inlineMathEnd(rest[:lineEnd]); end > 0 {
+				spans = append(spans, span{kind: InlineMath, start: i, end: i + end})
+				i += end
+				continue
+			}
+		case strings.HasPrefix(rest, "]("):
+			if closing := strings.IndexByte(rest[:lineEnd], ')'); closing != -1 {
+				spans = append(spans, span{kind: URL, start: i + 2, end: i + closing})
+				i += closing + 1
+				continue
+			}
+		case isWordStart(content, i):
+			if m := urlPattern.FindString(rest); m != "" {
+				m = strings.TrimRight(m, ".,;:!?)")
+				spans = append(spans, span{kind: URL, start: i, end: i + len(m)})
+				i += len(m)
+				continue
+			}
+		}
+		i++
+	}
+	return spans
+}
+
+// closingIndex returns the offset just past the closing delimiter, or the
+// length of s when the span is never closed. Obsidian hides the rest of
+// the note after an unclosed comment.
+func closingIndex(s, open, close string) int {
+	if idx := strings.Index(s[len(open):], close); idx != -1 {
+		return len(open) + idx + len(close)
+	}
+	return len(s)
+}
+
+// inlineMathEnd returns the end of a $...$ or $$...$$ span at the start of
+// line, or 0 if there is none. Like Obsidian, the opening $ must be followed
+// by a non-space and the closing $ preceded by one, so "$5 and $10" is not
+// math.
+func inlineMathEnd(line string) int {
+	delim := "$"
+	if strings.HasPrefix(line, "$$") {
+		delim = "$$"
+	}
+	body := line[len(delim):]
+	if body == "" || body[0] == ' ' || body[0] == '\t' {
+		return 0
+	}
+	for i := 1; i < len(body); i++ {
+		if strings.HasPrefix(body[i:], delim) && body[i-1] != ' ' && body[i-1] != '\t' && body[i-1] != '\\' {
+			return len(delim) + i + len(delim)
+		}
+	}
+	return 0
+}
+
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
+
+func isWordStart(content string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	c := content[i-1]
+	return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
+
+// lastNonBlankEnd returns the end of the last non-blank line between start
+// and end, so trailing blank lines are not counted as code.
+func lastNonBlankEnd(content string, start, end int) int {
+	block := strings.TrimRight(content[start:end], " \t\n")
+	return start + len(block)
+}
@@ -0,0 +1,133 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Kind
+		texts   []string
+	}{
+		{
+			name:    "prose only",
+			content: "Just #prose here.",
+			want:    []Kind{Prose},
+			texts:   []string{"Just #prose here."},
+		},
+		{
+			name:    "fenced code",
+			content: "before\n```c\n#include <stdio.h>\n```\nafter",
+			want:    []Kind{Prose, FencedCode, Prose},
+			texts:   []string{"before\n", "```c\n#include <stdio.h>\n```", "\nafter"},
+		},
+		{
+			name:    "tilde fence needs matching closer",
+			content: "~~~~\n```\n#code\n~~~~\n#prose",
+			want:    []Kind{FencedCode, Prose},
+			texts:   []string{"~~~~\n```\n#code\n~~~~", "\n#prose"},
+		},
+		{
+			name:    "unclosed fence runs to end",
+			content: "text\n```\n#code",
+			want:    []Kind{Prose, FencedCode},
+			texts:   []string{"text\n", "```\n#code"},
+		},
+		{
+			name:    "indented code after blank line",
+			content: "para\n\n    #define X\n    more\n\nafter",
+			want:    []Kind{Prose, IndentedCode, Prose},
+			texts:   []string{"para\n\n", "    #define X\n    more", "\n\nafter"},
+		},
+		{
+			name:    "indented paragraph continuation is prose",
+			content: "para\n    #continued",
+			want:    []Kind{Prose},
+			texts:   []string{"para\n    #continued"},
+		},
+		{
+			name:    "nested list items are prose",
+			content: "- item\n\n    - nested #tag",
+			want:    []Kind{Prose},
+			texts:   []string{"- item\n\n    - nested #tag"},
+		},
+		{
+			name:    "inline code",
+			content: "see `#2024` and ``a ` b`` done",
+			want:    []Kind{Prose, InlineCode, Prose, InlineCode, Prose},
+			texts:   []string{"see ", "`#2024`", " and ", "``a ` b``", " done"},
+		},
+		{
+			name:    "math",
+			content: "$$\n\\#x\n$$\ninline $#y$ costs $5 and $10",
+			want:    []Kind{MathBlock, Prose, InlineMath, Prose},
+			texts:   []string{"$$\n\\#x\n$$", "\ninline ", "$#y$", " costs $5 and $10"},
+		},
+		{
+			name:    "comments",
+			content: "a <!-- #hidden\nline --> b %% #secret %% c",
+			want:    []Kind{Prose, HTMLComment, Prose, Comment, Prose},
+			texts:   []string{"a ", "<!-- #hidden\nline -->", " b ", "%% #secret %%", " c"},
+		},
+		{
+			name:    "unclosed comment hides the rest",
+			content: "a %%\n#hidden",
+			want:    []Kind{Prose, Comment},
+			texts:   []string{"a ", "%%\n#hidden"},
+		},
+		{
+			name:    "urls",
+			content: "go to https://example.com/a#frag. or [x](notes.md#head) <http://b.org#c>",
+			want:    []Kind{Prose, URL, Prose, URL, Prose, URL, Prose},
+			texts:   []string{"go to ", "https://example.com/a#frag", ". or [x](", "notes.md#head", ") <", "http://b.org#c", ">"},
+		},
+		{
+			name:    "code inside comment",
+			content: "%%\n```\ncode\n```\n%%\n",
+			want:    []Kind{Comment, Prose},
+			texts:   []string{"%%\n```\ncode\n```\n%%", "\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := Tokenize(tt.content)
+
+			var kinds []Kind
+			var texts []string
+			var joined strings.Builder
+			for _, token := range tokens {
+				kinds = append(kinds, token.Kind)
+				texts = append(texts, token.Text)
+				joined.WriteString(token.Text)
+			}
+
+			if joined.String() != tt.content {
+				t.Fatalf("Tokenize() tokens do not cover content: %q", joined.String())
+			}
+			if len(kinds) != len(tt.want) {
+				t.Fatalf("Tokenize() kinds = %v, want %v (texts %q)", kinds, tt.want, texts)
+			}
+			for i := range kinds {
+				if kinds[i] != tt.want[i] || texts[i] != tt.texts[i] {
+					t.Errorf("Tokenize()[%d] = %v %q, want %v %q", i, kinds[i], texts[i], tt.want[i], tt.texts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	content := "#tag `#code`\n```\n#x\n```\n[[Link]] %% [[hidden]] %%"
+	want := "#tag " + strings.Repeat(" ", len("`#code`")) + "\n   \n  \n   \n[[Link]] " + strings.Repeat(" ", len("%% [[hidden]] %%"))
+
+	if got := Mask(content); got != want {
+		t.Errorf("Mask() = %q, want %q", got, want)
+	}
+	if got := Mask("plain"); got != "plain" {
+		t.Errorf("Mask(plain) = %q, want %q", got, "plain")
+	}
+}
@@ -0,0 +1,398 @@
+// Package trash moves deleted notes to a trash folder instead of removing
+// them, following Obsidian's "Deleted files" setting.
+package trash
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Option is Obsidian's trashOption setting.
+type Option string
+
+const (
+	// System moves files to the system trash.
+	System Option = "system"
+	// Local moves files to the .trash folder in the vault.
+	Local Option = "local"
+	// None deletes files permanently.
+	None Option = "none"
+)
+
+// LocalDir is the vault folder Obsidian uses as its local trash.
+const LocalDir = ".trash"
+
+// dateLayout is the DeletionDate format of the trash spec, in local time.
+const dateLayout = "2006-01-02T15:04:05"
+
+// ReadOption returns the trashOption from the vault's .obsidian/app.json.
+// It defaults to System, like Obsidian.
+func ReadOption(vaultPath string) Option {
+	data, err := os.ReadFile(filepath.Join(vaultPath, ".obsidian", "app.json"))
+	if err != nil {
+		return System
+	}
+	var settings struct {
+		TrashOption Option `json:"trashOption"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return System
+	}
+	switch settings.TrashOption {
+	case Local, None:
+		return settings.TrashOption
+	default:
+		return System
+	}
+}
+
+// Item is a file in a trash bin.
+type Item struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"` // original path relative to the vault
+	DeletedAt time.Time `json:"deletedAt"`
+	Size      int64     `json:"size"`
+	Location  Option    `json:"location"`
+}
+
+// Bin is a trash directory laid out as described by the FreeDesktop.org
+// trash specification: trashed files live in a files directory and a
+// matching .trashinfo file in an info directory records where each came
+// from and when it was deleted.
+type Bin struct {
+	location  Option
+	vaultPath string
+	filesDir  string
+	infoDir   string
+}
+
+// NewLocal returns the vault's .trash bin. Files sit directly in .trash, as
+// Obsidian puts them there; the info files are kept in .trash/.trashinfo.
+// Files trashed by Obsidian itself have no info file and are listed by name
+// with their modification time.
+func NewLocal(vaultPath string) *Bin {
+	dir := filepath.Join(vaultPath, LocalDir)
+	return &Bin{
+		location:  Local,
+		vaultPath: vaultPath,
+		filesDir:  dir,
+		infoDir:   filepath.Join(dir, ".trashinfo"),
+	}
+}
+
+// HasSystem reports whether the system trash follows the XDG spec, as it
+// does on Linux and the BSDs. Elsewhere NewSystem fails.
+func HasSystem() bool {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		return true
+	}
+	return false
+}
+
+// NewSystem returns the user's home trash as defined by the XDG spec:
+// $XDG_DATA_HOME/Trash, or ~/.local/share/Trash. Only items deleted from
+// vaultPath are listed.
+func NewSystem(vaultPath string) (*Bin, error) {
+	if !HasSystem() {
+		return nil, fmt.Errorf("the system trash is not supported on %s", runtime.GOOS)
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("cannot locate the system trash: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	dir := filepath.Join(dataHome, "Trash")
+	return &Bin{
+		location:  System,
+		vaultPath: vaultPath,
+		filesDir:  filepath.Join(dir, "files"),
+		infoDir:   filepath.Join(dir, "info"),
+	}, nil
+}
+
+// Location returns the Option this bin implements.
+func (b *Bin) Location() Option {
+	return b.location
+}
+
+// Put moves the file at fullPath, which must be inside the vault, into the
+// bin and returns the new item.
+func (b *Bin) Put(fullPath string) (Item, error) {
+	rel, err := filepath.Rel(b.vaultPath, fullPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return Item{}, fmt.Errorf("%s is outside the vault", fullPath)
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return Item{}, err
+	}
+	if err := os.MkdirAll(b.filesDir, 0o700); err != nil {
+		return Item{}, err
+	}
+	if err := os.MkdirAll(b.infoDir, 0o700); err != nil {
+		return Item{}, err
+	}
+
+	deletedAt := time.Now().Truncate(time.Second)
+	name, infoFile, err := b.reserve(filepath.Base(fullPath))
+	if err != nil {
+		return Item{}, err
+	}
+
+	// The info file is written first, so an interrupted move leaves an
+	// info file without a file rather than an orphaned file.
+	escaped := (&url.URL{Path: filepath.ToSlash(fullPath)}).EscapedPath()
+	_, err = fmt.Fprintf(infoFile, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", escaped, deletedAt.Format(dateLayout))
+	if closeErr := infoFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = moveFile(fullPath, filepath.Join(b.filesDir, name))
+	}
+	if err != nil {
+		os.Remove(b.infoPath(name))
+		return Item{}, err
+	}
+
+	return Item{
+		ID:        string(b.location) + ":" + name,
+		Path:      filepath.ToSlash(rel),
+		DeletedAt: deletedAt,
+		Size:      info.Size(),
+		Location:  b.location,
+	}, nil
+}
+
+// reserve picks a name that is free in the bin and creates its info file
+// exclusively, so concurrent deletions cannot pick the same name. Names
+// are made unique the way Obsidian does it: "Note.md", "Note 1.md", ...
+func (b *Bin) reserve(base string) (string, *os.File, error) {
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	for i := 0; ; i++ {
+		name := base
+		if i > 0 {
+			name = stem + " " + strconv.Itoa(i) + ext
+		}
+		if _, err := os.Lstat(filepath.Join(b.filesDir, name)); err == nil {
+			continue
+		}
+		f, err := os.OpenFile(b.infoPath(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return name, f, nil
+	}
+}
+
+func (b *Bin) infoPath(name string) string {
+	return filepath.Join(b.infoDir, name+".trashinfo")
+}
+
+// List returns the items in the bin that came from the vault, newest
+// first.
+func (b *Bin) List() ([]Item, error) {
+	entries, err := os.ReadDir(b.filesDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Item{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if b.location == Local && strings.HasPrefix(name, ".") {
+			continue
+		}
+		item, ok := b.item(name)
+		if ok {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].DeletedAt.Equal(items[j].DeletedAt) {
+			return items[i].DeletedAt.After(items[j].DeletedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+// item returns the item stored under name, and false if it does not exist
+// or belongs to another vault.
+func (b *Bin) item(name string) (Item, bool) {
+	info, err := os.Stat(filepath.Join(b.filesDir, name))
+	if err != nil {
+		return Item{}, false
+	}
+	item := Item{
+		ID:        string(b.location) + ":" + name,
+		Path:      name,
+		DeletedAt: info.ModTime(),
+		Size:      info.Size(),
+		Location:  b.location,
+	}
+
+	origPath, deletedAt, err := readInfo(b.infoPath(name))
+	switch {
+	case err == nil:
+		rel, relErr := filepath.Rel(b.vaultPath, filepath.FromSlash(origPath))
+		if relErr != nil || strings.HasPrefix(rel, "..") {
+			return Item{}, false
+		}
+		item.Path = filepath.ToSlash(rel)
+		if !deletedAt.IsZero() {
+			item.DeletedAt = deletedAt
+		}
+	case b.location == System:
+		// Without an info file there is no telling where it came from.
+		return Item{}, false
+	}
+	return item, true
+}
+
+// readInfo parses a .trashinfo file.
+func readInfo(path string) (string, time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer f.Close()
+
+	var origPath string
+	var deletedAt time.Time
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			origPath, err = url.PathUnescape(value)
+			if err != nil {
+				return "", time.Time{}, fmt.Errorf("invalid trash info %s: %w", path, err)
+			}
+		case "DeletionDate":
+			deletedAt, _ = time.ParseInLocation(dateLayout, value, time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", time.Time{}, err
+	}
+	if origPath == "" {
+		return "", time.Time{}, fmt.Errorf("invalid trash info %s: missing Path", path)
+	}
+	return origPath, deletedAt, nil
+}
+
+// Get returns the item with the given ID.
+func (b *Bin) Get(id string) (Item, error) {
+	name, ok := b.name(id)
+	if !ok {
+		return Item{}, fmt.Errorf("trash item not found: %s", id)
+	}
+	item, ok := b.item(name)
+	if !ok {
+		return Item{}, fmt.Errorf("trash item not found: %s", id)
+	}
+	return item, nil
+}
+
+// name returns the file name for an item ID of this bin.
+func (b *Bin) name(id string) (string, bool) {
+	name, ok := strings.CutPrefix(id, string(b.location)+":")
+	if !ok || name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	return name, true
+}
+
+// Restore moves an item back to fullPath, which must not exist.
+func (b *Bin) Restore(id, fullPath string) error {
+	name, ok := b.name(id)
+	if !ok {
+		return fmt.Errorf("trash item not found: %s", id)
+	}
+	if _, err := os.Lstat(fullPath); err == nil {
+		return fmt.Errorf("%s already exists", fullPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return err
+	}
+	if err := moveFile(filepath.Join(b.filesDir, name), fullPath); err != nil {
+		return err
+	}
+	os.Remove(b.infoPath(name))
+	return nil
+}
+
+// Remove permanently deletes an item.
+func (b *Bin) Remove(id string) error {
+	name, ok := b.name(id)
+	if !ok {
+		return fmt.Errorf("trash item not found: %s", id)
+	}
+	if err := os.RemoveAll(filepath.Join(b.filesDir, name)); err != nil {
+		return err
+	}
+	if err := os.Remove(b.infoPath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// moveFile renames src to dst, copying when they are on different file
+// systems, as the system trash may be.
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,145 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadOption(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     Option
+	}{
+		{name: "no settings", want: System},
+		{name: "local", settings: `{"trashOption":"local"}`, want: Local},
+		{name: "none", settings: `{"trashOption":"none"}`, want: None},
+		{name: "system", settings: `{"trashOption":"system"}`, want: System},
+		{name: "unknown", settings: `{"trashOption":"bin"}`, want: System},
+		{name: "invalid json", settings: `{`, want: System},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := t.TempDir()
+			if tt.settings != "" {
+				os.MkdirAll(filepath.Join(vault, ".obsidian"), 0o755)
+				os.WriteFile(filepath.Join(vault, ".obsidian", "app.json"), []byte(tt.settings), 0o644)
+			}
+			if got := ReadOption(vault); got != tt.want {
+				t.Errorf("ReadOption() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBin(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	for _, location := range []Option{Local, System} {
+		t.Run(string(location), func(t *testing.T) {
+			vault := t.TempDir()
+			bin := NewLocal(vault)
+			if location == System {
+				var err error
+				if bin, err = NewSystem(vault); err != nil {
+					t.Fatalf("NewSystem() error = %v", err)
+				}
+			}
+
+			note := filepath.Join(vault, "dir", "My Note.md")
+			os.MkdirAll(filepath.Dir(note), 0o755)
+			os.WriteFile(note, []byte("first"), 0o644)
+			first, err := bin.Put(note)
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			os.WriteFile(note, []byte("second"), 0o644)
+			second, err := bin.Put(note)
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+
+			if _, err := os.Stat(note); !os.IsNotExist(err) {
+				t.Errorf("Put() left %s in place", note)
+			}
+			if first.Path != "dir/My Note.md" || second.Path != "dir/My Note.md" {
+				t.Errorf("Put() paths = %q, %q, want dir/My Note.md", first.Path, second.Path)
+			}
+			if first.ID == second.ID {
+				t.Fatalf("Put() gave both items ID %q", first.ID)
+			}
+			if want := string(location) + ":My Note 1.md"; second.ID != want {
+				t.Errorf("Put() ID = %q, want %q", second.ID, want)
+			}
+
+			items, err := bin.List()
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(items) != 2 {
+				t.Fatalf("List() = %+v, want 2 items", items)
+			}
+			for _, item := range items {
+				if item.Path != "dir/My Note.md" || item.Location != location || item.DeletedAt.IsZero() {
+					t.Errorf("List() item = %+v", item)
+				}
+			}
+
+			if err := bin.Restore(second.ID, note); err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if data, _ := os.ReadFile(note); string(data) != "second" {
+				t.Errorf("Restore() content = %q, want %q", data, "second")
+			}
+			if err := bin.Restore(first.ID, note); err == nil {
+				t.Error("Restore() over an existing file succeeded")
+			}
+
+			if err := bin.Remove(first.ID); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if items, _ := bin.List(); len(items) != 0 {
+				t.Errorf("List() after Remove() = %+v, want none", items)
+			}
+			if _, err := bin.Get(first.ID); err == nil {
+				t.Error("Get() of a removed item succeeded")
+			}
+		})
+	}
+}
+
+func TestBinIgnoresOtherVaults(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	vault, other := t.TempDir(), t.TempDir()
+	note := filepath.Join(other, "note.md")
+	os.WriteFile(note, []byte("x"), 0o644)
+
+	otherBin, _ := NewSystem(other)
+	item, err := otherBin.Put(note)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	bin, _ := NewSystem(vault)
+	if items, _ := bin.List(); len(items) != 0 {
+		t.Errorf("List() = %+v, want none from another vault", items)
+	}
+	if _, err := bin.Get(item.ID); err == nil {
+		t.Error("Get() of another vault's item succeeded")
+	}
+}
+
+func TestBinRejectsInvalidIDs(t *testing.T) {
+	bin := NewLocal(t.TempDir())
+	for _, id := range []string{"", "local:", "system:note.md", "local:../note.md", "local:.trashinfo", "note.md"} {
+		if _, err := bin.Get(id); err == nil {
+			t.Errorf("Get(%q) succeeded", id)
+		}
+		if err := bin.Remove(id); err == nil {
+			t.Errorf("Remove(%q) succeeded", id)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package types
+
+type (
+	// NoteChange describes how an operation changes a note.
+	NoteChange struct {
+		Path   string `json:"path"`
+		Action string `json:"action"`          // "create", "modify", "rename" or "delete"
+		From   string `json:"from,omitempty"`  // source path of a rename
+		Diff   string `json:"diff,omitempty"`  // unified diff of the content, in dry runs
+		Trash  string `json:"trash,omitempty"` // trash item ID of a deleted note
+	}
+)
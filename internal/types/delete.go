@@ -5,12 +5,16 @@ type (
 	DeleteNoteParams struct {
 		Path        string `json:"path"`
 		ConfirmPath string `json:"confirmPath"`
+		IfMatch     string `json:"ifMatch,omitempty"` // fail unless the note is at this version
+		DryRun      bool   `json:"dryRun,omitempty"`  // report the change without deleting
 	}
 
 	// DeleteResult contains the result of a delete operation.
 	DeleteResult struct {
-		Success bool   `json:"success"`
-		Path    string `json:"path"`
-		Message string `json:"message"`
+		Success bool         `json:"success"`
+		Path    string       `json:"path"`
+		Message string       `json:"message"`
+		Trash   string       `json:"trash,omitempty"` // trash item ID, empty if deleted permanently
+		Changes []NoteChange `json:"changes,omitempty"`
 	}
 )
@@ -6,13 +6,17 @@ type (
 		OldPath   string `json:"oldPath"`
 		NewPath   string `json:"newPath"`
 		Overwrite bool   `json:"overwrite,omitempty"`
+		IfMatch   string `json:"ifMatch,omitempty"` // fail unless the old note is at this version
+		DryRun    bool   `json:"dryRun,omitempty"`  // report the change without moving
 	}
 
 	// MoveResult contains the result of a move operation.
 	MoveResult struct {
-		Success bool   `json:"success"`
-		OldPath string `json:"oldPath"`
-		NewPath string `json:"newPath"`
-		Message string `json:"message"`
+		Success bool         `json:"success"`
+		OldPath string       `json:"oldPath"`
+		NewPath string       `json:"newPath"`
+		Message string       `json:"message"`
+		Version string       `json:"version,omitempty"` // version token of the moved note
+		Changes []NoteChange `json:"changes,omitempty"`
 	}
 )
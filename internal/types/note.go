@@ -5,16 +5,21 @@ type (
 	// ParsedNote represents a parsed markdown note with frontmatter.
 	ParsedNote struct {
 		Frontmatter     map[string]any `json:"frontmatter"`
+		InlineFields    map[string]any `json:"inlineFields,omitempty"` // Dataview inline fields in the content
 		Content         string         `json:"content"`
 		OriginalContent string         `json:"originalContent"`
 	}
 
 	// NoteWriteParams contains parameters for writing a note.
 	NoteWriteParams struct {
-		Path        string         `json:"path"`
-		Content     string         `json:"content"`
-		Frontmatter map[string]any `json:"frontmatter,omitempty"`
-		Mode        string         `json:"mode,omitempty"` // "overwrite", "append", "prepend"
+		Path            string         `json:"path"`
+		Content         string         `json:"content"`
+		Frontmatter     map[string]any `json:"frontmatter,omitempty"`
+		Mode            string         `json:"mode,omitempty"`            // "overwrite", "append", "prepend"
+		Separator       string         `json:"separator,omitempty"`       // inserted between existing and new content (default "\n")
+		CreateIfMissing bool           `json:"createIfMissing,omitempty"` // append/prepend create missing notes and headings
+		Heading         string         `json:"heading,omitempty"`         // append/prepend within this heading's section
+		IfMatch         string         `json:"ifMatch,omitempty"`         // fail unless the note is at this version
 	}
 
 	// NoteInfo contains metadata about a note.
@@ -28,6 +28,9 @@ type (
 		ContextLines  int    `json:"contextLines,omitempty"`
 		Limit         int    `json:"limit,omitempty"`
 		Offset        int    `json:"offset,omitempty"`
+		// Paths restricts the search to these vault-relative paths when
+		// non-nil.
+		Paths []string `json:"paths,omitempty"`
 	}
 
 	// SearchMatchAdvanced represents a single match within a file.
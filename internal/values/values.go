@@ -0,0 +1,60 @@
+// Package values converts the loosely typed values found in frontmatter
+// and inline fields: numbers of any YAML width, and dates held as text.
+package values
+
+import (
+	"strings"
+	"time"
+)
+
+// DateTimeLayouts are the datetime formats accepted in text, including the
+// one Obsidian's date & time property writes.
+var DateTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// Number returns v as a float64 if it is a number.
+func Number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Time returns v as a time if it is one, or text that ParseDate accepts.
+func Time(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		return ParseDate(v)
+	}
+	return time.Time{}, false
+}
+
+// ParseDate parses a date in the form YYYY-MM-DD or a datetime in one of
+// DateTimeLayouts. Dates without a zone are in UTC, like the dates YAML
+// frontmatter holds.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true
+	}
+	return ParseDateTime(s)
+}
+
+// ParseDateTime parses a datetime in one of DateTimeLayouts.
+func ParseDateTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range DateTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,52 @@
+package values
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		value any
+		want  float64
+		ok    bool
+	}{
+		{3, 3, true},
+		{int64(-2), -2, true},
+		{uint64(7), 7, true},
+		{1.5, 1.5, true},
+		{"3", 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := Number(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("Number(%v) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Time
+		ok   bool
+	}{
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), true},
+		{" 2024-05-01T09:30 ", time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC), true},
+		{"2024-05-01 09:30", time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC), true},
+		{"2024-05-01 09:30:15", time.Date(2024, 5, 1, 9, 30, 15, 0, time.UTC), true},
+		{"2024-05-01T09:30:00Z", time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC), true},
+		{"May 1", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		if got, ok := ParseDate(tt.text); !got.Equal(tt.want) || ok != tt.ok {
+			t.Errorf("ParseDate(%q) = %v, %v, want %v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+	if _, ok := ParseDateTime("2024-05-01"); ok {
+		t.Error("ParseDateTime(2024-05-01) ok = true, want false for a date without a time")
+	}
+	if got, ok := Time(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)); !ok || got.Day() != 1 {
+		t.Errorf("Time(time.Time) = %v, %v", got, ok)
+	}
+}